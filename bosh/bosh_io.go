@@ -6,10 +6,15 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
 )
 
 const (
-	boshIOAPIURL = "https://bosh.io/api/v1/stemcells/%s"
+	boshIOAPIURL         = "https://bosh.io/api/v1/stemcells/%s"
+	boshIOReleasesAPIURL = "https://bosh.io/api/v1/releases/%s"
+	boshIODownloadURL    = "https://bosh.io/d/"
 )
 
 var (
@@ -34,12 +39,23 @@ type BoshIOStemcell struct {
 	Sha1    string
 }
 
+type BoshIORelease struct {
+	Name    string
+	Version string
+	URL     string
+	Sha1    string
+}
+
 //go:generate counterfeiter . BoshIO
 type BoshIO interface {
 	Stemcells(name string) ([]byte, error)
+	Releases(repository string) ([]byte, error)
 }
 
 type BoshIOClient struct {
+	// ReleasesAPIURL is the URL of the releases of a repository, with a %s
+	// for the repository. It defaults to the bosh.io API.
+	ReleasesAPIURL string
 }
 
 func (c BoshIOClient) Stemcells(name string) ([]byte, error) {
@@ -52,6 +68,25 @@ func (c BoshIOClient) Stemcells(name string) ([]byte, error) {
 	return io.ReadAll(resp.Body)
 }
 
+func (c BoshIOClient) Releases(repository string) ([]byte, error) {
+	releasesAPIURL := c.ReleasesAPIURL
+	if releasesAPIURL == "" {
+		releasesAPIURL = boshIOReleasesAPIURL
+	}
+
+	resp, err := http.Get(fmt.Sprintf(releasesAPIURL, repository))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close() //nolint:errcheck
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("could not list the releases of %s on bosh.io: %s", repository, resp.Status)
+	}
+
+	return io.ReadAll(resp.Body)
+}
+
 func LookupBoshIOStemcell(c BoshIO, cpi, os, version string, light bool) (BoshIOStemcell, error) {
 	if version == "latest" {
 		return BoshIOStemcell{},
@@ -115,3 +150,112 @@ func stemcellName(cpi, os string) (string, error) {
 	}
 	return fmt.Sprintf("bosh-%s-%s-go_agent", name, os), nil
 }
+
+// LookupBoshIORelease finds a release on bosh.io, in the repository of its
+// bosh.io url.
+func LookupBoshIORelease(c BoshIO, release Release) (BoshIORelease, error) {
+	if release.Version == "latest" {
+		return BoshIORelease{},
+			errors.New("Auto upload of \"latest\" release is not support, please use bosh-io-release-resource") //nolint:staticcheck
+	}
+
+	if !IsBoshIOURL(release.URL) {
+		return BoshIORelease{}, fmt.Errorf("release %s has no bosh.io url to find it by, add e.g. url: %sgithub.com/<owner>/<repository>", release.Name, boshIODownloadURL)
+	}
+	repository := boshIORepository(release.URL)
+
+	releases, err := c.Releases(repository)
+	if err != nil {
+		return BoshIORelease{}, err
+	}
+
+	return filterReleases(releases, release.Name, release.Version)
+}
+
+// boshIORepository is the repository of a bosh.io release download url, e.g.
+// github.com/concourse/concourse-bosh-release.
+func boshIORepository(url string) string {
+	return strings.SplitN(strings.TrimPrefix(url, boshIODownloadURL), "?", 2)[0]
+}
+
+// IsBoshIOURL reports whether a manifest url points at a bosh.io download.
+func IsBoshIOURL(url string) bool {
+	return strings.HasPrefix(url, boshIODownloadURL)
+}
+
+func filterReleases(raw []byte, name, version string) (BoshIORelease, error) {
+	var releases []struct {
+		Version string
+		URL     string
+		Sha1    string
+	}
+
+	err := json.Unmarshal(raw, &releases)
+	if err != nil {
+		return BoshIORelease{}, err
+	}
+
+	for _, r := range releases {
+		if r.Version == version {
+			return BoshIORelease{
+				Name:    name,
+				Version: r.Version,
+				URL:     r.URL,
+				Sha1:    r.Sha1,
+			}, nil
+		}
+	}
+	return BoshIORelease{}, fmt.Errorf("did not find release %s with version: %s", name, version)
+}
+
+// isBoshIOMetadataDir reports whether path is the output of a bosh-io-release
+// or bosh-io-stemcell resource get that skipped downloading the tarball.
+func isBoshIOMetadataDir(path string) bool {
+	info, err := os.Stat(filepath.Join(path, "url"))
+	return err == nil && !info.IsDir()
+}
+
+func readBoshIOMetadata(dir string) (url, version, sha1 string, err error) {
+	values := map[string]string{}
+	for _, name := range []string{"url", "version", "sha1"} {
+		contents, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return "", "", "", fmt.Errorf("Could not read %s from %s: %s", name, dir, err) //nolint:staticcheck
+		}
+		values[name] = strings.TrimSpace(string(contents))
+	}
+	return values["url"], values["version"], values["sha1"], nil
+}
+
+// boshIOURLName is the last path segment of a bosh.io download url, e.g. the
+// repository of a release or the name of a stemcell.
+func boshIOURLName(url string) string {
+	path := strings.SplitN(url, "?", 2)[0]
+	return path[strings.LastIndex(path, "/")+1:]
+}
+
+// stemcellNameFromURL returns the name of a stemcell from either its bosh.io
+// download url or the url of its tarball, e.g.
+// light-bosh-stemcell-1.50-google-kvm-ubuntu-jammy-go_agent.tgz.
+func stemcellNameFromURL(url, version string) string {
+	name := strings.TrimSuffix(boshIOURLName(url), ".tgz")
+	name = strings.TrimPrefix(name, "light-")
+	if strings.HasPrefix(name, "bosh-stemcell-") {
+		name = "bosh-" + strings.TrimPrefix(name, fmt.Sprintf("bosh-stemcell-%s-", version))
+	}
+	return name
+}
+
+// stemcellOS extracts the operating system from a stemcell name such as
+// bosh-google-kvm-ubuntu-jammy-go_agent.
+func stemcellOS(name string) string {
+	name = strings.TrimSuffix(strings.TrimPrefix(name, "bosh-"), "-go_agent")
+	for _, infrastructure := range cpiStemcellMap {
+		if strings.HasPrefix(name, infrastructure+"-") {
+			return strings.TrimPrefix(name, infrastructure+"-")
+		}
+	}
+
+	parts := strings.SplitN(name, "-", 3)
+	return parts[len(parts)-1]
+}
@@ -1,6 +1,9 @@
 package bosh_test
 
 import (
+	"net/http"
+	"net/http/httptest"
+
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 
@@ -93,3 +96,105 @@ var _ = Describe("LookupBoshIOStemcell", func() {
 		})
 	})
 })
+
+var _ = Describe("LookupBoshIORelease", func() {
+	var (
+		fakeBoshIOClient *boshfakes.FakeBoshIO
+		release          Release
+	)
+
+	BeforeEach(func() {
+		fakeBoshIOClient = new(boshfakes.FakeBoshIO)
+		fakeBoshIOClient.ReleasesReturns([]byte(`[{
+			"name":"github.com/cloudfoundry/routing-release",
+			"version":"0.280.0",
+			"url":"https://bosh.io/d/github.com/cloudfoundry/routing-release?v=0.280.0",
+			"sha1":"routing-sha"
+		}]`), nil)
+		release = Release{Name: "routing", Version: "0.280.0", URL: "https://bosh.io/d/github.com/cloudfoundry/routing-release?v=0.279.0"}
+	})
+
+	It("returns the release", func() {
+		boshIORelease, err := LookupBoshIORelease(fakeBoshIOClient, release)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(boshIORelease).To(Equal(BoshIORelease{
+			Name:    "routing",
+			Version: "0.280.0",
+			URL:     "https://bosh.io/d/github.com/cloudfoundry/routing-release?v=0.280.0",
+			Sha1:    "routing-sha",
+		}))
+	})
+
+	It("looks the release up in the repository of its bosh.io url", func() {
+		_, err := LookupBoshIORelease(fakeBoshIOClient, release)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(fakeBoshIOClient.ReleasesArgsForCall(0)).To(Equal("github.com/cloudfoundry/routing-release"))
+	})
+
+	Context("when the release has no bosh.io url", func() {
+		It("raises an error", func() {
+			release.URL = ""
+
+			_, err := LookupBoshIORelease(fakeBoshIOClient, release)
+			Expect(err).To(MatchError("release routing has no bosh.io url to find it by, add e.g. url: https://bosh.io/d/github.com/<owner>/<repository>"))
+			Expect(fakeBoshIOClient.ReleasesCallCount()).To(Equal(0))
+		})
+	})
+
+	Context("when using the latest release", func() {
+		It("raises an error", func() {
+			release.Version = "latest"
+
+			_, err := LookupBoshIORelease(fakeBoshIOClient, release)
+			Expect(err).To(
+				MatchError("Auto upload of \"latest\" release is not support, please use bosh-io-release-resource"))
+		})
+	})
+
+	Context("when release version not found", func() {
+		It("raises an error", func() {
+			release.Version = "non-existing-version"
+
+			_, err := LookupBoshIORelease(fakeBoshIOClient, release)
+			Expect(err).To(
+				MatchError("did not find release routing with version: non-existing-version"))
+		})
+	})
+})
+
+var _ = Describe("BoshIOClient", func() {
+	Describe("Releases", func() {
+		var (
+			server *httptest.Server
+			client BoshIOClient
+		)
+
+		BeforeEach(func() {
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/api/v1/releases/github.com/cloudfoundry/routing-release" {
+					http.NotFound(w, r)
+					return
+				}
+				w.Write([]byte(`[{"version":"0.280.0"}]`)) //nolint:errcheck
+			}))
+			client = BoshIOClient{ReleasesAPIURL: server.URL + "/api/v1/releases/%s"}
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		It("returns the releases of the repository", func() {
+			releases, err := client.Releases("github.com/cloudfoundry/routing-release")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(releases)).To(Equal(`[{"version":"0.280.0"}]`))
+		})
+
+		Context("when bosh.io does not know the repository", func() {
+			It("raises an error", func() {
+				_, err := client.Releases("github.com/cloudfoundry/routing")
+				Expect(err).To(MatchError("could not list the releases of github.com/cloudfoundry/routing on bosh.io: 404 Not Found"))
+			})
+		})
+	})
+})
@@ -8,6 +8,19 @@ import (
 )
 
 type FakeBoshIO struct {
+	ReleasesStub        func(string) ([]byte, error)
+	releasesMutex       sync.RWMutex
+	releasesArgsForCall []struct {
+		arg1 string
+	}
+	releasesReturns struct {
+		result1 []byte
+		result2 error
+	}
+	releasesReturnsOnCall map[int]struct {
+		result1 []byte
+		result2 error
+	}
 	StemcellsStub        func(string) ([]byte, error)
 	stemcellsMutex       sync.RWMutex
 	stemcellsArgsForCall []struct {
@@ -25,21 +38,86 @@ type FakeBoshIO struct {
 	invocationsMutex sync.RWMutex
 }
 
+func (fake *FakeBoshIO) Releases(arg1 string) ([]byte, error) {
+	fake.releasesMutex.Lock()
+	ret, specificReturn := fake.releasesReturnsOnCall[len(fake.releasesArgsForCall)]
+	fake.releasesArgsForCall = append(fake.releasesArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.ReleasesStub
+	fakeReturns := fake.releasesReturns
+	fake.recordInvocation("Releases", []interface{}{arg1})
+	fake.releasesMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeBoshIO) ReleasesCallCount() int {
+	fake.releasesMutex.RLock()
+	defer fake.releasesMutex.RUnlock()
+	return len(fake.releasesArgsForCall)
+}
+
+func (fake *FakeBoshIO) ReleasesCalls(stub func(string) ([]byte, error)) {
+	fake.releasesMutex.Lock()
+	defer fake.releasesMutex.Unlock()
+	fake.ReleasesStub = stub
+}
+
+func (fake *FakeBoshIO) ReleasesArgsForCall(i int) string {
+	fake.releasesMutex.RLock()
+	defer fake.releasesMutex.RUnlock()
+	argsForCall := fake.releasesArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeBoshIO) ReleasesReturns(result1 []byte, result2 error) {
+	fake.releasesMutex.Lock()
+	defer fake.releasesMutex.Unlock()
+	fake.ReleasesStub = nil
+	fake.releasesReturns = struct {
+		result1 []byte
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeBoshIO) ReleasesReturnsOnCall(i int, result1 []byte, result2 error) {
+	fake.releasesMutex.Lock()
+	defer fake.releasesMutex.Unlock()
+	fake.ReleasesStub = nil
+	if fake.releasesReturnsOnCall == nil {
+		fake.releasesReturnsOnCall = make(map[int]struct {
+			result1 []byte
+			result2 error
+		})
+	}
+	fake.releasesReturnsOnCall[i] = struct {
+		result1 []byte
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeBoshIO) Stemcells(arg1 string) ([]byte, error) {
 	fake.stemcellsMutex.Lock()
 	ret, specificReturn := fake.stemcellsReturnsOnCall[len(fake.stemcellsArgsForCall)]
 	fake.stemcellsArgsForCall = append(fake.stemcellsArgsForCall, struct {
 		arg1 string
 	}{arg1})
+	stub := fake.StemcellsStub
+	fakeReturns := fake.stemcellsReturns
 	fake.recordInvocation("Stemcells", []interface{}{arg1})
 	fake.stemcellsMutex.Unlock()
-	if fake.StemcellsStub != nil {
-		return fake.StemcellsStub(arg1)
+	if stub != nil {
+		return stub(arg1)
 	}
 	if specificReturn {
 		return ret.result1, ret.result2
 	}
-	fakeReturns := fake.stemcellsReturns
 	return fakeReturns.result1, fakeReturns.result2
 }
 
@@ -91,6 +169,8 @@ func (fake *FakeBoshIO) StemcellsReturnsOnCall(i int, result1 []byte, result2 er
 func (fake *FakeBoshIO) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
+	fake.releasesMutex.RLock()
+	defer fake.releasesMutex.RUnlock()
 	fake.stemcellsMutex.RLock()
 	defer fake.stemcellsMutex.RUnlock()
 	copiedInvocations := map[string][][]interface{}{}
@@ -3,12 +3,62 @@ package boshfakes
 
 import (
 	"sync"
+	"time"
 
 	"github.com/cloudfoundry/bosh-cli/v7/director"
 	"github.com/cloudfoundry/bosh-deployment-resource/bosh"
 )
 
 type FakeDirector struct {
+	AttachToDeployTaskStub        func() (int, error)
+	attachToDeployTaskMutex       sync.RWMutex
+	attachToDeployTaskArgsForCall []struct {
+	}
+	attachToDeployTaskReturns struct {
+		result1 int
+		result2 error
+	}
+	attachToDeployTaskReturnsOnCall map[int]struct {
+		result1 int
+		result2 error
+	}
+	CertificateExpiryStub        func() ([]bosh.CertificateExpiry, error)
+	certificateExpiryMutex       sync.RWMutex
+	certificateExpiryArgsForCall []struct {
+	}
+	certificateExpiryReturns struct {
+		result1 []bosh.CertificateExpiry
+		result2 error
+	}
+	certificateExpiryReturnsOnCall map[int]struct {
+		result1 []bosh.CertificateExpiry
+		result2 error
+	}
+	CloudCheckStub        func(map[string]string, bool) ([]bosh.CloudCheckProblem, error)
+	cloudCheckMutex       sync.RWMutex
+	cloudCheckArgsForCall []struct {
+		arg1 map[string]string
+		arg2 bool
+	}
+	cloudCheckReturns struct {
+		result1 []bosh.CloudCheckProblem
+		result2 error
+	}
+	cloudCheckReturnsOnCall map[int]struct {
+		result1 []bosh.CloudCheckProblem
+		result2 error
+	}
+	CreateReleaseStub        func(bosh.CreateReleaseParams) error
+	createReleaseMutex       sync.RWMutex
+	createReleaseArgsForCall []struct {
+		arg1 bosh.CreateReleaseParams
+	}
+	createReleaseReturns struct {
+		result1 error
+	}
+	createReleaseReturnsOnCall map[int]struct {
+		result1 error
+	}
 	DeleteStub        func(bool) error
 	deleteMutex       sync.RWMutex
 	deleteArgsForCall []struct {
@@ -32,6 +82,32 @@ type FakeDirector struct {
 	deployReturnsOnCall map[int]struct {
 		result1 error
 	}
+	DiffStub        func([]byte, bosh.DeployParams) (bosh.DeploymentDiff, error)
+	diffMutex       sync.RWMutex
+	diffArgsForCall []struct {
+		arg1 []byte
+		arg2 bosh.DeployParams
+	}
+	diffReturns struct {
+		result1 bosh.DeploymentDiff
+		result2 error
+	}
+	diffReturnsOnCall map[int]struct {
+		result1 bosh.DeploymentDiff
+		result2 error
+	}
+	DiskTypeSizesStub        func() (map[string]int, error)
+	diskTypeSizesMutex       sync.RWMutex
+	diskTypeSizesArgsForCall []struct {
+	}
+	diskTypeSizesReturns struct {
+		result1 map[string]int
+		result2 error
+	}
+	diskTypeSizesReturnsOnCall map[int]struct {
+		result1 map[string]int
+		result2 error
+	}
 	DownloadManifestStub        func() ([]byte, error)
 	downloadManifestMutex       sync.RWMutex
 	downloadManifestArgsForCall []struct {
@@ -82,6 +158,106 @@ type FakeDirector struct {
 		result1 []byte
 		result2 error
 	}
+	RecreateStub        func(bosh.InstanceActionParams) error
+	recreateMutex       sync.RWMutex
+	recreateArgsForCall []struct {
+		arg1 bosh.InstanceActionParams
+	}
+	recreateReturns struct {
+		result1 error
+	}
+	recreateReturnsOnCall map[int]struct {
+		result1 error
+	}
+	ReleaseNeedsUploadStub        func(bosh.Release) (bool, error)
+	releaseNeedsUploadMutex       sync.RWMutex
+	releaseNeedsUploadArgsForCall []struct {
+		arg1 bosh.Release
+	}
+	releaseNeedsUploadReturns struct {
+		result1 bool
+		result2 error
+	}
+	releaseNeedsUploadReturnsOnCall map[int]struct {
+		result1 bool
+		result2 error
+	}
+	RestartStub        func(bosh.InstanceActionParams) error
+	restartMutex       sync.RWMutex
+	restartArgsForCall []struct {
+		arg1 bosh.InstanceActionParams
+	}
+	restartReturns struct {
+		result1 error
+	}
+	restartReturnsOnCall map[int]struct {
+		result1 error
+	}
+	RunErrandStub        func(bosh.ErrandParams) ([]bosh.ErrandResult, error)
+	runErrandMutex       sync.RWMutex
+	runErrandArgsForCall []struct {
+		arg1 bosh.ErrandParams
+	}
+	runErrandReturns struct {
+		result1 []bosh.ErrandResult
+		result2 error
+	}
+	runErrandReturnsOnCall map[int]struct {
+		result1 []bosh.ErrandResult
+		result2 error
+	}
+	StartStub        func(bosh.InstanceActionParams) error
+	startMutex       sync.RWMutex
+	startArgsForCall []struct {
+		arg1 bosh.InstanceActionParams
+	}
+	startReturns struct {
+		result1 error
+	}
+	startReturnsOnCall map[int]struct {
+		result1 error
+	}
+	StemcellNeedsUploadStub        func(bosh.Stemcell) (bool, error)
+	stemcellNeedsUploadMutex       sync.RWMutex
+	stemcellNeedsUploadArgsForCall []struct {
+		arg1 bosh.Stemcell
+	}
+	stemcellNeedsUploadReturns struct {
+		result1 bool
+		result2 error
+	}
+	stemcellNeedsUploadReturnsOnCall map[int]struct {
+		result1 bool
+		result2 error
+	}
+	StopStub        func(bosh.InstanceActionParams) error
+	stopMutex       sync.RWMutex
+	stopArgsForCall []struct {
+		arg1 bosh.InstanceActionParams
+	}
+	stopReturns struct {
+		result1 error
+	}
+	stopReturnsOnCall map[int]struct {
+		result1 error
+	}
+	UpdateConfigStub        func(string, string, []byte) (string, bool, error)
+	updateConfigMutex       sync.RWMutex
+	updateConfigArgsForCall []struct {
+		arg1 string
+		arg2 string
+		arg3 []byte
+	}
+	updateConfigReturns struct {
+		result1 string
+		result2 bool
+		result3 error
+	}
+	updateConfigReturnsOnCall map[int]struct {
+		result1 string
+		result2 bool
+		result3 error
+	}
 	UploadReleaseStub        func(string) error
 	uploadReleaseMutex       sync.RWMutex
 	uploadReleaseArgsForCall []struct {
@@ -93,6 +269,20 @@ type FakeDirector struct {
 	uploadReleaseReturnsOnCall map[int]struct {
 		result1 error
 	}
+	UploadRemoteReleaseStub        func(string, string, string, string) error
+	uploadRemoteReleaseMutex       sync.RWMutex
+	uploadRemoteReleaseArgsForCall []struct {
+		arg1 string
+		arg2 string
+		arg3 string
+		arg4 string
+	}
+	uploadRemoteReleaseReturns struct {
+		result1 error
+	}
+	uploadRemoteReleaseReturnsOnCall map[int]struct {
+		result1 error
+	}
 	UploadRemoteStemcellStub        func(string, string, string, string) error
 	uploadRemoteStemcellMutex       sync.RWMutex
 	uploadRemoteStemcellArgsForCall []struct {
@@ -118,9 +308,10 @@ type FakeDirector struct {
 	uploadStemcellReturnsOnCall map[int]struct {
 		result1 error
 	}
-	WaitForDeployLockStub        func() error
+	WaitForDeployLockStub        func(time.Duration) error
 	waitForDeployLockMutex       sync.RWMutex
 	waitForDeployLockArgsForCall []struct {
+		arg1 time.Duration
 	}
 	waitForDeployLockReturns struct {
 		result1 error
@@ -128,25 +319,275 @@ type FakeDirector struct {
 	waitForDeployLockReturnsOnCall map[int]struct {
 		result1 error
 	}
+	WithOutputPrefixStub        func(string) bosh.Director
+	withOutputPrefixMutex       sync.RWMutex
+	withOutputPrefixArgsForCall []struct {
+		arg1 string
+	}
+	withOutputPrefixReturns struct {
+		result1 bosh.Director
+	}
+	withOutputPrefixReturnsOnCall map[int]struct {
+		result1 bosh.Director
+	}
 	invocations      map[string][][]interface{}
 	invocationsMutex sync.RWMutex
 }
 
+func (fake *FakeDirector) AttachToDeployTask() (int, error) {
+	fake.attachToDeployTaskMutex.Lock()
+	ret, specificReturn := fake.attachToDeployTaskReturnsOnCall[len(fake.attachToDeployTaskArgsForCall)]
+	fake.attachToDeployTaskArgsForCall = append(fake.attachToDeployTaskArgsForCall, struct {
+	}{})
+	stub := fake.AttachToDeployTaskStub
+	fakeReturns := fake.attachToDeployTaskReturns
+	fake.recordInvocation("AttachToDeployTask", []interface{}{})
+	fake.attachToDeployTaskMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeDirector) AttachToDeployTaskCallCount() int {
+	fake.attachToDeployTaskMutex.RLock()
+	defer fake.attachToDeployTaskMutex.RUnlock()
+	return len(fake.attachToDeployTaskArgsForCall)
+}
+
+func (fake *FakeDirector) AttachToDeployTaskCalls(stub func() (int, error)) {
+	fake.attachToDeployTaskMutex.Lock()
+	defer fake.attachToDeployTaskMutex.Unlock()
+	fake.AttachToDeployTaskStub = stub
+}
+
+func (fake *FakeDirector) AttachToDeployTaskReturns(result1 int, result2 error) {
+	fake.attachToDeployTaskMutex.Lock()
+	defer fake.attachToDeployTaskMutex.Unlock()
+	fake.AttachToDeployTaskStub = nil
+	fake.attachToDeployTaskReturns = struct {
+		result1 int
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeDirector) AttachToDeployTaskReturnsOnCall(i int, result1 int, result2 error) {
+	fake.attachToDeployTaskMutex.Lock()
+	defer fake.attachToDeployTaskMutex.Unlock()
+	fake.AttachToDeployTaskStub = nil
+	if fake.attachToDeployTaskReturnsOnCall == nil {
+		fake.attachToDeployTaskReturnsOnCall = make(map[int]struct {
+			result1 int
+			result2 error
+		})
+	}
+	fake.attachToDeployTaskReturnsOnCall[i] = struct {
+		result1 int
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeDirector) CertificateExpiry() ([]bosh.CertificateExpiry, error) {
+	fake.certificateExpiryMutex.Lock()
+	ret, specificReturn := fake.certificateExpiryReturnsOnCall[len(fake.certificateExpiryArgsForCall)]
+	fake.certificateExpiryArgsForCall = append(fake.certificateExpiryArgsForCall, struct {
+	}{})
+	stub := fake.CertificateExpiryStub
+	fakeReturns := fake.certificateExpiryReturns
+	fake.recordInvocation("CertificateExpiry", []interface{}{})
+	fake.certificateExpiryMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeDirector) CertificateExpiryCallCount() int {
+	fake.certificateExpiryMutex.RLock()
+	defer fake.certificateExpiryMutex.RUnlock()
+	return len(fake.certificateExpiryArgsForCall)
+}
+
+func (fake *FakeDirector) CertificateExpiryCalls(stub func() ([]bosh.CertificateExpiry, error)) {
+	fake.certificateExpiryMutex.Lock()
+	defer fake.certificateExpiryMutex.Unlock()
+	fake.CertificateExpiryStub = stub
+}
+
+func (fake *FakeDirector) CertificateExpiryReturns(result1 []bosh.CertificateExpiry, result2 error) {
+	fake.certificateExpiryMutex.Lock()
+	defer fake.certificateExpiryMutex.Unlock()
+	fake.CertificateExpiryStub = nil
+	fake.certificateExpiryReturns = struct {
+		result1 []bosh.CertificateExpiry
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeDirector) CertificateExpiryReturnsOnCall(i int, result1 []bosh.CertificateExpiry, result2 error) {
+	fake.certificateExpiryMutex.Lock()
+	defer fake.certificateExpiryMutex.Unlock()
+	fake.CertificateExpiryStub = nil
+	if fake.certificateExpiryReturnsOnCall == nil {
+		fake.certificateExpiryReturnsOnCall = make(map[int]struct {
+			result1 []bosh.CertificateExpiry
+			result2 error
+		})
+	}
+	fake.certificateExpiryReturnsOnCall[i] = struct {
+		result1 []bosh.CertificateExpiry
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeDirector) CloudCheck(arg1 map[string]string, arg2 bool) ([]bosh.CloudCheckProblem, error) {
+	fake.cloudCheckMutex.Lock()
+	ret, specificReturn := fake.cloudCheckReturnsOnCall[len(fake.cloudCheckArgsForCall)]
+	fake.cloudCheckArgsForCall = append(fake.cloudCheckArgsForCall, struct {
+		arg1 map[string]string
+		arg2 bool
+	}{arg1, arg2})
+	stub := fake.CloudCheckStub
+	fakeReturns := fake.cloudCheckReturns
+	fake.recordInvocation("CloudCheck", []interface{}{arg1, arg2})
+	fake.cloudCheckMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeDirector) CloudCheckCallCount() int {
+	fake.cloudCheckMutex.RLock()
+	defer fake.cloudCheckMutex.RUnlock()
+	return len(fake.cloudCheckArgsForCall)
+}
+
+func (fake *FakeDirector) CloudCheckCalls(stub func(map[string]string, bool) ([]bosh.CloudCheckProblem, error)) {
+	fake.cloudCheckMutex.Lock()
+	defer fake.cloudCheckMutex.Unlock()
+	fake.CloudCheckStub = stub
+}
+
+func (fake *FakeDirector) CloudCheckArgsForCall(i int) (map[string]string, bool) {
+	fake.cloudCheckMutex.RLock()
+	defer fake.cloudCheckMutex.RUnlock()
+	argsForCall := fake.cloudCheckArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeDirector) CloudCheckReturns(result1 []bosh.CloudCheckProblem, result2 error) {
+	fake.cloudCheckMutex.Lock()
+	defer fake.cloudCheckMutex.Unlock()
+	fake.CloudCheckStub = nil
+	fake.cloudCheckReturns = struct {
+		result1 []bosh.CloudCheckProblem
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeDirector) CloudCheckReturnsOnCall(i int, result1 []bosh.CloudCheckProblem, result2 error) {
+	fake.cloudCheckMutex.Lock()
+	defer fake.cloudCheckMutex.Unlock()
+	fake.CloudCheckStub = nil
+	if fake.cloudCheckReturnsOnCall == nil {
+		fake.cloudCheckReturnsOnCall = make(map[int]struct {
+			result1 []bosh.CloudCheckProblem
+			result2 error
+		})
+	}
+	fake.cloudCheckReturnsOnCall[i] = struct {
+		result1 []bosh.CloudCheckProblem
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeDirector) CreateRelease(arg1 bosh.CreateReleaseParams) error {
+	fake.createReleaseMutex.Lock()
+	ret, specificReturn := fake.createReleaseReturnsOnCall[len(fake.createReleaseArgsForCall)]
+	fake.createReleaseArgsForCall = append(fake.createReleaseArgsForCall, struct {
+		arg1 bosh.CreateReleaseParams
+	}{arg1})
+	stub := fake.CreateReleaseStub
+	fakeReturns := fake.createReleaseReturns
+	fake.recordInvocation("CreateRelease", []interface{}{arg1})
+	fake.createReleaseMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeDirector) CreateReleaseCallCount() int {
+	fake.createReleaseMutex.RLock()
+	defer fake.createReleaseMutex.RUnlock()
+	return len(fake.createReleaseArgsForCall)
+}
+
+func (fake *FakeDirector) CreateReleaseCalls(stub func(bosh.CreateReleaseParams) error) {
+	fake.createReleaseMutex.Lock()
+	defer fake.createReleaseMutex.Unlock()
+	fake.CreateReleaseStub = stub
+}
+
+func (fake *FakeDirector) CreateReleaseArgsForCall(i int) bosh.CreateReleaseParams {
+	fake.createReleaseMutex.RLock()
+	defer fake.createReleaseMutex.RUnlock()
+	argsForCall := fake.createReleaseArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeDirector) CreateReleaseReturns(result1 error) {
+	fake.createReleaseMutex.Lock()
+	defer fake.createReleaseMutex.Unlock()
+	fake.CreateReleaseStub = nil
+	fake.createReleaseReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeDirector) CreateReleaseReturnsOnCall(i int, result1 error) {
+	fake.createReleaseMutex.Lock()
+	defer fake.createReleaseMutex.Unlock()
+	fake.CreateReleaseStub = nil
+	if fake.createReleaseReturnsOnCall == nil {
+		fake.createReleaseReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.createReleaseReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *FakeDirector) Delete(arg1 bool) error {
 	fake.deleteMutex.Lock()
 	ret, specificReturn := fake.deleteReturnsOnCall[len(fake.deleteArgsForCall)]
 	fake.deleteArgsForCall = append(fake.deleteArgsForCall, struct {
 		arg1 bool
 	}{arg1})
+	stub := fake.DeleteStub
+	fakeReturns := fake.deleteReturns
 	fake.recordInvocation("Delete", []interface{}{arg1})
 	fake.deleteMutex.Unlock()
-	if fake.DeleteStub != nil {
-		return fake.DeleteStub(arg1)
+	if stub != nil {
+		return stub(arg1)
 	}
 	if specificReturn {
 		return ret.result1
 	}
-	fakeReturns := fake.deleteReturns
 	return fakeReturns.result1
 }
 
@@ -204,15 +645,16 @@ func (fake *FakeDirector) Deploy(arg1 []byte, arg2 bosh.DeployParams) error {
 		arg1 []byte
 		arg2 bosh.DeployParams
 	}{arg1Copy, arg2})
+	stub := fake.DeployStub
+	fakeReturns := fake.deployReturns
 	fake.recordInvocation("Deploy", []interface{}{arg1Copy, arg2})
 	fake.deployMutex.Unlock()
-	if fake.DeployStub != nil {
-		return fake.DeployStub(arg1, arg2)
+	if stub != nil {
+		return stub(arg1, arg2)
 	}
 	if specificReturn {
 		return ret.result1
 	}
-	fakeReturns := fake.deployReturns
 	return fakeReturns.result1
 }
 
@@ -258,20 +700,147 @@ func (fake *FakeDirector) DeployReturnsOnCall(i int, result1 error) {
 	}{result1}
 }
 
+func (fake *FakeDirector) Diff(arg1 []byte, arg2 bosh.DeployParams) (bosh.DeploymentDiff, error) {
+	var arg1Copy []byte
+	if arg1 != nil {
+		arg1Copy = make([]byte, len(arg1))
+		copy(arg1Copy, arg1)
+	}
+	fake.diffMutex.Lock()
+	ret, specificReturn := fake.diffReturnsOnCall[len(fake.diffArgsForCall)]
+	fake.diffArgsForCall = append(fake.diffArgsForCall, struct {
+		arg1 []byte
+		arg2 bosh.DeployParams
+	}{arg1Copy, arg2})
+	stub := fake.DiffStub
+	fakeReturns := fake.diffReturns
+	fake.recordInvocation("Diff", []interface{}{arg1Copy, arg2})
+	fake.diffMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeDirector) DiffCallCount() int {
+	fake.diffMutex.RLock()
+	defer fake.diffMutex.RUnlock()
+	return len(fake.diffArgsForCall)
+}
+
+func (fake *FakeDirector) DiffCalls(stub func([]byte, bosh.DeployParams) (bosh.DeploymentDiff, error)) {
+	fake.diffMutex.Lock()
+	defer fake.diffMutex.Unlock()
+	fake.DiffStub = stub
+}
+
+func (fake *FakeDirector) DiffArgsForCall(i int) ([]byte, bosh.DeployParams) {
+	fake.diffMutex.RLock()
+	defer fake.diffMutex.RUnlock()
+	argsForCall := fake.diffArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeDirector) DiffReturns(result1 bosh.DeploymentDiff, result2 error) {
+	fake.diffMutex.Lock()
+	defer fake.diffMutex.Unlock()
+	fake.DiffStub = nil
+	fake.diffReturns = struct {
+		result1 bosh.DeploymentDiff
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeDirector) DiffReturnsOnCall(i int, result1 bosh.DeploymentDiff, result2 error) {
+	fake.diffMutex.Lock()
+	defer fake.diffMutex.Unlock()
+	fake.DiffStub = nil
+	if fake.diffReturnsOnCall == nil {
+		fake.diffReturnsOnCall = make(map[int]struct {
+			result1 bosh.DeploymentDiff
+			result2 error
+		})
+	}
+	fake.diffReturnsOnCall[i] = struct {
+		result1 bosh.DeploymentDiff
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeDirector) DiskTypeSizes() (map[string]int, error) {
+	fake.diskTypeSizesMutex.Lock()
+	ret, specificReturn := fake.diskTypeSizesReturnsOnCall[len(fake.diskTypeSizesArgsForCall)]
+	fake.diskTypeSizesArgsForCall = append(fake.diskTypeSizesArgsForCall, struct {
+	}{})
+	stub := fake.DiskTypeSizesStub
+	fakeReturns := fake.diskTypeSizesReturns
+	fake.recordInvocation("DiskTypeSizes", []interface{}{})
+	fake.diskTypeSizesMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeDirector) DiskTypeSizesCallCount() int {
+	fake.diskTypeSizesMutex.RLock()
+	defer fake.diskTypeSizesMutex.RUnlock()
+	return len(fake.diskTypeSizesArgsForCall)
+}
+
+func (fake *FakeDirector) DiskTypeSizesCalls(stub func() (map[string]int, error)) {
+	fake.diskTypeSizesMutex.Lock()
+	defer fake.diskTypeSizesMutex.Unlock()
+	fake.DiskTypeSizesStub = stub
+}
+
+func (fake *FakeDirector) DiskTypeSizesReturns(result1 map[string]int, result2 error) {
+	fake.diskTypeSizesMutex.Lock()
+	defer fake.diskTypeSizesMutex.Unlock()
+	fake.DiskTypeSizesStub = nil
+	fake.diskTypeSizesReturns = struct {
+		result1 map[string]int
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeDirector) DiskTypeSizesReturnsOnCall(i int, result1 map[string]int, result2 error) {
+	fake.diskTypeSizesMutex.Lock()
+	defer fake.diskTypeSizesMutex.Unlock()
+	fake.DiskTypeSizesStub = nil
+	if fake.diskTypeSizesReturnsOnCall == nil {
+		fake.diskTypeSizesReturnsOnCall = make(map[int]struct {
+			result1 map[string]int
+			result2 error
+		})
+	}
+	fake.diskTypeSizesReturnsOnCall[i] = struct {
+		result1 map[string]int
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeDirector) DownloadManifest() ([]byte, error) {
 	fake.downloadManifestMutex.Lock()
 	ret, specificReturn := fake.downloadManifestReturnsOnCall[len(fake.downloadManifestArgsForCall)]
 	fake.downloadManifestArgsForCall = append(fake.downloadManifestArgsForCall, struct {
 	}{})
+	stub := fake.DownloadManifestStub
+	fakeReturns := fake.downloadManifestReturns
 	fake.recordInvocation("DownloadManifest", []interface{}{})
 	fake.downloadManifestMutex.Unlock()
-	if fake.DownloadManifestStub != nil {
-		return fake.DownloadManifestStub()
+	if stub != nil {
+		return stub()
 	}
 	if specificReturn {
 		return ret.result1, ret.result2
 	}
-	fakeReturns := fake.downloadManifestReturns
 	return fakeReturns.result1, fakeReturns.result2
 }
 
@@ -325,15 +894,16 @@ func (fake *FakeDirector) ExportReleases(arg1 string, arg2 []bosh.ReleaseSpec) e
 		arg1 string
 		arg2 []bosh.ReleaseSpec
 	}{arg1, arg2Copy})
+	stub := fake.ExportReleasesStub
+	fakeReturns := fake.exportReleasesReturns
 	fake.recordInvocation("ExportReleases", []interface{}{arg1, arg2Copy})
 	fake.exportReleasesMutex.Unlock()
-	if fake.ExportReleasesStub != nil {
-		return fake.ExportReleasesStub(arg1, arg2)
+	if stub != nil {
+		return stub(arg1, arg2)
 	}
 	if specificReturn {
 		return ret.result1
 	}
-	fakeReturns := fake.exportReleasesReturns
 	return fakeReturns.result1
 }
 
@@ -384,15 +954,16 @@ func (fake *FakeDirector) Info() (director.Info, error) {
 	ret, specificReturn := fake.infoReturnsOnCall[len(fake.infoArgsForCall)]
 	fake.infoArgsForCall = append(fake.infoArgsForCall, struct {
 	}{})
+	stub := fake.InfoStub
+	fakeReturns := fake.infoReturns
 	fake.recordInvocation("Info", []interface{}{})
 	fake.infoMutex.Unlock()
-	if fake.InfoStub != nil {
-		return fake.InfoStub()
+	if stub != nil {
+		return stub()
 	}
 	if specificReturn {
 		return ret.result1, ret.result2
 	}
-	fakeReturns := fake.infoReturns
 	return fakeReturns.result1, fakeReturns.result2
 }
 
@@ -446,15 +1017,16 @@ func (fake *FakeDirector) Interpolate(arg1 []byte, arg2 bosh.InterpolateParams)
 		arg1 []byte
 		arg2 bosh.InterpolateParams
 	}{arg1Copy, arg2})
+	stub := fake.InterpolateStub
+	fakeReturns := fake.interpolateReturns
 	fake.recordInvocation("Interpolate", []interface{}{arg1Copy, arg2})
 	fake.interpolateMutex.Unlock()
-	if fake.InterpolateStub != nil {
-		return fake.InterpolateStub(arg1, arg2)
+	if stub != nil {
+		return stub(arg1, arg2)
 	}
 	if specificReturn {
 		return ret.result1, ret.result2
 	}
-	fakeReturns := fake.interpolateReturns
 	return fakeReturns.result1, fakeReturns.result2
 }
 
@@ -503,21 +1075,532 @@ func (fake *FakeDirector) InterpolateReturnsOnCall(i int, result1 []byte, result
 	}{result1, result2}
 }
 
-func (fake *FakeDirector) UploadRelease(arg1 string) error {
-	fake.uploadReleaseMutex.Lock()
-	ret, specificReturn := fake.uploadReleaseReturnsOnCall[len(fake.uploadReleaseArgsForCall)]
-	fake.uploadReleaseArgsForCall = append(fake.uploadReleaseArgsForCall, struct {
-		arg1 string
+func (fake *FakeDirector) Recreate(arg1 bosh.InstanceActionParams) error {
+	fake.recreateMutex.Lock()
+	ret, specificReturn := fake.recreateReturnsOnCall[len(fake.recreateArgsForCall)]
+	fake.recreateArgsForCall = append(fake.recreateArgsForCall, struct {
+		arg1 bosh.InstanceActionParams
 	}{arg1})
-	fake.recordInvocation("UploadRelease", []interface{}{arg1})
-	fake.uploadReleaseMutex.Unlock()
-	if fake.UploadReleaseStub != nil {
-		return fake.UploadReleaseStub(arg1)
+	stub := fake.RecreateStub
+	fakeReturns := fake.recreateReturns
+	fake.recordInvocation("Recreate", []interface{}{arg1})
+	fake.recreateMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeDirector) RecreateCallCount() int {
+	fake.recreateMutex.RLock()
+	defer fake.recreateMutex.RUnlock()
+	return len(fake.recreateArgsForCall)
+}
+
+func (fake *FakeDirector) RecreateCalls(stub func(bosh.InstanceActionParams) error) {
+	fake.recreateMutex.Lock()
+	defer fake.recreateMutex.Unlock()
+	fake.RecreateStub = stub
+}
+
+func (fake *FakeDirector) RecreateArgsForCall(i int) bosh.InstanceActionParams {
+	fake.recreateMutex.RLock()
+	defer fake.recreateMutex.RUnlock()
+	argsForCall := fake.recreateArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeDirector) RecreateReturns(result1 error) {
+	fake.recreateMutex.Lock()
+	defer fake.recreateMutex.Unlock()
+	fake.RecreateStub = nil
+	fake.recreateReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeDirector) RecreateReturnsOnCall(i int, result1 error) {
+	fake.recreateMutex.Lock()
+	defer fake.recreateMutex.Unlock()
+	fake.RecreateStub = nil
+	if fake.recreateReturnsOnCall == nil {
+		fake.recreateReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.recreateReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeDirector) ReleaseNeedsUpload(arg1 bosh.Release) (bool, error) {
+	fake.releaseNeedsUploadMutex.Lock()
+	ret, specificReturn := fake.releaseNeedsUploadReturnsOnCall[len(fake.releaseNeedsUploadArgsForCall)]
+	fake.releaseNeedsUploadArgsForCall = append(fake.releaseNeedsUploadArgsForCall, struct {
+		arg1 bosh.Release
+	}{arg1})
+	stub := fake.ReleaseNeedsUploadStub
+	fakeReturns := fake.releaseNeedsUploadReturns
+	fake.recordInvocation("ReleaseNeedsUpload", []interface{}{arg1})
+	fake.releaseNeedsUploadMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeDirector) ReleaseNeedsUploadCallCount() int {
+	fake.releaseNeedsUploadMutex.RLock()
+	defer fake.releaseNeedsUploadMutex.RUnlock()
+	return len(fake.releaseNeedsUploadArgsForCall)
+}
+
+func (fake *FakeDirector) ReleaseNeedsUploadCalls(stub func(bosh.Release) (bool, error)) {
+	fake.releaseNeedsUploadMutex.Lock()
+	defer fake.releaseNeedsUploadMutex.Unlock()
+	fake.ReleaseNeedsUploadStub = stub
+}
+
+func (fake *FakeDirector) ReleaseNeedsUploadArgsForCall(i int) bosh.Release {
+	fake.releaseNeedsUploadMutex.RLock()
+	defer fake.releaseNeedsUploadMutex.RUnlock()
+	argsForCall := fake.releaseNeedsUploadArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeDirector) ReleaseNeedsUploadReturns(result1 bool, result2 error) {
+	fake.releaseNeedsUploadMutex.Lock()
+	defer fake.releaseNeedsUploadMutex.Unlock()
+	fake.ReleaseNeedsUploadStub = nil
+	fake.releaseNeedsUploadReturns = struct {
+		result1 bool
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeDirector) ReleaseNeedsUploadReturnsOnCall(i int, result1 bool, result2 error) {
+	fake.releaseNeedsUploadMutex.Lock()
+	defer fake.releaseNeedsUploadMutex.Unlock()
+	fake.ReleaseNeedsUploadStub = nil
+	if fake.releaseNeedsUploadReturnsOnCall == nil {
+		fake.releaseNeedsUploadReturnsOnCall = make(map[int]struct {
+			result1 bool
+			result2 error
+		})
+	}
+	fake.releaseNeedsUploadReturnsOnCall[i] = struct {
+		result1 bool
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeDirector) Restart(arg1 bosh.InstanceActionParams) error {
+	fake.restartMutex.Lock()
+	ret, specificReturn := fake.restartReturnsOnCall[len(fake.restartArgsForCall)]
+	fake.restartArgsForCall = append(fake.restartArgsForCall, struct {
+		arg1 bosh.InstanceActionParams
+	}{arg1})
+	stub := fake.RestartStub
+	fakeReturns := fake.restartReturns
+	fake.recordInvocation("Restart", []interface{}{arg1})
+	fake.restartMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeDirector) RestartCallCount() int {
+	fake.restartMutex.RLock()
+	defer fake.restartMutex.RUnlock()
+	return len(fake.restartArgsForCall)
+}
+
+func (fake *FakeDirector) RestartCalls(stub func(bosh.InstanceActionParams) error) {
+	fake.restartMutex.Lock()
+	defer fake.restartMutex.Unlock()
+	fake.RestartStub = stub
+}
+
+func (fake *FakeDirector) RestartArgsForCall(i int) bosh.InstanceActionParams {
+	fake.restartMutex.RLock()
+	defer fake.restartMutex.RUnlock()
+	argsForCall := fake.restartArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeDirector) RestartReturns(result1 error) {
+	fake.restartMutex.Lock()
+	defer fake.restartMutex.Unlock()
+	fake.RestartStub = nil
+	fake.restartReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeDirector) RestartReturnsOnCall(i int, result1 error) {
+	fake.restartMutex.Lock()
+	defer fake.restartMutex.Unlock()
+	fake.RestartStub = nil
+	if fake.restartReturnsOnCall == nil {
+		fake.restartReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.restartReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeDirector) RunErrand(arg1 bosh.ErrandParams) ([]bosh.ErrandResult, error) {
+	fake.runErrandMutex.Lock()
+	ret, specificReturn := fake.runErrandReturnsOnCall[len(fake.runErrandArgsForCall)]
+	fake.runErrandArgsForCall = append(fake.runErrandArgsForCall, struct {
+		arg1 bosh.ErrandParams
+	}{arg1})
+	stub := fake.RunErrandStub
+	fakeReturns := fake.runErrandReturns
+	fake.recordInvocation("RunErrand", []interface{}{arg1})
+	fake.runErrandMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeDirector) RunErrandCallCount() int {
+	fake.runErrandMutex.RLock()
+	defer fake.runErrandMutex.RUnlock()
+	return len(fake.runErrandArgsForCall)
+}
+
+func (fake *FakeDirector) RunErrandCalls(stub func(bosh.ErrandParams) ([]bosh.ErrandResult, error)) {
+	fake.runErrandMutex.Lock()
+	defer fake.runErrandMutex.Unlock()
+	fake.RunErrandStub = stub
+}
+
+func (fake *FakeDirector) RunErrandArgsForCall(i int) bosh.ErrandParams {
+	fake.runErrandMutex.RLock()
+	defer fake.runErrandMutex.RUnlock()
+	argsForCall := fake.runErrandArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeDirector) RunErrandReturns(result1 []bosh.ErrandResult, result2 error) {
+	fake.runErrandMutex.Lock()
+	defer fake.runErrandMutex.Unlock()
+	fake.RunErrandStub = nil
+	fake.runErrandReturns = struct {
+		result1 []bosh.ErrandResult
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeDirector) RunErrandReturnsOnCall(i int, result1 []bosh.ErrandResult, result2 error) {
+	fake.runErrandMutex.Lock()
+	defer fake.runErrandMutex.Unlock()
+	fake.RunErrandStub = nil
+	if fake.runErrandReturnsOnCall == nil {
+		fake.runErrandReturnsOnCall = make(map[int]struct {
+			result1 []bosh.ErrandResult
+			result2 error
+		})
+	}
+	fake.runErrandReturnsOnCall[i] = struct {
+		result1 []bosh.ErrandResult
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeDirector) Start(arg1 bosh.InstanceActionParams) error {
+	fake.startMutex.Lock()
+	ret, specificReturn := fake.startReturnsOnCall[len(fake.startArgsForCall)]
+	fake.startArgsForCall = append(fake.startArgsForCall, struct {
+		arg1 bosh.InstanceActionParams
+	}{arg1})
+	stub := fake.StartStub
+	fakeReturns := fake.startReturns
+	fake.recordInvocation("Start", []interface{}{arg1})
+	fake.startMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeDirector) StartCallCount() int {
+	fake.startMutex.RLock()
+	defer fake.startMutex.RUnlock()
+	return len(fake.startArgsForCall)
+}
+
+func (fake *FakeDirector) StartCalls(stub func(bosh.InstanceActionParams) error) {
+	fake.startMutex.Lock()
+	defer fake.startMutex.Unlock()
+	fake.StartStub = stub
+}
+
+func (fake *FakeDirector) StartArgsForCall(i int) bosh.InstanceActionParams {
+	fake.startMutex.RLock()
+	defer fake.startMutex.RUnlock()
+	argsForCall := fake.startArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeDirector) StartReturns(result1 error) {
+	fake.startMutex.Lock()
+	defer fake.startMutex.Unlock()
+	fake.StartStub = nil
+	fake.startReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeDirector) StartReturnsOnCall(i int, result1 error) {
+	fake.startMutex.Lock()
+	defer fake.startMutex.Unlock()
+	fake.StartStub = nil
+	if fake.startReturnsOnCall == nil {
+		fake.startReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.startReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeDirector) StemcellNeedsUpload(arg1 bosh.Stemcell) (bool, error) {
+	fake.stemcellNeedsUploadMutex.Lock()
+	ret, specificReturn := fake.stemcellNeedsUploadReturnsOnCall[len(fake.stemcellNeedsUploadArgsForCall)]
+	fake.stemcellNeedsUploadArgsForCall = append(fake.stemcellNeedsUploadArgsForCall, struct {
+		arg1 bosh.Stemcell
+	}{arg1})
+	stub := fake.StemcellNeedsUploadStub
+	fakeReturns := fake.stemcellNeedsUploadReturns
+	fake.recordInvocation("StemcellNeedsUpload", []interface{}{arg1})
+	fake.stemcellNeedsUploadMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeDirector) StemcellNeedsUploadCallCount() int {
+	fake.stemcellNeedsUploadMutex.RLock()
+	defer fake.stemcellNeedsUploadMutex.RUnlock()
+	return len(fake.stemcellNeedsUploadArgsForCall)
+}
+
+func (fake *FakeDirector) StemcellNeedsUploadCalls(stub func(bosh.Stemcell) (bool, error)) {
+	fake.stemcellNeedsUploadMutex.Lock()
+	defer fake.stemcellNeedsUploadMutex.Unlock()
+	fake.StemcellNeedsUploadStub = stub
+}
+
+func (fake *FakeDirector) StemcellNeedsUploadArgsForCall(i int) bosh.Stemcell {
+	fake.stemcellNeedsUploadMutex.RLock()
+	defer fake.stemcellNeedsUploadMutex.RUnlock()
+	argsForCall := fake.stemcellNeedsUploadArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeDirector) StemcellNeedsUploadReturns(result1 bool, result2 error) {
+	fake.stemcellNeedsUploadMutex.Lock()
+	defer fake.stemcellNeedsUploadMutex.Unlock()
+	fake.StemcellNeedsUploadStub = nil
+	fake.stemcellNeedsUploadReturns = struct {
+		result1 bool
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeDirector) StemcellNeedsUploadReturnsOnCall(i int, result1 bool, result2 error) {
+	fake.stemcellNeedsUploadMutex.Lock()
+	defer fake.stemcellNeedsUploadMutex.Unlock()
+	fake.StemcellNeedsUploadStub = nil
+	if fake.stemcellNeedsUploadReturnsOnCall == nil {
+		fake.stemcellNeedsUploadReturnsOnCall = make(map[int]struct {
+			result1 bool
+			result2 error
+		})
+	}
+	fake.stemcellNeedsUploadReturnsOnCall[i] = struct {
+		result1 bool
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeDirector) Stop(arg1 bosh.InstanceActionParams) error {
+	fake.stopMutex.Lock()
+	ret, specificReturn := fake.stopReturnsOnCall[len(fake.stopArgsForCall)]
+	fake.stopArgsForCall = append(fake.stopArgsForCall, struct {
+		arg1 bosh.InstanceActionParams
+	}{arg1})
+	stub := fake.StopStub
+	fakeReturns := fake.stopReturns
+	fake.recordInvocation("Stop", []interface{}{arg1})
+	fake.stopMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
 	}
 	if specificReturn {
 		return ret.result1
 	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeDirector) StopCallCount() int {
+	fake.stopMutex.RLock()
+	defer fake.stopMutex.RUnlock()
+	return len(fake.stopArgsForCall)
+}
+
+func (fake *FakeDirector) StopCalls(stub func(bosh.InstanceActionParams) error) {
+	fake.stopMutex.Lock()
+	defer fake.stopMutex.Unlock()
+	fake.StopStub = stub
+}
+
+func (fake *FakeDirector) StopArgsForCall(i int) bosh.InstanceActionParams {
+	fake.stopMutex.RLock()
+	defer fake.stopMutex.RUnlock()
+	argsForCall := fake.stopArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeDirector) StopReturns(result1 error) {
+	fake.stopMutex.Lock()
+	defer fake.stopMutex.Unlock()
+	fake.StopStub = nil
+	fake.stopReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeDirector) StopReturnsOnCall(i int, result1 error) {
+	fake.stopMutex.Lock()
+	defer fake.stopMutex.Unlock()
+	fake.StopStub = nil
+	if fake.stopReturnsOnCall == nil {
+		fake.stopReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.stopReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeDirector) UpdateConfig(arg1 string, arg2 string, arg3 []byte) (string, bool, error) {
+	var arg3Copy []byte
+	if arg3 != nil {
+		arg3Copy = make([]byte, len(arg3))
+		copy(arg3Copy, arg3)
+	}
+	fake.updateConfigMutex.Lock()
+	ret, specificReturn := fake.updateConfigReturnsOnCall[len(fake.updateConfigArgsForCall)]
+	fake.updateConfigArgsForCall = append(fake.updateConfigArgsForCall, struct {
+		arg1 string
+		arg2 string
+		arg3 []byte
+	}{arg1, arg2, arg3Copy})
+	stub := fake.UpdateConfigStub
+	fakeReturns := fake.updateConfigReturns
+	fake.recordInvocation("UpdateConfig", []interface{}{arg1, arg2, arg3Copy})
+	fake.updateConfigMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2, ret.result3
+	}
+	return fakeReturns.result1, fakeReturns.result2, fakeReturns.result3
+}
+
+func (fake *FakeDirector) UpdateConfigCallCount() int {
+	fake.updateConfigMutex.RLock()
+	defer fake.updateConfigMutex.RUnlock()
+	return len(fake.updateConfigArgsForCall)
+}
+
+func (fake *FakeDirector) UpdateConfigCalls(stub func(string, string, []byte) (string, bool, error)) {
+	fake.updateConfigMutex.Lock()
+	defer fake.updateConfigMutex.Unlock()
+	fake.UpdateConfigStub = stub
+}
+
+func (fake *FakeDirector) UpdateConfigArgsForCall(i int) (string, string, []byte) {
+	fake.updateConfigMutex.RLock()
+	defer fake.updateConfigMutex.RUnlock()
+	argsForCall := fake.updateConfigArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeDirector) UpdateConfigReturns(result1 string, result2 bool, result3 error) {
+	fake.updateConfigMutex.Lock()
+	defer fake.updateConfigMutex.Unlock()
+	fake.UpdateConfigStub = nil
+	fake.updateConfigReturns = struct {
+		result1 string
+		result2 bool
+		result3 error
+	}{result1, result2, result3}
+}
+
+func (fake *FakeDirector) UpdateConfigReturnsOnCall(i int, result1 string, result2 bool, result3 error) {
+	fake.updateConfigMutex.Lock()
+	defer fake.updateConfigMutex.Unlock()
+	fake.UpdateConfigStub = nil
+	if fake.updateConfigReturnsOnCall == nil {
+		fake.updateConfigReturnsOnCall = make(map[int]struct {
+			result1 string
+			result2 bool
+			result3 error
+		})
+	}
+	fake.updateConfigReturnsOnCall[i] = struct {
+		result1 string
+		result2 bool
+		result3 error
+	}{result1, result2, result3}
+}
+
+func (fake *FakeDirector) UploadRelease(arg1 string) error {
+	fake.uploadReleaseMutex.Lock()
+	ret, specificReturn := fake.uploadReleaseReturnsOnCall[len(fake.uploadReleaseArgsForCall)]
+	fake.uploadReleaseArgsForCall = append(fake.uploadReleaseArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.UploadReleaseStub
 	fakeReturns := fake.uploadReleaseReturns
+	fake.recordInvocation("UploadRelease", []interface{}{arg1})
+	fake.uploadReleaseMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
 	return fakeReturns.result1
 }
 
@@ -563,6 +1646,70 @@ func (fake *FakeDirector) UploadReleaseReturnsOnCall(i int, result1 error) {
 	}{result1}
 }
 
+func (fake *FakeDirector) UploadRemoteRelease(arg1 string, arg2 string, arg3 string, arg4 string) error {
+	fake.uploadRemoteReleaseMutex.Lock()
+	ret, specificReturn := fake.uploadRemoteReleaseReturnsOnCall[len(fake.uploadRemoteReleaseArgsForCall)]
+	fake.uploadRemoteReleaseArgsForCall = append(fake.uploadRemoteReleaseArgsForCall, struct {
+		arg1 string
+		arg2 string
+		arg3 string
+		arg4 string
+	}{arg1, arg2, arg3, arg4})
+	stub := fake.UploadRemoteReleaseStub
+	fakeReturns := fake.uploadRemoteReleaseReturns
+	fake.recordInvocation("UploadRemoteRelease", []interface{}{arg1, arg2, arg3, arg4})
+	fake.uploadRemoteReleaseMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3, arg4)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeDirector) UploadRemoteReleaseCallCount() int {
+	fake.uploadRemoteReleaseMutex.RLock()
+	defer fake.uploadRemoteReleaseMutex.RUnlock()
+	return len(fake.uploadRemoteReleaseArgsForCall)
+}
+
+func (fake *FakeDirector) UploadRemoteReleaseCalls(stub func(string, string, string, string) error) {
+	fake.uploadRemoteReleaseMutex.Lock()
+	defer fake.uploadRemoteReleaseMutex.Unlock()
+	fake.UploadRemoteReleaseStub = stub
+}
+
+func (fake *FakeDirector) UploadRemoteReleaseArgsForCall(i int) (string, string, string, string) {
+	fake.uploadRemoteReleaseMutex.RLock()
+	defer fake.uploadRemoteReleaseMutex.RUnlock()
+	argsForCall := fake.uploadRemoteReleaseArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4
+}
+
+func (fake *FakeDirector) UploadRemoteReleaseReturns(result1 error) {
+	fake.uploadRemoteReleaseMutex.Lock()
+	defer fake.uploadRemoteReleaseMutex.Unlock()
+	fake.UploadRemoteReleaseStub = nil
+	fake.uploadRemoteReleaseReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeDirector) UploadRemoteReleaseReturnsOnCall(i int, result1 error) {
+	fake.uploadRemoteReleaseMutex.Lock()
+	defer fake.uploadRemoteReleaseMutex.Unlock()
+	fake.UploadRemoteReleaseStub = nil
+	if fake.uploadRemoteReleaseReturnsOnCall == nil {
+		fake.uploadRemoteReleaseReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.uploadRemoteReleaseReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *FakeDirector) UploadRemoteStemcell(arg1 string, arg2 string, arg3 string, arg4 string) error {
 	fake.uploadRemoteStemcellMutex.Lock()
 	ret, specificReturn := fake.uploadRemoteStemcellReturnsOnCall[len(fake.uploadRemoteStemcellArgsForCall)]
@@ -572,15 +1719,16 @@ func (fake *FakeDirector) UploadRemoteStemcell(arg1 string, arg2 string, arg3 st
 		arg3 string
 		arg4 string
 	}{arg1, arg2, arg3, arg4})
+	stub := fake.UploadRemoteStemcellStub
+	fakeReturns := fake.uploadRemoteStemcellReturns
 	fake.recordInvocation("UploadRemoteStemcell", []interface{}{arg1, arg2, arg3, arg4})
 	fake.uploadRemoteStemcellMutex.Unlock()
-	if fake.UploadRemoteStemcellStub != nil {
-		return fake.UploadRemoteStemcellStub(arg1, arg2, arg3, arg4)
+	if stub != nil {
+		return stub(arg1, arg2, arg3, arg4)
 	}
 	if specificReturn {
 		return ret.result1
 	}
-	fakeReturns := fake.uploadRemoteStemcellReturns
 	return fakeReturns.result1
 }
 
@@ -632,15 +1780,16 @@ func (fake *FakeDirector) UploadStemcell(arg1 string) error {
 	fake.uploadStemcellArgsForCall = append(fake.uploadStemcellArgsForCall, struct {
 		arg1 string
 	}{arg1})
+	stub := fake.UploadStemcellStub
+	fakeReturns := fake.uploadStemcellReturns
 	fake.recordInvocation("UploadStemcell", []interface{}{arg1})
 	fake.uploadStemcellMutex.Unlock()
-	if fake.UploadStemcellStub != nil {
-		return fake.UploadStemcellStub(arg1)
+	if stub != nil {
+		return stub(arg1)
 	}
 	if specificReturn {
 		return ret.result1
 	}
-	fakeReturns := fake.uploadStemcellReturns
 	return fakeReturns.result1
 }
 
@@ -686,20 +1835,22 @@ func (fake *FakeDirector) UploadStemcellReturnsOnCall(i int, result1 error) {
 	}{result1}
 }
 
-func (fake *FakeDirector) WaitForDeployLock() error {
+func (fake *FakeDirector) WaitForDeployLock(arg1 time.Duration) error {
 	fake.waitForDeployLockMutex.Lock()
 	ret, specificReturn := fake.waitForDeployLockReturnsOnCall[len(fake.waitForDeployLockArgsForCall)]
 	fake.waitForDeployLockArgsForCall = append(fake.waitForDeployLockArgsForCall, struct {
-	}{})
-	fake.recordInvocation("WaitForDeployLock", []interface{}{})
+		arg1 time.Duration
+	}{arg1})
+	stub := fake.WaitForDeployLockStub
+	fakeReturns := fake.waitForDeployLockReturns
+	fake.recordInvocation("WaitForDeployLock", []interface{}{arg1})
 	fake.waitForDeployLockMutex.Unlock()
-	if fake.WaitForDeployLockStub != nil {
-		return fake.WaitForDeployLockStub()
+	if stub != nil {
+		return stub(arg1)
 	}
 	if specificReturn {
 		return ret.result1
 	}
-	fakeReturns := fake.waitForDeployLockReturns
 	return fakeReturns.result1
 }
 
@@ -709,12 +1860,19 @@ func (fake *FakeDirector) WaitForDeployLockCallCount() int {
 	return len(fake.waitForDeployLockArgsForCall)
 }
 
-func (fake *FakeDirector) WaitForDeployLockCalls(stub func() error) {
+func (fake *FakeDirector) WaitForDeployLockCalls(stub func(time.Duration) error) {
 	fake.waitForDeployLockMutex.Lock()
 	defer fake.waitForDeployLockMutex.Unlock()
 	fake.WaitForDeployLockStub = stub
 }
 
+func (fake *FakeDirector) WaitForDeployLockArgsForCall(i int) time.Duration {
+	fake.waitForDeployLockMutex.RLock()
+	defer fake.waitForDeployLockMutex.RUnlock()
+	argsForCall := fake.waitForDeployLockArgsForCall[i]
+	return argsForCall.arg1
+}
+
 func (fake *FakeDirector) WaitForDeployLockReturns(result1 error) {
 	fake.waitForDeployLockMutex.Lock()
 	defer fake.waitForDeployLockMutex.Unlock()
@@ -738,13 +1896,86 @@ func (fake *FakeDirector) WaitForDeployLockReturnsOnCall(i int, result1 error) {
 	}{result1}
 }
 
+func (fake *FakeDirector) WithOutputPrefix(arg1 string) bosh.Director {
+	fake.withOutputPrefixMutex.Lock()
+	ret, specificReturn := fake.withOutputPrefixReturnsOnCall[len(fake.withOutputPrefixArgsForCall)]
+	fake.withOutputPrefixArgsForCall = append(fake.withOutputPrefixArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.WithOutputPrefixStub
+	fakeReturns := fake.withOutputPrefixReturns
+	fake.recordInvocation("WithOutputPrefix", []interface{}{arg1})
+	fake.withOutputPrefixMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeDirector) WithOutputPrefixCallCount() int {
+	fake.withOutputPrefixMutex.RLock()
+	defer fake.withOutputPrefixMutex.RUnlock()
+	return len(fake.withOutputPrefixArgsForCall)
+}
+
+func (fake *FakeDirector) WithOutputPrefixCalls(stub func(string) bosh.Director) {
+	fake.withOutputPrefixMutex.Lock()
+	defer fake.withOutputPrefixMutex.Unlock()
+	fake.WithOutputPrefixStub = stub
+}
+
+func (fake *FakeDirector) WithOutputPrefixArgsForCall(i int) string {
+	fake.withOutputPrefixMutex.RLock()
+	defer fake.withOutputPrefixMutex.RUnlock()
+	argsForCall := fake.withOutputPrefixArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeDirector) WithOutputPrefixReturns(result1 bosh.Director) {
+	fake.withOutputPrefixMutex.Lock()
+	defer fake.withOutputPrefixMutex.Unlock()
+	fake.WithOutputPrefixStub = nil
+	fake.withOutputPrefixReturns = struct {
+		result1 bosh.Director
+	}{result1}
+}
+
+func (fake *FakeDirector) WithOutputPrefixReturnsOnCall(i int, result1 bosh.Director) {
+	fake.withOutputPrefixMutex.Lock()
+	defer fake.withOutputPrefixMutex.Unlock()
+	fake.WithOutputPrefixStub = nil
+	if fake.withOutputPrefixReturnsOnCall == nil {
+		fake.withOutputPrefixReturnsOnCall = make(map[int]struct {
+			result1 bosh.Director
+		})
+	}
+	fake.withOutputPrefixReturnsOnCall[i] = struct {
+		result1 bosh.Director
+	}{result1}
+}
+
 func (fake *FakeDirector) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
+	fake.attachToDeployTaskMutex.RLock()
+	defer fake.attachToDeployTaskMutex.RUnlock()
+	fake.certificateExpiryMutex.RLock()
+	defer fake.certificateExpiryMutex.RUnlock()
+	fake.cloudCheckMutex.RLock()
+	defer fake.cloudCheckMutex.RUnlock()
+	fake.createReleaseMutex.RLock()
+	defer fake.createReleaseMutex.RUnlock()
 	fake.deleteMutex.RLock()
 	defer fake.deleteMutex.RUnlock()
 	fake.deployMutex.RLock()
 	defer fake.deployMutex.RUnlock()
+	fake.diffMutex.RLock()
+	defer fake.diffMutex.RUnlock()
+	fake.diskTypeSizesMutex.RLock()
+	defer fake.diskTypeSizesMutex.RUnlock()
 	fake.downloadManifestMutex.RLock()
 	defer fake.downloadManifestMutex.RUnlock()
 	fake.exportReleasesMutex.RLock()
@@ -753,14 +1984,34 @@ func (fake *FakeDirector) Invocations() map[string][][]interface{} {
 	defer fake.infoMutex.RUnlock()
 	fake.interpolateMutex.RLock()
 	defer fake.interpolateMutex.RUnlock()
+	fake.recreateMutex.RLock()
+	defer fake.recreateMutex.RUnlock()
+	fake.releaseNeedsUploadMutex.RLock()
+	defer fake.releaseNeedsUploadMutex.RUnlock()
+	fake.restartMutex.RLock()
+	defer fake.restartMutex.RUnlock()
+	fake.runErrandMutex.RLock()
+	defer fake.runErrandMutex.RUnlock()
+	fake.startMutex.RLock()
+	defer fake.startMutex.RUnlock()
+	fake.stemcellNeedsUploadMutex.RLock()
+	defer fake.stemcellNeedsUploadMutex.RUnlock()
+	fake.stopMutex.RLock()
+	defer fake.stopMutex.RUnlock()
+	fake.updateConfigMutex.RLock()
+	defer fake.updateConfigMutex.RUnlock()
 	fake.uploadReleaseMutex.RLock()
 	defer fake.uploadReleaseMutex.RUnlock()
+	fake.uploadRemoteReleaseMutex.RLock()
+	defer fake.uploadRemoteReleaseMutex.RUnlock()
 	fake.uploadRemoteStemcellMutex.RLock()
 	defer fake.uploadRemoteStemcellMutex.RUnlock()
 	fake.uploadStemcellMutex.RLock()
 	defer fake.uploadStemcellMutex.RUnlock()
 	fake.waitForDeployLockMutex.RLock()
 	defer fake.waitForDeployLockMutex.RUnlock()
+	fake.withOutputPrefixMutex.RLock()
+	defer fake.withOutputPrefixMutex.RUnlock()
 	copiedInvocations := map[string][][]interface{}{}
 	for key, value := range fake.invocations {
 		copiedInvocations[key] = value
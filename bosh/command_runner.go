@@ -1,9 +1,17 @@
 package bosh
 
 import (
+	"fmt"
 	"io"
+	"path/filepath"
 
 	boshcmd "github.com/cloudfoundry/bosh-cli/v7/cmd"
+	cmdconf "github.com/cloudfoundry/bosh-cli/v7/cmd/config"
+	boshcmdopts "github.com/cloudfoundry/bosh-cli/v7/cmd/opts"
+	boshdir "github.com/cloudfoundry/bosh-cli/v7/director"
+	boshrel "github.com/cloudfoundry/bosh-cli/v7/release"
+	boshreldir "github.com/cloudfoundry/bosh-cli/v7/releasedir"
+	boshui "github.com/cloudfoundry/bosh-cli/v7/ui"
 )
 
 //go:generate counterfeiter . Runner
@@ -15,6 +23,7 @@ type Runner interface {
 
 type CommandRunner struct {
 	cliCoordinator CLICoordinator
+	contextID      string
 }
 
 func NewCommandRunner(cliCoordinator CLICoordinator) CommandRunner {
@@ -23,6 +32,13 @@ func NewCommandRunner(cliCoordinator CLICoordinator) CommandRunner {
 	}
 }
 
+// WithContext returns a runner that tags the tasks of the deploys, deletes
+// and instance actions it runs with the context ID.
+func (c CommandRunner) WithContext(contextID string) CommandRunner {
+	c.contextID = contextID
+	return c
+}
+
 func (c CommandRunner) ExecuteWithWriter(commandOpts interface{}, writer io.Writer) error {
 	return c.ExecuteWithDefaultOverride(commandOpts, func(opts interface{}) (interface{}, error) { return opts, nil }, writer)
 }
@@ -42,7 +58,98 @@ func (c CommandRunner) ExecuteWithDefaultOverride(commandOpts interface{}, overr
 		return err
 	}
 
+	if c.contextID != "" {
+		switch commandOpts.(type) {
+		case *boshcmdopts.DeployOpts, *boshcmdopts.DeleteDeploymentOpts, *boshcmdopts.StartOpts,
+			*boshcmdopts.StopOpts, *boshcmdopts.RestartOpts, *boshcmdopts.RecreateOpts:
+			return c.executeWithContext(globalOpts, commandOpts, deps)
+		}
+	}
+
 	cmd := boshcmd.NewCmd(globalOpts, commandOpts, deps)
 
 	return cmd.Execute()
 }
+
+// executeWithContext runs the command like boshcmd.Cmd does, but with a
+// director that sets the context ID on its requests. The CLI has no option
+// for the context ID.
+func (c CommandRunner) executeWithContext(globalOpts boshcmdopts.BoshOpts, commandOpts interface{}, deps boshcmd.BasicDeps) error {
+	deps.UI.EnableNonInteractive()
+	if !globalOpts.NoColorOpt {
+		deps.UI.EnableColor()
+	}
+
+	tmpDirPath, err := deps.FS.ExpandPath(filepath.Join("~", ".bosh", "tmp"))
+	if err != nil {
+		return err
+	}
+	if err := deps.FS.ChangeTempRoot(tmpDirPath); err != nil {
+		return err
+	}
+
+	config, err := cmdconf.NewFSConfigFromPath(globalOpts.ConfigPathOpt, deps.FS)
+	if err != nil {
+		return err
+	}
+
+	session := boshcmd.NewSessionFromOpts(globalOpts, config, deps.UI, true, true, deps.FS, deps.Logger)
+	director, err := session.Director()
+	if err != nil {
+		return err
+	}
+	director = director.WithContext(c.contextID)
+
+	deployment, err := director.FindDeployment(globalOpts.DeploymentOpt)
+	if err != nil {
+		return err
+	}
+
+	switch opts := commandOpts.(type) {
+	case *boshcmdopts.DeployOpts:
+		return boshcmd.NewDeployCmd(deps.UI, deployment, releaseManager(globalOpts, deps, director), director).Run(*opts)
+	case *boshcmdopts.DeleteDeploymentOpts:
+		return boshcmd.NewDeleteDeploymentCmd(deps.UI, deployment).Run(*opts)
+	case *boshcmdopts.StartOpts:
+		return boshcmd.NewStartCmd(deps.UI, deployment).Run(*opts)
+	case *boshcmdopts.StopOpts:
+		return boshcmd.NewStopCmd(deps.UI, deployment).Run(*opts)
+	case *boshcmdopts.RestartOpts:
+		return boshcmd.NewRestartCmd(deps.UI, deployment).Run(*opts)
+	case *boshcmdopts.RecreateOpts:
+		return boshcmd.NewRecreateCmd(deps.UI, deployment).Run(*opts)
+	default:
+		return fmt.Errorf("can not run %T with a context ID", commandOpts)
+	}
+}
+
+// releaseManager builds the release manager the deploy command uses to upload
+// releases given by url in the manifest.
+func releaseManager(globalOpts boshcmdopts.BoshOpts, deps boshcmd.BasicDeps, director boshdir.Director) boshcmd.ReleaseManager {
+	releaseProvider := boshrel.NewProvider(
+		deps.CmdRunner, deps.Compressor, deps.DigestCalculator, deps.FS, deps.Logger)
+
+	releaseDirProvider := boshreldir.NewProvider(
+		boshui.NewIndexReporter(deps.UI), boshui.NewReleaseIndexReporter(deps.UI), boshui.NewBlobsReporter(deps.UI),
+		releaseProvider, deps.DigestCalculator, deps.CmdRunner, deps.UUIDGen, deps.Time, deps.FS,
+		deps.DigestCreationAlgorithms, deps.Logger)
+
+	releaseDirFactory := func(dir boshcmdopts.DirOrCWDArg) (boshrel.Reader, boshreldir.ReleaseDir) {
+		releaseReader := releaseDirProvider.NewReleaseReader(dir.Path, globalOpts.Parallel)
+		releaseDir := releaseDirProvider.NewFSReleaseDir(dir.Path, globalOpts.Parallel)
+		return releaseReader, releaseDir
+	}
+
+	releaseWriter := releaseProvider.NewArchiveWriter()
+
+	createReleaseCmd := boshcmd.NewCreateReleaseCmd(releaseDirFactory, releaseWriter, deps.FS, deps.UI)
+
+	releaseArchiveFactory := func(path string) boshdir.ReleaseArchive {
+		return boshdir.NewFSReleaseArchive(path, deps.FS)
+	}
+
+	uploadReleaseCmd := boshcmd.NewUploadReleaseCmd(
+		releaseDirFactory, releaseWriter, director, releaseArchiveFactory, deps.CmdRunner, deps.FS, deps.UI)
+
+	return boshcmd.NewReleaseManager(createReleaseCmd, uploadReleaseCmd, globalOpts.Parallel)
+}
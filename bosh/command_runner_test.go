@@ -0,0 +1,124 @@
+package bosh_test
+
+import (
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+
+	boshcmdopts "github.com/cloudfoundry/bosh-cli/v7/cmd/opts"
+
+	"github.com/cloudfoundry/bosh-deployment-resource/bosh"
+	"github.com/cloudfoundry/bosh-deployment-resource/bosh/boshfakes"
+	"github.com/cloudfoundry/bosh-deployment-resource/concourse"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// fakeDirector answers the requests of a deploy and a delete and records
+// the context ID of the requests that create their tasks.
+type fakeDirector struct {
+	lock              sync.Mutex
+	deployed          bool
+	deployContextID   string
+	deployContentType string
+	deleted           bool
+	deleteContextID   string
+}
+
+func (f *fakeDirector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == "/info":
+		w.Write([]byte(`{"name":"some-director","uuid":"some-uuid","version":"280.0.0","user_authentication":{"type":"basic","options":{}}}`)) //nolint:errcheck
+	case r.Method == http.MethodGet && r.URL.Path == "/configs":
+		w.Write([]byte(`[]`)) //nolint:errcheck
+	case r.Method == http.MethodPost && r.URL.Path == "/deployments/some-deployment/diff":
+		w.Write([]byte(`{"context":{},"diff":[]}`)) //nolint:errcheck
+	case r.Method == http.MethodPost && r.URL.Path == "/deployments":
+		f.deployed = true
+		f.deployContextID = r.Header.Get("X-Bosh-Context-Id")
+		f.deployContentType = r.Header.Get("Content-Type")
+		w.WriteHeader(http.StatusBadRequest)
+	case r.Method == http.MethodDelete && r.URL.Path == "/deployments/some-deployment":
+		f.deleted = true
+		f.deleteContextID = r.Header.Get("X-Bosh-Context-Id")
+		w.WriteHeader(http.StatusBadRequest)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+var _ = Describe("CommandRunner", func() {
+	var (
+		director       *fakeDirector
+		server         *httptest.Server
+		cliCoordinator bosh.CLICoordinator
+		deployOpts     *boshcmdopts.DeployOpts
+		home           string
+		originalHome   string
+	)
+
+	BeforeEach(func() {
+		director = &fakeDirector{}
+		server = httptest.NewTLSServer(director)
+
+		caCert := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+		source := concourse.Source{
+			Target:       server.URL,
+			Client:       "some-client",
+			ClientSecret: "some-secret",
+			CACert:       string(caCert),
+			Deployment:   "some-deployment",
+		}
+		cliCoordinator = bosh.NewCLICoordinator(source, GinkgoWriter, &boshfakes.FakeProxy{})
+
+		var err error
+		home, err = os.MkdirTemp("", "command-runner-home")
+		Expect(err).NotTo(HaveOccurred())
+		originalHome = os.Getenv("HOME")
+		os.Setenv("HOME", home) //nolint:errcheck
+
+		deployOpts = &boshcmdopts.DeployOpts{}
+		deployOpts.Args.Manifest.Bytes = []byte("name: some-deployment\n")
+	})
+
+	AfterEach(func() {
+		server.Close()
+		os.Setenv("HOME", originalHome) //nolint:errcheck
+		os.RemoveAll(home)              //nolint:errcheck
+	})
+
+	Describe("Execute", func() {
+		It("deploys with the CLI", func() {
+			err := bosh.NewCommandRunner(cliCoordinator).Execute(deployOpts)
+			Expect(err).To(HaveOccurred())
+
+			Expect(director.deployed).To(BeTrue())
+			Expect(director.deployContentType).To(Equal("text/yaml"))
+			Expect(director.deployContextID).To(BeEmpty())
+		})
+
+		Context("with a context ID", func() {
+			It("tags the deploy task with the context ID", func() {
+				err := bosh.NewCommandRunner(cliCoordinator).WithContext("some-context-id").Execute(deployOpts)
+				Expect(err).To(HaveOccurred())
+
+				Expect(director.deployed).To(BeTrue())
+				Expect(director.deployContentType).To(Equal("text/yaml"))
+				Expect(director.deployContextID).To(Equal("some-context-id"))
+			})
+
+			It("tags the delete task with the context ID", func() {
+				err := bosh.NewCommandRunner(cliCoordinator).WithContext("some-context-id").Execute(&boshcmdopts.DeleteDeploymentOpts{})
+				Expect(err).To(HaveOccurred())
+
+				Expect(director.deleted).To(BeTrue())
+				Expect(director.deleteContextID).To(Equal("some-context-id"))
+			})
+		})
+	})
+})
@@ -0,0 +1,192 @@
+package bosh
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+type DiffLine struct {
+	Text  string
+	State string
+}
+
+type DeploymentDiff struct {
+	Lines []DiffLine
+}
+
+type InstanceGroupSpec struct {
+	Instances          *int
+	PersistentDisk     *int
+	PersistentDiskType string
+}
+
+// InstanceGroupChange holds the keys of an instance group that appear in a
+// diff, before and after the deploy. Keys that did not change are not set.
+type InstanceGroupChange struct {
+	Name   string
+	Before InstanceGroupSpec
+	After  InstanceGroupSpec
+}
+
+func NewDeploymentDiff(diff [][]interface{}) DeploymentDiff {
+	lines := []DiffLine{}
+	for _, line := range diff {
+		diffLine := DiffLine{}
+		if len(line) > 0 {
+			diffLine.Text, _ = line[0].(string) //nolint:errcheck
+		}
+		if len(line) > 1 {
+			diffLine.State, _ = line[1].(string) //nolint:errcheck
+		}
+		lines = append(lines, diffLine)
+	}
+
+	return DeploymentDiff{Lines: lines}
+}
+
+func (d DeploymentDiff) HasChanges() bool {
+	for _, line := range d.Lines {
+		if line.State == "added" || line.State == "removed" {
+			return true
+		}
+	}
+	return false
+}
+
+func (d DeploymentDiff) String() string {
+	var builder strings.Builder
+	for _, line := range d.Lines {
+		prefix := " "
+		switch line.State {
+		case "added":
+			prefix = "+"
+		case "removed":
+			prefix = "-"
+		}
+		fmt.Fprintf(&builder, "%s %s\n", prefix, line.Text) //nolint:errcheck
+	}
+	return builder.String()
+}
+
+func (d DeploymentDiff) Sha256() string {
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(d.String())))
+}
+
+func (d DeploymentDiff) RemovedInstanceGroups() ([]string, error) {
+	return d.removedEntries("instance_groups")
+}
+
+func (d DeploymentDiff) RemovedReleases() ([]string, error) {
+	return d.removedEntries("releases")
+}
+
+func (d DeploymentDiff) ChangedInstanceGroups() ([]InstanceGroupChange, error) {
+	before, after, err := d.manifests()
+	if err != nil {
+		return nil, err
+	}
+
+	beforeGroups := namedEntries(before, "instance_groups")
+	afterGroups := namedEntries(after, "instance_groups")
+
+	changes := []InstanceGroupChange{}
+	for _, name := range sortedNames(beforeGroups) {
+		afterGroup, ok := afterGroups[name]
+		if !ok {
+			continue
+		}
+		changes = append(changes, InstanceGroupChange{
+			Name:   name,
+			Before: newInstanceGroupSpec(beforeGroups[name]),
+			After:  newInstanceGroupSpec(afterGroup),
+		})
+	}
+
+	return changes, nil
+}
+
+func (d DeploymentDiff) removedEntries(section string) ([]string, error) {
+	before, after, err := d.manifests()
+	if err != nil {
+		return nil, err
+	}
+
+	afterEntries := namedEntries(after, section)
+
+	removed := []string{}
+	for _, name := range sortedNames(namedEntries(before, section)) {
+		if _, ok := afterEntries[name]; !ok {
+			removed = append(removed, name)
+		}
+	}
+
+	return removed, nil
+}
+
+// manifests rebuilds the parts of the manifest shown in the diff, as they
+// were before and as they will be after the deploy.
+func (d DeploymentDiff) manifests() (map[interface{}]interface{}, map[interface{}]interface{}, error) {
+	var before, after strings.Builder
+	for _, line := range d.Lines {
+		if line.State != "added" {
+			before.WriteString(line.Text + "\n")
+		}
+		if line.State != "removed" {
+			after.WriteString(line.Text + "\n")
+		}
+	}
+
+	beforeManifest := map[interface{}]interface{}{}
+	if err := yaml.Unmarshal([]byte(before.String()), &beforeManifest); err != nil {
+		return nil, nil, fmt.Errorf("could not parse deployment diff: %s", err)
+	}
+
+	afterManifest := map[interface{}]interface{}{}
+	if err := yaml.Unmarshal([]byte(after.String()), &afterManifest); err != nil {
+		return nil, nil, fmt.Errorf("could not parse deployment diff: %s", err)
+	}
+
+	return beforeManifest, afterManifest, nil
+}
+
+func namedEntries(manifest map[interface{}]interface{}, section string) map[string]map[interface{}]interface{} {
+	entries := map[string]map[interface{}]interface{}{}
+
+	items, _ := manifest[section].([]interface{}) //nolint:errcheck
+	for _, item := range items {
+		entry, ok := item.(map[interface{}]interface{})
+		if !ok {
+			continue
+		}
+		if name, ok := entry["name"].(string); ok {
+			entries[name] = entry
+		}
+	}
+
+	return entries
+}
+
+func sortedNames(entries map[string]map[interface{}]interface{}) []string {
+	names := []string{}
+	for name := range entries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func newInstanceGroupSpec(instanceGroup map[interface{}]interface{}) InstanceGroupSpec {
+	spec := InstanceGroupSpec{}
+	if instances, ok := instanceGroup["instances"].(int); ok {
+		spec.Instances = &instances
+	}
+	if persistentDisk, ok := instanceGroup["persistent_disk"].(int); ok {
+		spec.PersistentDisk = &persistentDisk
+	}
+	spec.PersistentDiskType, _ = instanceGroup["persistent_disk_type"].(string) //nolint:errcheck
+	return spec
+}
@@ -0,0 +1,90 @@
+package bosh_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry/bosh-deployment-resource/bosh"
+)
+
+var _ = Describe("DeploymentDiff", func() {
+	var diff bosh.DeploymentDiff
+
+	BeforeEach(func() {
+		diff = bosh.NewDeploymentDiff([][]interface{}{
+			{"instance_groups:", nil},
+			{"- name: web", nil},
+			{"  instances: 2", "removed"},
+			{"  instances: 3", "added"},
+		})
+	})
+
+	It("parses the lines returned by the director", func() {
+		Expect(diff.Lines).To(Equal([]bosh.DiffLine{
+			{Text: "instance_groups:"},
+			{Text: "- name: web"},
+			{Text: "  instances: 2", State: "removed"},
+			{Text: "  instances: 3", State: "added"},
+		}))
+	})
+
+	It("prints the diff like the bosh cli", func() {
+		Expect(diff.String()).To(Equal("  instance_groups:\n  - name: web\n-   instances: 2\n+   instances: 3\n"))
+	})
+
+	Describe("HasChanges", func() {
+		It("is true when lines were added or removed", func() {
+			Expect(diff.HasChanges()).To(BeTrue())
+		})
+
+		It("is false when there are only context lines", func() {
+			Expect(bosh.NewDeploymentDiff([][]interface{}{{"name: foo", nil}}).HasChanges()).To(BeFalse())
+		})
+	})
+
+	Describe("guard helpers", func() {
+		BeforeEach(func() {
+			diff = bosh.NewDeploymentDiff([][]interface{}{
+				{"instance_groups:", nil},
+				{"- name: api", nil},
+				{"  instances: 4", "removed"},
+				{"  instances: 2", "added"},
+				{"  persistent_disk: 2048", "removed"},
+				{"  persistent_disk_type: small", "added"},
+				{"- name: worker", "removed"},
+				{"  instances: 1", "removed"},
+				{"releases:", nil},
+				{"- name: old-release", "removed"},
+				{"  version: 1", "removed"},
+				{"- name: new-release", "added"},
+				{"  version: 2", "added"},
+			})
+		})
+
+		It("finds removed instance groups", func() {
+			Expect(diff.RemovedInstanceGroups()).To(Equal([]string{"worker"}))
+		})
+
+		It("finds removed releases", func() {
+			Expect(diff.RemovedReleases()).To(Equal([]string{"old-release"}))
+		})
+
+		It("describes instance groups before and after the deploy", func() {
+			four, two, disk := 4, 2, 2048
+			Expect(diff.ChangedInstanceGroups()).To(Equal([]bosh.InstanceGroupChange{{
+				Name:   "api",
+				Before: bosh.InstanceGroupSpec{Instances: &four, PersistentDisk: &disk},
+				After:  bosh.InstanceGroupSpec{Instances: &two, PersistentDiskType: "small"},
+			}}))
+		})
+
+		Context("when the diff is not valid yaml", func() {
+			It("returns an error", func() {
+				diff = bosh.NewDeploymentDiff([][]interface{}{{"- : : :", "added"}, {"{", nil}})
+
+				_, err := diff.ChangedInstanceGroups()
+				Expect(err).To(MatchError(ContainSubstring("could not parse deployment diff")))
+			})
+		})
+	})
+})
@@ -3,6 +3,7 @@ package bosh
 import (
 	"errors"
 	"fmt"
+	"strings"
 
 	"gopkg.in/yaml.v2"
 )
@@ -39,6 +40,24 @@ func (d DeploymentManifest) UseReleaseVersion(releaseName, version string) error
 	return fmt.Errorf("Release %s not defined in deployment manifest", releaseName) //nolint:staticcheck
 }
 
+func (d DeploymentManifest) UseReleaseURL(releaseName, url, sha1 string) error {
+	releases, ok := d.manifest["releases"].([]interface{})
+	if !ok {
+		return errors.New("No releases section in deployment manifest") //nolint:staticcheck
+	}
+
+	for i := range releases {
+		release := releases[i].(map[interface{}]interface{})
+		if release["name"] == releaseName {
+			release["url"] = url
+			release["sha1"] = sha1
+			return nil
+		}
+	}
+
+	return fmt.Errorf("Release %s not defined in deployment manifest", releaseName) //nolint:staticcheck
+}
+
 func (d DeploymentManifest) UseStemcellVersion(stemcellName, os, version string) error {
 	stemcells, ok := d.manifest["stemcells"].([]interface{})
 	if !ok {
@@ -72,6 +91,33 @@ func (d DeploymentManifest) UseStemcellVersion(stemcellName, os, version string)
 	return nil
 }
 
+// ReleaseName returns the name of the manifest release that is downloaded
+// from the bosh.io repository, or else the one named like the repository
+// without a -release, -bosh-release or -boshrelease suffix.
+func (d DeploymentManifest) ReleaseName(repository string) (string, error) {
+	releases, err := d.Releases()
+	if err != nil {
+		return "", err
+	}
+
+	for _, release := range releases {
+		if IsBoshIOURL(release.URL) && boshIORepository(release.URL) == repository {
+			return release.Name, nil
+		}
+	}
+
+	repositoryName := repository[strings.LastIndex(repository, "/")+1:]
+	for _, suffix := range []string{"", "-boshrelease", "-bosh-release", "-release"} {
+		for _, release := range releases {
+			if release.Name+suffix == repositoryName {
+				return release.Name, nil
+			}
+		}
+	}
+
+	return "", fmt.Errorf("no release of the manifest is from bosh.io repository %s, add url: %s%s to the release", repository, boshIODownloadURL, repository)
+}
+
 func (d DeploymentManifest) Manifest() []byte {
 	bytes, _ := yaml.Marshal(d.manifest) //nolint:errcheck
 
@@ -101,3 +147,62 @@ func (d DeploymentManifest) Stemcells() ([]Stemcell, error) {
 	}
 	return out, nil
 }
+
+func (d DeploymentManifest) Releases() ([]Release, error) {
+	releases, ok := d.manifest["releases"].([]interface{})
+	if !ok {
+		return nil, errors.New("No releases section in deployment manifest") //nolint:staticcheck
+	}
+
+	out := make([]Release, 0)
+	for i := range releases {
+		release := releases[i].(map[interface{}]interface{})
+		name, ok := release["name"].(string)
+		if !ok {
+			return nil, errors.New("expected name key for release")
+		}
+
+		version, ok := release["version"]
+		if !ok {
+			return nil, errors.New("expected version key for release")
+		}
+
+		url, _ := release["url"].(string)   //nolint:errcheck
+		sha1, _ := release["sha1"].(string) //nolint:errcheck
+
+		out = append(out, Release{Name: name, Version: fmt.Sprint(version), URL: url, Sha1: sha1})
+	}
+	return out, nil
+}
+
+// Variable is a variable of the variables section of the manifest, which
+// BOSH generates when it is not in the vars store.
+type Variable struct {
+	Name    string
+	Type    string
+	Options map[interface{}]interface{}
+}
+
+// Variables returns the variables of the manifest, the section is optional.
+func (d DeploymentManifest) Variables() ([]Variable, error) {
+	variables, _ := d.manifest["variables"].([]interface{}) //nolint:errcheck
+
+	out := make([]Variable, 0)
+	for i := range variables {
+		variable, ok := variables[i].(map[interface{}]interface{})
+		if !ok {
+			return nil, errors.New("expected variables to be a list")
+		}
+
+		name, ok := variable["name"].(string)
+		if !ok {
+			return nil, errors.New("expected name key for variable")
+		}
+
+		variableType, _ := variable["type"].(string)                    //nolint:errcheck
+		options, _ := variable["options"].(map[interface{}]interface{}) //nolint:errcheck
+
+		out = append(out, Variable{Name: name, Type: variableType, Options: options})
+	}
+	return out, nil
+}
@@ -64,6 +64,37 @@ var _ = Describe("DeploymentManifest", func() {
 		})
 	})
 
+	Describe("UseReleaseURL", func() {
+		It("sets the url and sha1 of the requested release", func() {
+			d, _ := bosh.NewDeploymentManifest( //nolint:errcheck
+				properYaml(`
+				releases:
+				- name: cool-release
+				  version: "6"
+			`))
+
+			err := d.UseReleaseURL("cool-release", "https://example.com/cool-release.tgz", "cool-sha")
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(d.Manifest()).To(MatchYAML(properYaml(`
+				releases:
+				- name: cool-release
+				  version: "6"
+				  url: https://example.com/cool-release.tgz
+				  sha1: cool-sha
+			`)))
+		})
+
+		Context("when the release is not found", func() {
+			It("returns an error", func() {
+				d, _ := bosh.NewDeploymentManifest(properYaml(`releases: []`)) //nolint:errcheck
+
+				err := d.UseReleaseURL("unknown-release", "https://example.com/cool-release.tgz", "cool-sha")
+				Expect(err).To(MatchError("Release unknown-release not defined in deployment manifest"))
+			})
+		})
+	})
+
 	Describe("UseStemcell", func() {
 		It("updates the requested stemcell version to match the provided stemcell", func() {
 			d, _ := bosh.NewDeploymentManifest( //nolint:errcheck
@@ -173,4 +204,122 @@ var _ = Describe("DeploymentManifest", func() {
 			Expect(stemcells[1].OperatingSystem).To(Equal("ubuntu-trusty"))
 		})
 	})
+
+	Describe("Releases", func() {
+		It("returns name, version and url for defined releases", func() {
+			d, err := bosh.NewDeploymentManifest(properYaml(`
+				releases:
+				- name: cool-release
+				  version: 280
+				  url: https://bosh.io/d/github.com/cloudfoundry/cool-release?v=280
+				  sha1: cool-sha
+				- name: other-release
+				  version: latest
+			`))
+			Expect(err).ToNot(HaveOccurred())
+
+			releases, err := d.Releases()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(releases).To(Equal([]bosh.Release{
+				{Name: "cool-release", Version: "280", URL: "https://bosh.io/d/github.com/cloudfoundry/cool-release?v=280", Sha1: "cool-sha"},
+				{Name: "other-release", Version: "latest"},
+			}))
+		})
+
+		Context("when a release has no version", func() {
+			It("returns an error", func() {
+				d, _ := bosh.NewDeploymentManifest( //nolint:errcheck
+					properYaml(`
+					releases:
+					- name: cool-release
+				`))
+
+				_, err := d.Releases()
+				Expect(err).To(MatchError("expected version key for release"))
+			})
+		})
+	})
+
+	Describe("Variables", func() {
+		It("returns name, type and options for defined variables", func() {
+			d, err := bosh.NewDeploymentManifest(properYaml(`
+				variables:
+				- name: default_ca
+				  type: certificate
+				  options:
+				    is_ca: true
+				    common_name: ca
+				- name: admin_password
+				  type: password
+			`))
+			Expect(err).ToNot(HaveOccurred())
+
+			variables, err := d.Variables()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(variables).To(Equal([]bosh.Variable{
+				{Name: "default_ca", Type: "certificate", Options: map[interface{}]interface{}{"is_ca": true, "common_name": "ca"}},
+				{Name: "admin_password", Type: "password"},
+			}))
+		})
+
+		It("returns no variables when the manifest has none", func() {
+			d, err := bosh.NewDeploymentManifest(properYaml(`
+				name: some-deployment
+			`))
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(d.Variables()).To(BeEmpty())
+		})
+
+		Context("when a variable has no name", func() {
+			It("returns an error", func() {
+				d, _ := bosh.NewDeploymentManifest( //nolint:errcheck
+					properYaml(`
+					variables:
+					- type: password
+				`))
+
+				_, err := d.Variables()
+				Expect(err).To(MatchError("expected name key for variable"))
+			})
+		})
+	})
+
+	Describe("ReleaseName", func() {
+		var d bosh.DeploymentManifest
+
+		BeforeEach(func() {
+			var err error
+			d, err = bosh.NewDeploymentManifest(properYaml(`
+				releases:
+				- name: concourse
+				  version: latest
+				  url: https://bosh.io/d/github.com/concourse/concourse-bosh-release
+				- name: haproxy
+				  version: latest
+				- name: routing
+				  version: latest
+				- name: bpm
+				  version: latest
+			`))
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("finds the release downloaded from the repository", func() {
+			Expect(d.ReleaseName("github.com/concourse/concourse-bosh-release")).To(Equal("concourse"))
+		})
+
+		It("finds the release named like the repository without its suffix", func() {
+			Expect(d.ReleaseName("github.com/cloudfoundry/haproxy-boshrelease")).To(Equal("haproxy"))
+			Expect(d.ReleaseName("github.com/cloudfoundry/routing-release")).To(Equal("routing"))
+			Expect(d.ReleaseName("github.com/cloudfoundry/bpm-release")).To(Equal("bpm"))
+		})
+
+		Context("when no release is from the repository", func() {
+			It("returns an error", func() {
+				_, err := d.ReleaseName("github.com/cloudfoundry/uaa-release")
+				Expect(err).To(MatchError("no release of the manifest is from bosh.io repository github.com/cloudfoundry/uaa-release, add url: https://bosh.io/d/github.com/cloudfoundry/uaa-release to the release"))
+			})
+		})
+	})
 })
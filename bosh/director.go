@@ -6,8 +6,12 @@ import (
 	"fmt"
 	"io"
 	"strconv"
+	"strings"
+	"sync"
 	"time"
 
+	"gopkg.in/yaml.v2"
+
 	"github.com/cloudfoundry/bosh-deployment-resource/concourse"
 
 	boshcmdopts "github.com/cloudfoundry/bosh-cli/v7/cmd/opts"
@@ -23,13 +27,13 @@ type DeployParams struct {
 	VarsFiles   []string
 	OpsFiles    []string
 	NoRedact    bool
-	DryRun      bool
 	MaxInFlight int
 	Recreate    bool
 	SkipDrain   []string
 	Cleanup     bool
 	VarsStore   string
 	Fix         bool
+	Timeout     time.Duration
 }
 
 type InterpolateParams struct {
@@ -44,18 +48,77 @@ type ReleaseSpec struct {
 	Jobs []string
 }
 
+type CreateReleaseParams struct {
+	Dir     string
+	Tarball string
+	Version string
+	Force   bool
+	Final   bool
+}
+
+type ErrandParams struct {
+	Name        string
+	Instances   []string
+	KeepAlive   bool
+	WhenChanged bool
+}
+
+type InstanceActionParams struct {
+	Instances   []string
+	SkipDrain   bool
+	Hard        bool
+	Canaries    int
+	MaxInFlight int
+}
+
+type CloudCheckProblem struct {
+	ID          int
+	Type        string
+	Description string
+	Resolution  string
+}
+
+type ErrandResult struct {
+	Instance string
+	ExitCode int
+	Stdout   string
+	Stderr   string
+}
+
+type CertificateExpiry struct {
+	Path     string
+	Expiry   string
+	DaysLeft int
+}
+
 //go:generate counterfeiter . Director
 type Director interface {
 	Delete(force bool) error
 	Deploy(manifestBytes []byte, deployParams DeployParams) error
+	Diff(manifestBytes []byte, deployParams DeployParams) (DeploymentDiff, error)
 	Interpolate(manifestBytes []byte, interpolateParams InterpolateParams) ([]byte, error)
 	DownloadManifest() ([]byte, error)
 	ExportReleases(targetDirectory string, releases []ReleaseSpec) error
+	CreateRelease(createReleaseParams CreateReleaseParams) error
 	UploadRelease(releaseURL string) error
 	UploadStemcell(stemcellURL string) error
+	UploadRemoteRelease(releaseURL, name, version, sha string) error
+	ReleaseNeedsUpload(release Release) (bool, error)
+	StemcellNeedsUpload(stemcell Stemcell) (bool, error)
 	UploadRemoteStemcell(stemcellURL, name, version, sha string) error
 	Info() (boshdir.Info, error)
-	WaitForDeployLock() error
+	DiskTypeSizes() (map[string]int, error)
+	CertificateExpiry() ([]CertificateExpiry, error)
+	RunErrand(errandParams ErrandParams) ([]ErrandResult, error)
+	Start(actionParams InstanceActionParams) error
+	Stop(actionParams InstanceActionParams) error
+	Restart(actionParams InstanceActionParams) error
+	Recreate(actionParams InstanceActionParams) error
+	UpdateConfig(configType, name string, content []byte) (string, bool, error)
+	CloudCheck(policy map[string]string, reportOnly bool) ([]CloudCheckProblem, error)
+	WaitForDeployLock(timeout time.Duration) error
+	AttachToDeployTask() (int, error)
+	WithOutputPrefix(prefix string) Director
 }
 
 type BoshDirector struct {
@@ -63,6 +126,9 @@ type BoshDirector struct {
 	commandRunner Runner
 	cliDirector   boshdir.Director
 	writer        io.Writer
+	outputLock    *sync.Mutex
+	contextID     string
+	retry         retryPolicy
 }
 
 func NewBoshDirector(source concourse.Source, commandRunner Runner, cliDirector boshdir.Director, writer io.Writer) BoshDirector {
@@ -71,25 +137,34 @@ func NewBoshDirector(source concourse.Source, commandRunner Runner, cliDirector
 		commandRunner: commandRunner,
 		cliDirector:   cliDirector,
 		writer:        writer,
+		outputLock:    &sync.Mutex{},
+		retry:         newRetryPolicy(source.Retry),
 	}
 }
 
-func (d BoshDirector) Delete(force bool) error {
-	return d.commandRunner.Execute(&boshcmdopts.DeleteDeploymentOpts{Force: force})
+// WithContext returns a director that tags the tasks it starts through the
+// director API with the context ID, so CancelTasks can find them again.
+func (d BoshDirector) WithContext(contextID string) BoshDirector {
+	d.contextID = contextID
+	d.cliDirector = d.cliDirector.WithContext(contextID)
+	return d
 }
 
-func (d BoshDirector) Deploy(manifestBytes []byte, deployParams DeployParams) error {
-	boshVarsFiles, err := parsedVarsFiles(deployParams.VarsFiles)
-	if err != nil {
-		return err
-	}
+// WithOutputPrefix returns a director that prefixes each line of its output,
+// for running commands at the same time as other directors from this one.
+func (d BoshDirector) WithOutputPrefix(prefix string) Director {
+	writer := newPrefixWriter(d.writer, prefix, d.outputLock)
+	d.writer = writer
+	d.commandRunner = prefixedRunner{runner: d.commandRunner, writer: writer}
+	return d
+}
 
-	boshVarFiles, err := parsedVarFiles(deployParams.VarFiles)
-	if err != nil {
-		return err
-	}
+func (d BoshDirector) Delete(force bool) error {
+	return d.executeTask(&boshcmdopts.DeleteDeploymentOpts{Force: force}, 0)
+}
 
-	boshOpsFiles, err := parsedOpsFiles(deployParams.OpsFiles)
+func (d BoshDirector) Deploy(manifestBytes []byte, deployParams DeployParams) error {
+	varFlags, opsFlags, err := deployFlags(deployParams)
 	if err != nil {
 		return err
 	}
@@ -102,29 +177,15 @@ func (d BoshDirector) Deploy(manifestBytes []byte, deployParams DeployParams) er
 	deployOpts := boshcmdopts.DeployOpts{
 		Args:        boshcmdopts.DeployArgs{Manifest: boshcmdopts.FileBytesArg{Bytes: manifestBytes}},
 		NoRedact:    deployParams.NoRedact,
-		DryRun:      deployParams.DryRun,
 		MaxInFlight: convertMaxInFlight(deployParams.MaxInFlight),
 		Recreate:    deployParams.Recreate,
 		SkipDrain:   skipDrains,
 		Fix:         deployParams.Fix,
-		VarFlags: boshcmdopts.VarFlags{
-			VarKVs:    varKVsFromVars(deployParams.Vars),
-			VarsFiles: boshVarsFiles,
-			VarFiles:  boshVarFiles,
-		},
-		OpsFlags: boshcmdopts.OpsFlags{
-			OpsFiles: boshOpsFiles,
-		},
+		VarFlags:    varFlags,
+		OpsFlags:    opsFlags,
 	}
 
-	if deployParams.VarsStore != "" {
-		varsFSStore := boshcmdopts.VarsFSStore{}
-		varsFSStore.FS = boshFileSystem()
-		varsFSStore.UnmarshalFlag(deployParams.VarsStore) //nolint:errcheck
-		deployOpts.VarsFSStore = varsFSStore
-	}
-
-	err = d.commandRunner.Execute(&deployOpts)
+	err = d.executeTask(&deployOpts, deployParams.Timeout)
 	if err != nil {
 		return fmt.Errorf("Could not deploy: %s\n", err) //nolint:staticcheck
 	}
@@ -136,6 +197,30 @@ func (d BoshDirector) Deploy(manifestBytes []byte, deployParams DeployParams) er
 	return nil
 }
 
+func (d BoshDirector) Diff(manifestBytes []byte, deployParams DeployParams) (DeploymentDiff, error) {
+	varFlags, opsFlags, err := deployFlags(deployParams)
+	if err != nil {
+		return DeploymentDiff{}, err
+	}
+
+	evaluatedManifest, err := boshtpl.NewTemplate(manifestBytes).Evaluate(varFlags.AsVariables(), opsFlags.AsOp(), boshtpl.EvaluateOpts{})
+	if err != nil {
+		return DeploymentDiff{}, fmt.Errorf("Could not evaluate manifest: %s\n", err) //nolint:staticcheck
+	}
+
+	deployment, err := d.deployment()
+	if err != nil {
+		return DeploymentDiff{}, err
+	}
+
+	diff, err := deployment.Diff(evaluatedManifest, deployParams.NoRedact)
+	if err != nil {
+		return DeploymentDiff{}, fmt.Errorf("Could not diff manifest: %s\n", err) //nolint:staticcheck
+	}
+
+	return NewDeploymentDiff(diff.Diff), nil
+}
+
 func (d BoshDirector) Interpolate(manifestBytes []byte, interpolateParams InterpolateParams) ([]byte, error) {
 	boshVarsFiles, err := parsedVarsFiles(interpolateParams.VarsFiles)
 	if err != nil {
@@ -168,18 +253,78 @@ func (d BoshDirector) Interpolate(manifestBytes []byte, interpolateParams Interp
 }
 
 func (d BoshDirector) DownloadManifest() ([]byte, error) {
-	desiredDeployment, err := d.cliDirector.FindDeployment(d.source.Deployment)
-	if err != nil {
-		return nil, fmt.Errorf("Could not get deployment manifest: %s\n", err) //nolint:staticcheck
-	}
+	var manifest string
+	err := d.retry.Do(d.writer, "download manifest", func() error {
+		desiredDeployment, err := d.cliDirector.FindDeployment(d.source.Deployment)
+		if err != nil {
+			return fmt.Errorf("Could not get deployment manifest: %s\n", err) //nolint:staticcheck
+		}
 
-	manifest, err := desiredDeployment.Manifest()
+		manifest, err = desiredDeployment.Manifest()
+		return err
+	})
 	return []byte(manifest), err
 }
 
 func (d BoshDirector) UploadRelease(URL string) error {
-	err := d.commandRunner.Execute(&boshcmdopts.UploadReleaseOpts{
-		Args: boshcmdopts.UploadReleaseArgs{URL: boshcmdopts.URLArg(URL)},
+	err := d.retry.Do(d.writer, "upload release", func() error {
+		return d.commandRunner.Execute(&boshcmdopts.UploadReleaseOpts{
+			Args: boshcmdopts.UploadReleaseArgs{URL: boshcmdopts.URLArg(URL)},
+		})
+	})
+
+	if err != nil {
+		return fmt.Errorf("Could not upload release %s: %s\n", URL, err) //nolint:staticcheck
+	}
+
+	return nil
+}
+
+func (d BoshDirector) CreateRelease(createReleaseParams CreateReleaseParams) error {
+	var version boshcmdopts.VersionArg
+	if createReleaseParams.Version != "" {
+		if err := (&version).UnmarshalFlag(createReleaseParams.Version); err != nil {
+			return fmt.Errorf("Could not parse release version %s: %s\n", createReleaseParams.Version, err) //nolint:staticcheck
+		}
+	}
+
+	directoryFixFunction := func(defaultedOps interface{}) (interface{}, error) {
+		switch v := defaultedOps.(type) {
+		case (*boshcmdopts.CreateReleaseOpts):
+			v.Directory.Path = createReleaseParams.Dir
+		default:
+			return nil, fmt.Errorf("expected create release options, got %T", defaultedOps)
+		}
+		return defaultedOps, nil
+	}
+	err := d.commandRunner.ExecuteWithDefaultOverride(&boshcmdopts.CreateReleaseOpts{
+		Version: version,
+		Final:   createReleaseParams.Final,
+		Force:   createReleaseParams.Force,
+		Tarball: boshcmdopts.FileArg{ExpandedPath: createReleaseParams.Tarball},
+	}, directoryFixFunction, nil)
+	if err != nil {
+		return fmt.Errorf("Could not create release from %s: %s\n", createReleaseParams.Dir, err) //nolint:staticcheck
+	}
+
+	return nil
+}
+
+func (d BoshDirector) UploadRemoteRelease(URL, name, version, sha string) error {
+	var v boshcmdopts.VersionArg
+	err := (&v).UnmarshalFlag(version)
+	if err != nil {
+		return fmt.Errorf("Could not parse release version %s: %s\n", version, err) //nolint:staticcheck
+	}
+	err = d.retry.Do(d.writer, "upload release", func() error {
+		return d.commandRunner.Execute(&boshcmdopts.UploadReleaseOpts{
+			Name:    name,
+			Version: v,
+			SHA1:    sha,
+			Args: boshcmdopts.UploadReleaseArgs{
+				URL: boshcmdopts.URLArg(URL),
+			},
+		})
 	})
 
 	if err != nil {
@@ -189,29 +334,114 @@ func (d BoshDirector) UploadRelease(URL string) error {
 	return nil
 }
 
-func (d BoshDirector) WaitForDeployLock() error {
+// ReleaseNeedsUpload reports whether the director is missing the release, or
+// has a release with the same version but different jobs or packages.
+func (d BoshDirector) ReleaseNeedsUpload(release Release) (bool, error) {
+	var stemcell boshdir.OSVersionSlug
+	if release.Stemcell != "" {
+		parts := strings.SplitN(release.Stemcell, "/", 2)
+		if len(parts) != 2 {
+			return false, fmt.Errorf("could not parse stemcell %s of release %s", release.Stemcell, release.Name)
+		}
+		stemcell = boshdir.NewOSVersionSlug(parts[0], parts[1])
+	}
+
+	var found bool
+	err := d.retry.Do(d.writer, "check release", func() (err error) {
+		found, err = d.cliDirector.HasRelease(release.Name, release.Version, stemcell)
+		return err
+	})
+	if err != nil {
+		return false, fmt.Errorf("could not check release %s/%s: %s", release.Name, release.Version, err)
+	}
+	if !found || len(release.Fingerprints) == 0 {
+		return !found, nil
+	}
+
+	uploadedRelease, err := d.cliDirector.FindRelease(boshdir.NewReleaseSlug(release.Name, release.Version))
+	if err != nil {
+		return false, fmt.Errorf("could not check release %s/%s: %s", release.Name, release.Version, err)
+	}
+
+	jobs, err := uploadedRelease.Jobs()
+	if err != nil {
+		return false, fmt.Errorf("could not check release %s/%s: %s", release.Name, release.Version, err)
+	}
+
+	packages, err := uploadedRelease.Packages()
+	if err != nil {
+		return false, fmt.Errorf("could not check release %s/%s: %s", release.Name, release.Version, err)
+	}
+
+	uploadedFingerprints := map[string]string{}
+	for _, job := range jobs {
+		uploadedFingerprints[job.Name] = job.Fingerprint
+	}
+	for _, pkg := range packages {
+		uploadedFingerprints[pkg.Name] = pkg.Fingerprint
+	}
+
+	for name, fingerprint := range release.Fingerprints {
+		if uploadedFingerprints[name] != fingerprint {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func (d BoshDirector) StemcellNeedsUpload(stemcell Stemcell) (bool, error) {
+	var needsUpload bool
+	err := d.retry.Do(d.writer, "check stemcell", func() (err error) {
+		needsUpload, err = d.cliDirector.StemcellNeedsUpload(boshdir.StemcellInfo{Name: stemcell.Name, Version: stemcell.Version})
+		return err
+	})
+	if err != nil {
+		return false, fmt.Errorf("could not check stemcell %s/%s: %s", stemcell.Name, stemcell.Version, err)
+	}
+
+	return needsUpload, nil
+}
+
+// WaitForDeployLock waits until the deployment is no longer locked. It gives
+// up after the timeout, unless the timeout is zero.
+func (d BoshDirector) WaitForDeployLock(timeout time.Duration) error {
 	fmt.Fprint(d.writer, "Waiting for deployment lock") //nolint:errcheck
 
-	locked, err := d.deploymentIsLocked()
+	deadline := time.Now().Add(timeout)
+	lock, err := d.deploymentLock()
 	if err != nil {
 		return err
-	} else if locked {
-		for locked {
-			time.Sleep(3 * time.Second)
-			locked, err = d.deploymentIsLocked()
-			if err != nil {
-				return err
-			}
+	}
+	for lock != nil {
+		if timeout > 0 && !time.Now().Before(deadline) {
+			fmt.Fprintln(d.writer) //nolint:errcheck
+			return d.lockTimeoutError(*lock, timeout)
+		}
+
+		pollInterval := 3 * time.Second
+		if timeout > 0 && time.Until(deadline) < pollInterval {
+			pollInterval = time.Until(deadline)
+		}
+		time.Sleep(pollInterval)
+
+		lock, err = d.deploymentLock()
+		if err != nil {
+			return err
 		}
 	}
 	fmt.Fprintln(d.writer, " Done") //nolint:errcheck
 	return nil
 }
 
-func (d BoshDirector) deploymentIsLocked() (bool, error) {
-	locks, err := d.cliDirector.Locks()
+func (d BoshDirector) deploymentLock() (*boshdir.Lock, error) {
+	var locks []boshdir.Lock
+	err := d.retry.Do(d.writer, "fetch locks", func() (err error) {
+		locks, err = d.cliDirector.Locks()
+		return err
+	})
 	if err != nil {
-		return true, fmt.Errorf("Could not check if deployment was locked: %s\n", err) //nolint:staticcheck
+		return nil, fmt.Errorf("Could not check if deployment was locked: %s\n", err) //nolint:staticcheck
 	}
 
 	for _, lock := range locks {
@@ -219,15 +449,36 @@ func (d BoshDirector) deploymentIsLocked() (bool, error) {
 		for _, resource := range resources {
 			if resource == d.source.Deployment {
 				fmt.Fprint(d.writer, ".") //nolint:errcheck
-				return true, nil
+				return &lock, nil
 			}
 		}
 	}
-	return false, nil
+	return nil, nil
+}
+
+func (d BoshDirector) lockTimeoutError(lock boshdir.Lock, timeout time.Duration) error {
+	holder := fmt.Sprintf("task %s", lock.TaskID)
+
+	tasks, err := d.cliDirector.CurrentTasks(boshdir.TasksFilter{Deployment: d.source.Deployment})
+	if err == nil {
+		for _, task := range tasks {
+			if strconv.Itoa(task.ID()) == lock.TaskID {
+				holder = fmt.Sprintf("task %d (%s by %s, started at %s)",
+					task.ID(), task.Description(), task.User(), task.StartedAt().Format(time.RFC3339))
+			}
+		}
+	}
+
+	return fmt.Errorf("Timed out after %s waiting for the deployment lock held by %s", timeout, holder) //nolint:staticcheck
 }
 
 func (d BoshDirector) ExportReleases(targetDirectory string, releases []ReleaseSpec) error {
-	deploymentReleases, stemcell, err := d.releasesAndStemcell()
+	var deploymentReleases []boshdir.Release
+	var stemcell boshdir.Stemcell
+	err := d.retry.Do(d.writer, "fetch releases", func() (err error) {
+		deploymentReleases, stemcell, err = d.releasesAndStemcell()
+		return err
+	})
 	if err != nil {
 		return fmt.Errorf("could not export releases: %s", err)
 	}
@@ -262,11 +513,13 @@ func (d BoshDirector) ExportReleases(targetDirectory string, releases []ReleaseS
 			}
 			return defaultedOps, nil
 		}
-		err = d.commandRunner.ExecuteWithDefaultOverride(&boshcmdopts.ExportReleaseOpts{
-			Args:      boshcmdopts.ExportReleaseArgs{ReleaseSlug: releaseSlug, OSVersionSlug: osVersionSlug},
-			Jobs:      releases[i].Jobs,
-			Directory: directory,
-		}, directoryFixFunction, nil)
+		err = d.retry.Do(d.writer, "export release", func() error {
+			return d.commandRunner.ExecuteWithDefaultOverride(&boshcmdopts.ExportReleaseOpts{
+				Args:      boshcmdopts.ExportReleaseArgs{ReleaseSlug: releaseSlug, OSVersionSlug: osVersionSlug},
+				Jobs:      releases[i].Jobs,
+				Directory: directory,
+			}, directoryFixFunction, nil)
+		})
 		if err != nil {
 			return fmt.Errorf("could not export release %s: %s", deploymentRelease.Name(), err)
 		}
@@ -276,8 +529,10 @@ func (d BoshDirector) ExportReleases(targetDirectory string, releases []ReleaseS
 }
 
 func (d BoshDirector) UploadStemcell(URL string) error {
-	err := d.commandRunner.Execute(&boshcmdopts.UploadStemcellOpts{
-		Args: boshcmdopts.UploadStemcellArgs{URL: boshcmdopts.URLArg(URL)},
+	err := d.retry.Do(d.writer, "upload stemcell", func() error {
+		return d.commandRunner.Execute(&boshcmdopts.UploadStemcellOpts{
+			Args: boshcmdopts.UploadStemcellArgs{URL: boshcmdopts.URLArg(URL)},
+		})
 	})
 
 	if err != nil {
@@ -293,13 +548,15 @@ func (d BoshDirector) UploadRemoteStemcell(URL, name, version, sha string) error
 	if err != nil {
 		return fmt.Errorf("Could parse stemcell version %s: %s\n", version, err) //nolint:staticcheck
 	}
-	err = d.commandRunner.Execute(&boshcmdopts.UploadStemcellOpts{
-		Name:    name,
-		Version: v,
-		SHA1:    sha,
-		Args: boshcmdopts.UploadStemcellArgs{
-			URL: boshcmdopts.URLArg(URL),
-		},
+	err = d.retry.Do(d.writer, "upload stemcell", func() error {
+		return d.commandRunner.Execute(&boshcmdopts.UploadStemcellOpts{
+			Name:    name,
+			Version: v,
+			SHA1:    sha,
+			Args: boshcmdopts.UploadStemcellArgs{
+				URL: boshcmdopts.URLArg(URL),
+			},
+		})
 	})
 
 	if err != nil {
@@ -310,7 +567,276 @@ func (d BoshDirector) UploadRemoteStemcell(URL, name, version, sha string) error
 }
 
 func (d BoshDirector) Info() (boshdir.Info, error) {
-	return d.cliDirector.Info()
+	var info boshdir.Info
+	err := d.retry.Do(d.writer, "fetch director info", func() (err error) {
+		info, err = d.cliDirector.Info()
+		return err
+	})
+	return info, err
+}
+
+func (d BoshDirector) DiskTypeSizes() (map[string]int, error) {
+	var configs []boshdir.Config
+	err := d.retry.Do(d.writer, "fetch cloud configs", func() (err error) {
+		configs, err = d.cliDirector.ListConfigs(1, boshdir.ConfigsFilter{Type: "cloud"})
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not fetch cloud configs: %s", err)
+	}
+
+	sizes := map[string]int{}
+	for _, config := range configs {
+		var cloudConfig struct {
+			DiskTypes []struct {
+				Name     string `yaml:"name"`
+				DiskSize int    `yaml:"disk_size"`
+			} `yaml:"disk_types"`
+		}
+		if err := yaml.Unmarshal([]byte(config.Content), &cloudConfig); err != nil {
+			return nil, fmt.Errorf("could not parse cloud config %s: %s", config.Name, err)
+		}
+
+		for _, diskType := range cloudConfig.DiskTypes {
+			sizes[diskType.Name] = diskType.DiskSize
+		}
+	}
+
+	return sizes, nil
+}
+
+// CertificateExpiry returns the expiry of the certificates of the director
+// itself. Directors that can not report it have no certificates.
+func (d BoshDirector) CertificateExpiry() ([]CertificateExpiry, error) {
+	var infos []boshdir.CertificateExpiryInfo
+	err := d.retry.Do(d.writer, "fetch certificate expiry", func() (err error) {
+		infos, err = d.cliDirector.CertificateExpiry()
+		return err
+	})
+	if err != nil {
+		if strings.Contains(err.Error(), "Certificate expiry information not supported") {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("could not fetch certificate expiry: %s", err)
+	}
+
+	certificates := []CertificateExpiry{}
+	for _, info := range infos {
+		certificates = append(certificates, CertificateExpiry{
+			Path:     info.Path,
+			Expiry:   info.Expiry,
+			DaysLeft: info.DaysLeft,
+		})
+	}
+
+	return certificates, nil
+}
+
+func (d BoshDirector) RunErrand(errandParams ErrandParams) ([]ErrandResult, error) {
+	slugs := []boshdir.InstanceGroupOrInstanceSlug{}
+	for _, instance := range errandParams.Instances {
+		slug, err := boshdir.NewInstanceGroupOrInstanceSlugFromString(instance)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse errand instance %s: %s", instance, err)
+		}
+		slugs = append(slugs, slug)
+	}
+
+	deployment, err := d.deployment()
+	if err != nil {
+		return nil, err
+	}
+
+	fmt.Fprintf(d.writer, "Running errand %s\n", errandParams.Name) //nolint:errcheck
+
+	boshResults, err := deployment.RunErrand(errandParams.Name, errandParams.KeepAlive, errandParams.WhenChanged, slugs)
+	if err != nil {
+		return nil, fmt.Errorf("could not run errand %s: %s", errandParams.Name, err)
+	}
+
+	results := []ErrandResult{}
+	for _, boshResult := range boshResults {
+		result := ErrandResult{
+			ExitCode: boshResult.ExitCode,
+			Stdout:   boshResult.Stdout,
+			Stderr:   boshResult.Stderr,
+		}
+		if boshResult.InstanceGroup != "" {
+			result.Instance = boshdir.NewInstanceGroupOrInstanceSlug(boshResult.InstanceGroup, boshResult.InstanceID).String()
+		}
+
+		fmt.Fprintf(d.writer, "Instance: %s\nExit Code: %d\nStdout:\n%s\nStderr:\n%s\n", //nolint:errcheck
+			result.Instance, result.ExitCode, result.Stdout, result.Stderr)
+
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+func (d BoshDirector) Start(actionParams InstanceActionParams) error {
+	return d.runInstanceAction("start", actionParams.Instances, func(slug boshdir.AllOrInstanceGroupOrInstanceSlug) interface{} {
+		return &boshcmdopts.StartOpts{
+			Args:        boshcmdopts.AllOrInstanceGroupOrInstanceSlugArgs{Slug: slug},
+			Canaries:    convertMaxInFlight(actionParams.Canaries),
+			MaxInFlight: convertMaxInFlight(actionParams.MaxInFlight),
+		}
+	})
+}
+
+func (d BoshDirector) Stop(actionParams InstanceActionParams) error {
+	return d.runInstanceAction("stop", actionParams.Instances, func(slug boshdir.AllOrInstanceGroupOrInstanceSlug) interface{} {
+		return &boshcmdopts.StopOpts{
+			Args:        boshcmdopts.AllOrInstanceGroupOrInstanceSlugArgs{Slug: slug},
+			Hard:        actionParams.Hard,
+			SkipDrain:   actionParams.SkipDrain,
+			Canaries:    convertMaxInFlight(actionParams.Canaries),
+			MaxInFlight: convertMaxInFlight(actionParams.MaxInFlight),
+		}
+	})
+}
+
+func (d BoshDirector) Restart(actionParams InstanceActionParams) error {
+	return d.runInstanceAction("restart", actionParams.Instances, func(slug boshdir.AllOrInstanceGroupOrInstanceSlug) interface{} {
+		return &boshcmdopts.RestartOpts{
+			Args:        boshcmdopts.AllOrInstanceGroupOrInstanceSlugArgs{Slug: slug},
+			SkipDrain:   actionParams.SkipDrain,
+			Canaries:    convertMaxInFlight(actionParams.Canaries),
+			MaxInFlight: convertMaxInFlight(actionParams.MaxInFlight),
+		}
+	})
+}
+
+func (d BoshDirector) Recreate(actionParams InstanceActionParams) error {
+	return d.runInstanceAction("recreate", actionParams.Instances, func(slug boshdir.AllOrInstanceGroupOrInstanceSlug) interface{} {
+		return &boshcmdopts.RecreateOpts{
+			Args:        boshcmdopts.AllOrInstanceGroupOrInstanceSlugArgs{Slug: slug},
+			SkipDrain:   actionParams.SkipDrain,
+			Canaries:    convertMaxInFlight(actionParams.Canaries),
+			MaxInFlight: convertMaxInFlight(actionParams.MaxInFlight),
+		}
+	})
+}
+
+// runInstanceAction runs the command built by commandOpts once for each of
+// the instances, or once for the whole deployment when there are none.
+func (d BoshDirector) runInstanceAction(action string, instances []string, commandOpts func(boshdir.AllOrInstanceGroupOrInstanceSlug) interface{}) error {
+	slugs := []boshdir.AllOrInstanceGroupOrInstanceSlug{{}}
+	if len(instances) > 0 {
+		slugs = []boshdir.AllOrInstanceGroupOrInstanceSlug{}
+		for _, instance := range instances {
+			slug, err := boshdir.NewAllOrInstanceGroupOrInstanceSlugFromString(instance)
+			if err != nil {
+				return fmt.Errorf("could not parse %s instance %s: %s", action, instance, err)
+			}
+			slugs = append(slugs, slug)
+		}
+	}
+
+	for _, slug := range slugs {
+		target := slug.String()
+		if target == "" {
+			target = d.source.Deployment
+		}
+
+		if err := d.executeTask(commandOpts(slug), 0); err != nil {
+			return fmt.Errorf("could not %s %s: %s", action, target, err)
+		}
+	}
+
+	return nil
+}
+
+// UpdateConfig uploads the config unless it matches the latest config of its
+// type and name. It returns the ID of the latest config and whether it changed.
+func (d BoshDirector) UpdateConfig(configType, name string, content []byte) (string, bool, error) {
+	latestConfig, err := d.cliDirector.LatestConfig(configType, name)
+	if err != nil && err.Error() != "No config" {
+		return "", false, fmt.Errorf("could not fetch %s config %s: %s", configType, name, err)
+	}
+
+	if err == nil && latestConfig.Content == string(content) {
+		fmt.Fprintf(d.writer, "%s config %s is up to date\n", configType, name) //nolint:errcheck
+		return latestConfig.ID, false, nil
+	}
+
+	fmt.Fprintf(d.writer, "Updating %s config %s\n", configType, name) //nolint:errcheck
+
+	config, err := d.cliDirector.UpdateConfig(configType, name, latestConfig.ID, content)
+	if err != nil {
+		return "", false, fmt.Errorf("could not update %s config %s: %s", configType, name, err)
+	}
+
+	return config.ID, true, nil
+}
+
+// CloudCheck scans the deployment for problems and resolves each problem
+// with the resolution the policy has for its type. Problems without a
+// policy, or all problems when reportOnly is set, are left unresolved.
+func (d BoshDirector) CloudCheck(policy map[string]string, reportOnly bool) ([]CloudCheckProblem, error) {
+	deployment, err := d.deployment()
+	if err != nil {
+		return nil, err
+	}
+
+	boshProblems, err := deployment.ScanForProblems()
+	if err != nil {
+		return nil, fmt.Errorf("could not scan %s for problems: %s", d.source.Deployment, err)
+	}
+
+	fmt.Fprintf(d.writer, "Found %d problem(s)\n", len(boshProblems)) //nolint:errcheck
+
+	problems := []CloudCheckProblem{}
+	answers := []boshdir.ProblemAnswer{}
+	resolving := false
+	for _, boshProblem := range boshProblems {
+		problem := CloudCheckProblem{
+			ID:          boshProblem.ID,
+			Type:        boshProblem.Type,
+			Description: boshProblem.Description,
+		}
+		fmt.Fprintf(d.writer, "%d: %s: %s\n", problem.ID, problem.Type, problem.Description) //nolint:errcheck
+
+		answer := boshdir.ProblemAnswer{ProblemID: boshProblem.ID, Resolution: boshdir.ProblemResolutionSkip}
+		if resolutionName, ok := policy[boshProblem.Type]; ok && !reportOnly {
+			resolution, err := findResolution(boshProblem, resolutionName)
+			if err != nil {
+				return nil, err
+			}
+			answer.Resolution = resolution
+			problem.Resolution = resolutionName
+			resolving = true
+		}
+
+		answers = append(answers, answer)
+		problems = append(problems, problem)
+	}
+
+	if !resolving {
+		return problems, nil
+	}
+
+	if err := deployment.ResolveProblems(answers, nil); err != nil {
+		return nil, fmt.Errorf("could not resolve problems of %s: %s", d.source.Deployment, err)
+	}
+
+	return problems, nil
+}
+
+func findResolution(problem boshdir.Problem, resolutionName string) (boshdir.ProblemResolution, error) {
+	available := []string{}
+	for _, resolution := range problem.Resolutions {
+		if resolution.Name == nil {
+			continue
+		}
+		if *resolution.Name == resolutionName {
+			return resolution, nil
+		}
+		available = append(available, *resolution.Name)
+	}
+
+	return boshdir.ProblemResolution{}, fmt.Errorf("resolution %s is not available for %s problem %d, available resolutions: %s",
+		resolutionName, problem.Type, problem.ID, strings.Join(available, ", "))
 }
 
 func (d BoshDirector) deployment() (boshdir.Deployment, error) {
@@ -356,6 +882,38 @@ func (d BoshDirector) releasesAndStemcell() ([]boshdir.Release, boshdir.Stemcell
 	return releases, stemcell, nil
 }
 
+func deployFlags(deployParams DeployParams) (boshcmdopts.VarFlags, boshcmdopts.OpsFlags, error) {
+	boshVarsFiles, err := parsedVarsFiles(deployParams.VarsFiles)
+	if err != nil {
+		return boshcmdopts.VarFlags{}, boshcmdopts.OpsFlags{}, err
+	}
+
+	boshVarFiles, err := parsedVarFiles(deployParams.VarFiles)
+	if err != nil {
+		return boshcmdopts.VarFlags{}, boshcmdopts.OpsFlags{}, err
+	}
+
+	boshOpsFiles, err := parsedOpsFiles(deployParams.OpsFiles)
+	if err != nil {
+		return boshcmdopts.VarFlags{}, boshcmdopts.OpsFlags{}, err
+	}
+
+	varFlags := boshcmdopts.VarFlags{
+		VarKVs:    varKVsFromVars(deployParams.Vars),
+		VarsFiles: boshVarsFiles,
+		VarFiles:  boshVarFiles,
+	}
+
+	if deployParams.VarsStore != "" {
+		varsFSStore := boshcmdopts.VarsFSStore{}
+		varsFSStore.FS = boshFileSystem()
+		varsFSStore.UnmarshalFlag(deployParams.VarsStore) //nolint:errcheck
+		varFlags.VarsFSStore = varsFSStore
+	}
+
+	return varFlags, boshcmdopts.OpsFlags{OpsFiles: boshOpsFiles}, nil
+}
+
 func varKVsFromVars(vars map[string]interface{}) []boshtpl.VarKV {
 	varKVs := []boshtpl.VarKV{}
 	for k, v := range vars {
@@ -7,6 +7,7 @@ import (
 	"io"
 	"os"
 	"strconv"
+	"time"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -72,11 +73,9 @@ var _ = Describe("BoshDirector", func() {
 			opsFile.Write(opsFileContents)                //nolint:errcheck
 
 			noRedact := true
-			dryRun := false
 			maxInFlight := 5
 			err := director.Deploy(sillyBytes, bosh.DeployParams{
 				NoRedact:    noRedact,
-				DryRun:      dryRun,
 				MaxInFlight: maxInFlight,
 				Vars:        vars,
 				VarFiles:    map[string]string{"key2": varFile.Name()},
@@ -90,7 +89,7 @@ var _ = Describe("BoshDirector", func() {
 			deployOpts := commandRunner.ExecuteArgsForCall(0).(*boshcmdopts.DeployOpts)
 			Expect(deployOpts.Args.Manifest.Bytes).To(Equal(sillyBytes))
 			Expect(deployOpts.NoRedact).To(Equal(noRedact))
-			Expect(deployOpts.DryRun).To(Equal(dryRun))
+			Expect(deployOpts.DryRun).To(BeFalse())
 			Expect(deployOpts.MaxInFlight).To(Equal(strconv.Itoa(maxInFlight)))
 			Expect(deployOpts.VarKVs).To(Equal(varKVs))
 			Expect(len(deployOpts.VarsFiles)).To(Equal(1))
@@ -152,34 +151,127 @@ var _ = Describe("BoshDirector", func() {
 			})
 		})
 
-		Context("when cleanup is specified", func() {
-			It("runs a cleanup after the deploy", func() {
-				err := director.Deploy(sillyBytes, bosh.DeployParams{Cleanup: true})
+		Context("when a timeout is specified", func() {
+			var runningTask, deployTask *boshdirfakes.FakeTask
+
+			BeforeEach(func() {
+				runningTask = new(boshdirfakes.FakeTask)
+				runningTask.IDReturns(11)
+				runningTask.StateReturns("processing")
+				runningTask.DeploymentNameReturns("cool-deployment")
+				deployTask = new(boshdirfakes.FakeTask)
+				deployTask.IDReturns(12)
+				deployTask.StateReturns("processing")
+				deployTask.DeploymentNameReturns("cool-deployment")
+				otherTask := new(boshdirfakes.FakeTask)
+				otherTask.IDReturns(13)
+				otherTask.StateReturns("processing")
+				otherTask.DeploymentNameReturns("other-deployment")
+
+				fakeBoshDirector.WithContextReturns(fakeBoshDirector)
+				director = director.WithContext("some-context")
+				fakeBoshDirector.FindTasksByContextIdReturnsOnCall(0, []boshdir.Task{runningTask}, nil)
+				fakeBoshDirector.FindTasksByContextIdReturnsOnCall(1, []boshdir.Task{runningTask, deployTask, otherTask}, nil)
+			})
+
+			It("deploys when the deploy finishes in time", func() {
+				err := director.Deploy(sillyBytes, bosh.DeployParams{Timeout: time.Minute})
 				Expect(err).ToNot(HaveOccurred())
 
-				Expect(commandRunner.ExecuteCallCount()).To(Equal(2))
+				Expect(commandRunner.ExecuteCallCount()).To(Equal(1))
+				Expect(fakeBoshDirector.FindTasksByContextIdArgsForCall(0)).To(Equal("some-context"))
+				Expect(deployTask.CancelCallCount()).To(Equal(0))
+			})
 
-				deployOpts := commandRunner.ExecuteArgsForCall(0).(*boshcmdopts.DeployOpts)
-				Expect(deployOpts.Args.Manifest.Bytes).To(Equal(sillyBytes))
+			Context("when there is no context ID", func() {
+				It("returns an error without deploying", func() {
+					director = bosh.NewBoshDirector(concourse.Source{Deployment: "cool-deployment"}, commandRunner, fakeBoshDirector, &loggerOutput)
 
-				cleanUpOpts := commandRunner.ExecuteArgsForCall(1).(*boshcmdopts.CleanUpOpts)
-				Expect(cleanUpOpts.All).To(Equal(false))
+					err := director.Deploy(sillyBytes, bosh.DeployParams{Timeout: time.Minute})
+					Expect(err).To(MatchError("Could not deploy: a timeout of 1m0s needs a context ID to find the task to cancel\n"))
+					Expect(commandRunner.ExecuteCallCount()).To(Equal(0))
+				})
 			})
-		})
 
-		Context("when dryrun is specified", func() {
-			It("use dry-run flags", func() {
-				dryRun := true
-				err := director.Deploy(sillyBytes, bosh.DeployParams{
-					DryRun: dryRun,
+			Context("when the deploy does not finish in time", func() {
+				BeforeEach(func() {
+					cancelled := make(chan struct{})
+					deployTask.CancelStub = func() error {
+						close(cancelled)
+						return nil
+					}
+					commandRunner.ExecuteStub = func(interface{}) error {
+						<-cancelled
+						return errors.New("Task 12 cancelled")
+					}
+				})
+
+				It("cancels the task with the context ID started by the deploy", func() {
+					err := director.Deploy(sillyBytes, bosh.DeployParams{Timeout: 10 * time.Millisecond})
+					Expect(err).To(MatchError("Could not deploy: timed out after 10ms, cancelled task(s) 12\n"))
+
+					Expect(deployTask.CancelCallCount()).To(Equal(1))
+					Expect(runningTask.CancelCallCount()).To(Equal(0))
+					Expect(loggerOutput.String()).To(ContainSubstring("Cancelling task 12"))
+				})
+
+				Context("when cancelling the task fails", func() {
+					It("tries again until the task is cancelled", func() {
+						cancel := deployTask.CancelStub
+						deployTask.CancelStub = func() error {
+							if deployTask.CancelCallCount() == 1 {
+								return errors.New("director unavailable")
+							}
+							return cancel()
+						}
+						fakeBoshDirector.FindTasksByContextIdReturns([]boshdir.Task{runningTask, deployTask}, nil)
+
+						err := director.Deploy(sillyBytes, bosh.DeployParams{Timeout: 10 * time.Millisecond})
+						Expect(err).To(MatchError("Could not deploy: timed out after 10ms, cancelled task(s) 12\n"))
+
+						Expect(deployTask.CancelCallCount()).To(Equal(2))
+						Expect(loggerOutput.String()).To(ContainSubstring("Could not cancel the director task yet: could not cancel task 12: director unavailable"))
+					})
+
+					It("returns an error once the deploy returned", func() {
+						deployTask.CancelReturns(errors.New("task already finished"))
+						fakeBoshDirector.FindTasksByContextIdReturns([]boshdir.Task{runningTask, deployTask}, nil)
+						commandRunner.ExecuteStub = func(interface{}) error {
+							time.Sleep(50 * time.Millisecond)
+							return errors.New("Task 12 done")
+						}
+
+						err := director.Deploy(sillyBytes, bosh.DeployParams{Timeout: 10 * time.Millisecond})
+						Expect(err).To(MatchError("Could not deploy: timed out after 10ms and could not cancel the director task: could not cancel task 12: task already finished\n"))
+						Expect(deployTask.CancelCallCount()).To(BeNumerically(">", 1))
+					})
 				})
+
+				Context("when the deploy task was not started yet", func() {
+					It("cancels it once it is started", func() {
+						fakeBoshDirector.FindTasksByContextIdReturnsOnCall(1, []boshdir.Task{runningTask}, nil)
+						fakeBoshDirector.FindTasksByContextIdReturns([]boshdir.Task{runningTask, deployTask}, nil)
+
+						err := director.Deploy(sillyBytes, bosh.DeployParams{Timeout: 10 * time.Millisecond})
+						Expect(err).To(MatchError("Could not deploy: timed out after 10ms, cancelled task(s) 12\n"))
+						Expect(deployTask.CancelCallCount()).To(Equal(1))
+					})
+				})
+			})
+		})
+
+		Context("when cleanup is specified", func() {
+			It("runs a cleanup after the deploy", func() {
+				err := director.Deploy(sillyBytes, bosh.DeployParams{Cleanup: true})
 				Expect(err).ToNot(HaveOccurred())
 
-				Expect(commandRunner.ExecuteCallCount()).To(Equal(1))
+				Expect(commandRunner.ExecuteCallCount()).To(Equal(2))
 
 				deployOpts := commandRunner.ExecuteArgsForCall(0).(*boshcmdopts.DeployOpts)
 				Expect(deployOpts.Args.Manifest.Bytes).To(Equal(sillyBytes))
-				Expect(deployOpts.DryRun).To(Equal(dryRun))
+
+				cleanUpOpts := commandRunner.ExecuteArgsForCall(1).(*boshcmdopts.CleanUpOpts)
+				Expect(cleanUpOpts.All).To(Equal(false))
 			})
 		})
 
@@ -218,6 +310,62 @@ var _ = Describe("BoshDirector", func() {
 			})
 		})
 	})
+	Describe("Diff", func() {
+		var fakeDeployment *boshdirfakes.FakeDeployment
+
+		BeforeEach(func() {
+			fakeDeployment = new(boshdirfakes.FakeDeployment)
+			fakeBoshDirector.FindDeploymentReturns(fakeDeployment, nil)
+			fakeDeployment.DiffReturns(boshdir.NewDeploymentDiff([][]interface{}{
+				{"name: cool-deployment", nil},
+				{"foo: bar", "added"},
+			}, nil), nil)
+		})
+
+		It("diffs the evaluated manifest against the deployment", func() {
+			varFile, _ := os.CreateTemp("", "var-file") //nolint:errcheck
+			varFile.Write([]byte("from-file"))          //nolint:errcheck
+
+			diff, err := director.Diff([]byte("name: ((name))\nfoo: ((foo))\nfile: ((file))\n"), bosh.DeployParams{
+				NoRedact: true,
+				Vars:     map[string]interface{}{"foo": "bar", "name": "cool-deployment"},
+				VarFiles: map[string]string{"file": varFile.Name()},
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(diff).To(Equal(bosh.DeploymentDiff{Lines: []bosh.DiffLine{
+				{Text: "name: cool-deployment"},
+				{Text: "foo: bar", State: "added"},
+			}}))
+
+			Expect(fakeBoshDirector.FindDeploymentArgsForCall(0)).To(Equal("cool-deployment"))
+			manifest, noRedact := fakeDeployment.DiffArgsForCall(0)
+			Expect(manifest).To(MatchYAML("name: cool-deployment\nfoo: bar\nfile: from-file\n"))
+			Expect(noRedact).To(BeTrue())
+			Expect(commandRunner.ExecuteCallCount()).To(Equal(0))
+		})
+
+		It("uses the vars store", func() {
+			varsStore, _ := os.CreateTemp("", "vars-store") //nolint:errcheck
+			varsStore.Write([]byte("password: stored\n"))   //nolint:errcheck
+
+			_, err := director.Diff([]byte("password: ((password))\n"), bosh.DeployParams{VarsStore: varsStore.Name()})
+			Expect(err).ToNot(HaveOccurred())
+
+			manifest, _ := fakeDeployment.DiffArgsForCall(0)
+			Expect(manifest).To(MatchYAML("password: stored\n"))
+		})
+
+		Context("when diffing fails", func() {
+			It("returns an error", func() {
+				fakeDeployment.DiffReturns(boshdir.DeploymentDiff{}, errors.New("director unavailable"))
+
+				_, err := director.Diff([]byte("name: cool-deployment"), bosh.DeployParams{})
+				Expect(err).To(MatchError(ContainSubstring("Could not diff manifest: director unavailable")))
+			})
+		})
+	})
+
 	Describe("Delete", func() {
 		It("tells BOSH to delete the configured deployment", func() {
 			err := director.Delete(true)
@@ -376,6 +524,185 @@ var _ = Describe("BoshDirector", func() {
 		})
 	})
 
+	Describe("CreateRelease", func() {
+		It("creates a release tarball from the release directory", func() {
+			err := director.CreateRelease(bosh.CreateReleaseParams{
+				Dir:     "/tmp/cool-release",
+				Tarball: "/tmp/releases/release-0.tgz",
+				Version: "1.2.3",
+				Force:   true,
+				Final:   true,
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(commandRunner.ExecuteWithDefaultOverrideCallCount()).To(Equal(1))
+
+			opts, optFunc, _ := commandRunner.ExecuteWithDefaultOverrideArgsForCall(0)
+			createReleaseOpts := opts.(*boshcmdopts.CreateReleaseOpts)
+			Expect(createReleaseOpts.Tarball.ExpandedPath).To(Equal("/tmp/releases/release-0.tgz"))
+			Expect(version.Version(createReleaseOpts.Version).AsString()).To(Equal("1.2.3"))
+			Expect(createReleaseOpts.Force).To(BeTrue())
+			Expect(createReleaseOpts.Final).To(BeTrue())
+
+			fixedOpts, err := optFunc(&boshcmdopts.CreateReleaseOpts{Directory: boshcmdopts.DirOrCWDArg{Path: "wrong-path"}})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(fixedOpts.(*boshcmdopts.CreateReleaseOpts).Directory.Path).To(Equal("/tmp/cool-release"))
+
+			_, err = optFunc(&boshcmdopts.UploadReleaseOpts{})
+			Expect(err).To(MatchError("expected create release options, got *opts.UploadReleaseOpts"))
+		})
+
+		It("uses the next dev version when no version is given", func() {
+			err := director.CreateRelease(bosh.CreateReleaseParams{Dir: "/tmp/cool-release"})
+			Expect(err).ToNot(HaveOccurred())
+
+			opts, _, _ := commandRunner.ExecuteWithDefaultOverrideArgsForCall(0)
+			Expect(version.Version(opts.(*boshcmdopts.CreateReleaseOpts).Version).Empty()).To(BeTrue())
+		})
+
+		Context("when the version is invalid", func() {
+			It("returns an error", func() {
+				err := director.CreateRelease(bosh.CreateReleaseParams{Dir: "/tmp/cool-release", Version: "not a version"})
+				Expect(err).To(MatchError(ContainSubstring("Could not parse release version not a version")))
+				Expect(commandRunner.ExecuteWithDefaultOverrideCallCount()).To(Equal(0))
+			})
+		})
+
+		Context("when creating the release fails", func() {
+			It("returns an error", func() {
+				commandRunner.ExecuteWithDefaultOverrideReturns(errors.New("git is dirty"))
+
+				err := director.CreateRelease(bosh.CreateReleaseParams{Dir: "/tmp/cool-release"})
+				Expect(err).To(MatchError(ContainSubstring("Could not create release from /tmp/cool-release: git is dirty")))
+			})
+		})
+	})
+
+	Describe("UploadRemoteRelease", func() {
+		It("uploads the release from its url", func() {
+			err := director.UploadRemoteRelease("https://example.com/cool-release.tgz", "cool-release", "1.2.3", "cool-sha")
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(commandRunner.ExecuteCallCount()).To(Equal(1))
+
+			uploadReleaseOpts := commandRunner.ExecuteArgsForCall(0).(*boshcmdopts.UploadReleaseOpts)
+			Expect(string(uploadReleaseOpts.Args.URL)).To(Equal("https://example.com/cool-release.tgz"))
+			Expect(uploadReleaseOpts.Name).To(Equal("cool-release"))
+			Expect(version.Version(uploadReleaseOpts.Version).AsString()).To(Equal("1.2.3"))
+			Expect(uploadReleaseOpts.SHA1).To(Equal("cool-sha"))
+		})
+
+		Context("when uploading the release fails", func() {
+			It("returns an error", func() {
+				commandRunner.ExecuteReturns(errors.New("failed communicating with director"))
+
+				err := director.UploadRemoteRelease("https://example.com/cool-release.tgz", "cool-release", "1.2.3", "cool-sha")
+				Expect(err).To(MatchError(ContainSubstring("Could not upload release https://example.com/cool-release.tgz: failed communicating with director")))
+			})
+		})
+	})
+
+	Describe("ReleaseNeedsUpload", func() {
+		var (
+			release         bosh.Release
+			uploadedRelease *boshdirfakes.FakeRelease
+		)
+
+		BeforeEach(func() {
+			release = bosh.Release{
+				Name:         "cool-release",
+				Version:      "1.2.3",
+				Fingerprints: map[string]string{"web": "web-fingerprint", "nginx": "nginx-fingerprint"},
+			}
+
+			uploadedRelease = new(boshdirfakes.FakeRelease)
+			uploadedRelease.JobsReturns([]boshdir.Job{{Name: "web", Fingerprint: "web-fingerprint"}}, nil)
+			uploadedRelease.PackagesReturns([]boshdir.Package{{Name: "nginx", Fingerprint: "nginx-fingerprint"}}, nil)
+			fakeBoshDirector.HasReleaseReturns(true, nil)
+			fakeBoshDirector.FindReleaseReturns(uploadedRelease, nil)
+		})
+
+		It("is false when the director has the release with the same fingerprints", func() {
+			needsUpload, err := director.ReleaseNeedsUpload(release)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(needsUpload).To(BeFalse())
+
+			name, version, stemcell := fakeBoshDirector.HasReleaseArgsForCall(0)
+			Expect(name).To(Equal("cool-release"))
+			Expect(version).To(Equal("1.2.3"))
+			Expect(stemcell.IsProvided()).To(BeFalse())
+			Expect(fakeBoshDirector.FindReleaseArgsForCall(0)).To(Equal(boshdir.NewReleaseSlug("cool-release", "1.2.3")))
+		})
+
+		It("is true when the director does not have the release", func() {
+			fakeBoshDirector.HasReleaseReturns(false, nil)
+
+			needsUpload, err := director.ReleaseNeedsUpload(release)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(needsUpload).To(BeTrue())
+		})
+
+		It("is true when a fingerprint differs", func() {
+			uploadedRelease.JobsReturns([]boshdir.Job{{Name: "web", Fingerprint: "other-fingerprint"}}, nil)
+
+			needsUpload, err := director.ReleaseNeedsUpload(release)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(needsUpload).To(BeTrue())
+		})
+
+		Context("when the release is compiled", func() {
+			It("checks for packages compiled against its stemcell", func() {
+				release.Stemcell = "ubuntu-jammy/1.50"
+
+				_, err := director.ReleaseNeedsUpload(release)
+				Expect(err).ToNot(HaveOccurred())
+
+				_, _, stemcell := fakeBoshDirector.HasReleaseArgsForCall(0)
+				Expect(stemcell).To(Equal(boshdir.NewOSVersionSlug("ubuntu-jammy", "1.50")))
+			})
+		})
+
+		Context("when the release has no fingerprints", func() {
+			It("only checks the name and version", func() {
+				release.Fingerprints = nil
+
+				needsUpload, err := director.ReleaseNeedsUpload(release)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(needsUpload).To(BeFalse())
+				Expect(fakeBoshDirector.FindReleaseCallCount()).To(Equal(0))
+			})
+		})
+
+		Context("when checking the director fails", func() {
+			It("returns an error", func() {
+				fakeBoshDirector.HasReleaseReturns(false, errors.New("unauthorized"))
+
+				_, err := director.ReleaseNeedsUpload(release)
+				Expect(err).To(MatchError("could not check release cool-release/1.2.3: unauthorized"))
+			})
+		})
+	})
+
+	Describe("StemcellNeedsUpload", func() {
+		It("asks the director", func() {
+			fakeBoshDirector.StemcellNeedsUploadReturns(true, nil)
+
+			needsUpload, err := director.StemcellNeedsUpload(bosh.Stemcell{Name: "cool-stemcell", Version: "1.50"})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(needsUpload).To(BeTrue())
+			Expect(fakeBoshDirector.StemcellNeedsUploadArgsForCall(0)).To(Equal(boshdir.StemcellInfo{Name: "cool-stemcell", Version: "1.50"}))
+		})
+
+		Context("when checking the director fails", func() {
+			It("returns an error", func() {
+				fakeBoshDirector.StemcellNeedsUploadReturns(false, errors.New("unauthorized"))
+
+				_, err := director.StemcellNeedsUpload(bosh.Stemcell{Name: "cool-stemcell", Version: "1.50"})
+				Expect(err).To(MatchError("could not check stemcell cool-stemcell/1.50: unauthorized"))
+			})
+		})
+	})
+
 	Describe("ExportReleases", func() {
 		fakeDeployment := new(boshdirfakes.FakeDeployment)
 		var fakeDeploymentStemcell *boshdirfakes.FakeStemcell
@@ -567,9 +894,417 @@ var _ = Describe("BoshDirector", func() {
 		})
 	})
 
+	Describe("CertificateExpiry", func() {
+		It("returns the expiry of the director certificates", func() {
+			fakeBoshDirector.CertificateExpiryReturns([]boshdir.CertificateExpiryInfo{
+				{Path: "nats.tls", Expiry: "2026-10-25T00:00:00Z", DaysLeft: 7},
+			}, nil)
+
+			certificates, err := director.CertificateExpiry()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(certificates).To(Equal([]bosh.CertificateExpiry{
+				{Path: "nats.tls", Expiry: "2026-10-25T00:00:00Z", DaysLeft: 7},
+			}))
+		})
+
+		Context("when the director does not report certificate expiry", func() {
+			It("returns no certificates", func() {
+				fakeBoshDirector.CertificateExpiryReturns(nil, errors.New("Certificate expiry information not supported: Director responded with non-successful status code '404'"))
+
+				certificates, err := director.CertificateExpiry()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(certificates).To(BeEmpty())
+			})
+		})
+
+		Context("when fetching the certificate expiry fails", func() {
+			It("returns an error", func() {
+				fakeBoshDirector.CertificateExpiryReturns(nil, errors.New("unauthorized"))
+
+				_, err := director.CertificateExpiry()
+				Expect(err).To(MatchError("could not fetch certificate expiry: unauthorized"))
+			})
+		})
+	})
+
+	Describe("DiskTypeSizes", func() {
+		It("returns the size of each disk type in the cloud configs", func() {
+			fakeBoshDirector.ListConfigsReturns([]boshdir.Config{
+				{Name: "default", Content: "disk_types:\n- name: small\n  disk_size: 1024\n"},
+				{Name: "extra", Content: "disk_types:\n- name: large\n  disk_size: 10240\n"},
+			}, nil)
+
+			sizes, err := director.DiskTypeSizes()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(sizes).To(Equal(map[string]int{"small": 1024, "large": 10240}))
+
+			limit, filter := fakeBoshDirector.ListConfigsArgsForCall(0)
+			Expect(limit).To(Equal(1))
+			Expect(filter).To(Equal(boshdir.ConfigsFilter{Type: "cloud"}))
+		})
+
+		Context("when fetching the cloud configs fails", func() {
+			It("returns an error", func() {
+				fakeBoshDirector.ListConfigsReturns(nil, errors.New("unauthorized"))
+
+				_, err := director.DiskTypeSizes()
+				Expect(err).To(MatchError("could not fetch cloud configs: unauthorized"))
+			})
+		})
+
+		Context("when a cloud config can not be parsed", func() {
+			It("returns an error", func() {
+				fakeBoshDirector.ListConfigsReturns([]boshdir.Config{{Name: "default", Content: "disk_types: {"}}, nil)
+
+				_, err := director.DiskTypeSizes()
+				Expect(err).To(MatchError(ContainSubstring("could not parse cloud config default")))
+			})
+		})
+	})
+
+	Describe("Start", func() {
+		It("starts each instance", func() {
+			err := director.Start(bosh.InstanceActionParams{
+				Instances:   []string{"web", "worker/0"},
+				Canaries:    1,
+				MaxInFlight: 2,
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(commandRunner.ExecuteCallCount()).To(Equal(2))
+			Expect(commandRunner.ExecuteArgsForCall(0)).To(Equal(&boshcmdopts.StartOpts{
+				Args:        boshcmdopts.AllOrInstanceGroupOrInstanceSlugArgs{Slug: boshdir.NewAllOrInstanceGroupOrInstanceSlug("web", "")},
+				Canaries:    "1",
+				MaxInFlight: "2",
+			}))
+			Expect(commandRunner.ExecuteArgsForCall(1)).To(Equal(&boshcmdopts.StartOpts{
+				Args:        boshcmdopts.AllOrInstanceGroupOrInstanceSlugArgs{Slug: boshdir.NewAllOrInstanceGroupOrInstanceSlug("worker", "0")},
+				Canaries:    "1",
+				MaxInFlight: "2",
+			}))
+		})
+
+		It("starts the whole deployment without instances", func() {
+			err := director.Start(bosh.InstanceActionParams{})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(commandRunner.ExecuteCallCount()).To(Equal(1))
+			Expect(commandRunner.ExecuteArgsForCall(0)).To(Equal(&boshcmdopts.StartOpts{}))
+		})
+
+		Context("when an instance is invalid", func() {
+			It("returns an error", func() {
+				err := director.Start(bosh.InstanceActionParams{Instances: []string{"web/0/1"}})
+				Expect(err).To(MatchError(ContainSubstring("could not parse start instance web/0/1")))
+				Expect(commandRunner.ExecuteCallCount()).To(Equal(0))
+			})
+		})
+
+		Context("when starting fails", func() {
+			It("returns an error", func() {
+				commandRunner.ExecuteReturns(errors.New("director unavailable"))
+
+				err := director.Start(bosh.InstanceActionParams{})
+				Expect(err).To(MatchError("could not start cool-deployment: director unavailable"))
+			})
+		})
+	})
+
+	Describe("Stop", func() {
+		It("stops the instances", func() {
+			err := director.Stop(bosh.InstanceActionParams{
+				Instances:   []string{"web"},
+				Hard:        true,
+				SkipDrain:   true,
+				Canaries:    1,
+				MaxInFlight: 2,
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(commandRunner.ExecuteCallCount()).To(Equal(1))
+			Expect(commandRunner.ExecuteArgsForCall(0)).To(Equal(&boshcmdopts.StopOpts{
+				Args:        boshcmdopts.AllOrInstanceGroupOrInstanceSlugArgs{Slug: boshdir.NewAllOrInstanceGroupOrInstanceSlug("web", "")},
+				Hard:        true,
+				SkipDrain:   true,
+				Canaries:    "1",
+				MaxInFlight: "2",
+			}))
+		})
+
+		Context("when stopping fails", func() {
+			It("returns an error", func() {
+				commandRunner.ExecuteReturns(errors.New("director unavailable"))
+
+				err := director.Stop(bosh.InstanceActionParams{Instances: []string{"web/0"}})
+				Expect(err).To(MatchError("could not stop web/0: director unavailable"))
+			})
+		})
+	})
+
+	Describe("Restart", func() {
+		It("restarts the instances", func() {
+			err := director.Restart(bosh.InstanceActionParams{
+				Instances:   []string{"web"},
+				SkipDrain:   true,
+				Canaries:    1,
+				MaxInFlight: 2,
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(commandRunner.ExecuteCallCount()).To(Equal(1))
+			Expect(commandRunner.ExecuteArgsForCall(0)).To(Equal(&boshcmdopts.RestartOpts{
+				Args:        boshcmdopts.AllOrInstanceGroupOrInstanceSlugArgs{Slug: boshdir.NewAllOrInstanceGroupOrInstanceSlug("web", "")},
+				SkipDrain:   true,
+				Canaries:    "1",
+				MaxInFlight: "2",
+			}))
+		})
+	})
+
+	Describe("Recreate", func() {
+		It("recreates the instances", func() {
+			err := director.Recreate(bosh.InstanceActionParams{
+				Instances:   []string{"web"},
+				SkipDrain:   true,
+				Canaries:    1,
+				MaxInFlight: 2,
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(commandRunner.ExecuteCallCount()).To(Equal(1))
+			Expect(commandRunner.ExecuteArgsForCall(0)).To(Equal(&boshcmdopts.RecreateOpts{
+				Args:        boshcmdopts.AllOrInstanceGroupOrInstanceSlugArgs{Slug: boshdir.NewAllOrInstanceGroupOrInstanceSlug("web", "")},
+				SkipDrain:   true,
+				Canaries:    "1",
+				MaxInFlight: "2",
+			}))
+		})
+	})
+
+	Describe("UpdateConfig", func() {
+		BeforeEach(func() {
+			fakeBoshDirector.LatestConfigReturns(boshdir.Config{ID: "1", Content: "old-content"}, nil)
+			fakeBoshDirector.UpdateConfigReturns(boshdir.Config{ID: "2"}, nil)
+		})
+
+		It("updates the config expecting the latest config", func() {
+			id, changed, err := director.UpdateConfig("runtime", "dns", []byte("new-content"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(id).To(Equal("2"))
+			Expect(changed).To(BeTrue())
+
+			configType, name := fakeBoshDirector.LatestConfigArgsForCall(0)
+			Expect(configType).To(Equal("runtime"))
+			Expect(name).To(Equal("dns"))
+
+			configType, name, expectedLatestID, content := fakeBoshDirector.UpdateConfigArgsForCall(0)
+			Expect(configType).To(Equal("runtime"))
+			Expect(name).To(Equal("dns"))
+			Expect(expectedLatestID).To(Equal("1"))
+			Expect(content).To(Equal([]byte("new-content")))
+		})
+
+		Context("when the config is unchanged", func() {
+			It("does not update the config", func() {
+				id, changed, err := director.UpdateConfig("runtime", "dns", []byte("old-content"))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(id).To(Equal("1"))
+				Expect(changed).To(BeFalse())
+				Expect(fakeBoshDirector.UpdateConfigCallCount()).To(Equal(0))
+			})
+		})
+
+		Context("when there is no config yet", func() {
+			It("creates the config", func() {
+				fakeBoshDirector.LatestConfigReturns(boshdir.Config{}, errors.New("No config"))
+
+				id, changed, err := director.UpdateConfig("runtime", "dns", []byte("new-content"))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(id).To(Equal("2"))
+				Expect(changed).To(BeTrue())
+
+				_, _, expectedLatestID, _ := fakeBoshDirector.UpdateConfigArgsForCall(0)
+				Expect(expectedLatestID).To(Equal(""))
+			})
+		})
+
+		Context("when fetching the latest config fails", func() {
+			It("returns an error", func() {
+				fakeBoshDirector.LatestConfigReturns(boshdir.Config{}, errors.New("director unavailable"))
+
+				_, _, err := director.UpdateConfig("runtime", "dns", []byte("new-content"))
+				Expect(err).To(MatchError("could not fetch runtime config dns: director unavailable"))
+				Expect(fakeBoshDirector.UpdateConfigCallCount()).To(Equal(0))
+			})
+		})
+
+		Context("when the update is rejected", func() {
+			It("returns an error", func() {
+				fakeBoshDirector.UpdateConfigReturns(boshdir.Config{}, errors.New("Config update rejected"))
+
+				_, _, err := director.UpdateConfig("runtime", "dns", []byte("new-content"))
+				Expect(err).To(MatchError("could not update runtime config dns: Config update rejected"))
+			})
+		})
+	})
+
+	Describe("CloudCheck", func() {
+		var fakeDeployment *boshdirfakes.FakeDeployment
+
+		resolution := func(name string) boshdir.ProblemResolution {
+			return boshdir.ProblemResolution{Name: &name}
+		}
+
+		BeforeEach(func() {
+			fakeDeployment = new(boshdirfakes.FakeDeployment)
+			fakeBoshDirector.FindDeploymentReturns(fakeDeployment, nil)
+			fakeDeployment.ScanForProblemsReturns([]boshdir.Problem{
+				{
+					ID:          4,
+					Type:        "missing_vm",
+					Description: "VM for 'web/abc' missing.",
+					Resolutions: []boshdir.ProblemResolution{resolution("ignore"), resolution("recreate_vm")},
+				},
+				{
+					ID:          5,
+					Type:        "unresponsive_agent",
+					Description: "worker/def is not responding",
+					Resolutions: []boshdir.ProblemResolution{resolution("ignore"), resolution("reboot_vm")},
+				},
+			}, nil)
+		})
+
+		It("resolves the problems with a policy", func() {
+			problems, err := director.CloudCheck(map[string]string{"missing_vm": "recreate_vm"}, false)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(problems).To(Equal([]bosh.CloudCheckProblem{
+				{ID: 4, Type: "missing_vm", Description: "VM for 'web/abc' missing.", Resolution: "recreate_vm"},
+				{ID: 5, Type: "unresponsive_agent", Description: "worker/def is not responding"},
+			}))
+
+			Expect(fakeBoshDirector.FindDeploymentArgsForCall(0)).To(Equal("cool-deployment"))
+			Expect(fakeDeployment.ResolveProblemsCallCount()).To(Equal(1))
+			answers, overrides := fakeDeployment.ResolveProblemsArgsForCall(0)
+			Expect(answers).To(Equal([]boshdir.ProblemAnswer{
+				{ProblemID: 4, Resolution: resolution("recreate_vm")},
+				{ProblemID: 5, Resolution: boshdir.ProblemResolutionSkip},
+			}))
+			Expect(overrides).To(BeNil())
+
+			Expect(loggerOutput.String()).To(ContainSubstring("4: missing_vm: VM for 'web/abc' missing."))
+		})
+
+		Context("when reporting only", func() {
+			It("does not resolve the problems", func() {
+				problems, err := director.CloudCheck(map[string]string{"missing_vm": "recreate_vm"}, true)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(problems).To(HaveLen(2))
+				Expect(problems[0].Resolution).To(BeEmpty())
+				Expect(fakeDeployment.ResolveProblemsCallCount()).To(Equal(0))
+			})
+		})
+
+		Context("when no problem has a policy", func() {
+			It("does not resolve the problems", func() {
+				_, err := director.CloudCheck(map[string]string{"mount_info_mismatch": "reattach_disk"}, false)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(fakeDeployment.ResolveProblemsCallCount()).To(Equal(0))
+			})
+		})
+
+		Context("when the policy resolution is not available", func() {
+			It("returns an error", func() {
+				_, err := director.CloudCheck(map[string]string{"unresponsive_agent": "recreate_vm"}, false)
+				Expect(err).To(MatchError("resolution recreate_vm is not available for unresponsive_agent problem 5, available resolutions: ignore, reboot_vm"))
+				Expect(fakeDeployment.ResolveProblemsCallCount()).To(Equal(0))
+			})
+		})
+
+		Context("when the scan fails", func() {
+			It("returns an error", func() {
+				fakeDeployment.ScanForProblemsReturns(nil, errors.New("director unavailable"))
+
+				_, err := director.CloudCheck(nil, false)
+				Expect(err).To(MatchError("could not scan cool-deployment for problems: director unavailable"))
+			})
+		})
+
+		Context("when resolving fails", func() {
+			It("returns an error", func() {
+				fakeDeployment.ResolveProblemsReturns(errors.New("task failed"))
+
+				_, err := director.CloudCheck(map[string]string{"missing_vm": "recreate_vm"}, false)
+				Expect(err).To(MatchError("could not resolve problems of cool-deployment: task failed"))
+			})
+		})
+	})
+
+	Describe("RunErrand", func() {
+		var fakeDeployment *boshdirfakes.FakeDeployment
+
+		BeforeEach(func() {
+			fakeDeployment = new(boshdirfakes.FakeDeployment)
+			fakeBoshDirector.FindDeploymentReturns(fakeDeployment, nil)
+			fakeDeployment.RunErrandReturns([]boshdir.ErrandResult{
+				{InstanceGroup: "smoke-tests", InstanceID: "abc-123", ExitCode: 1, Stdout: "some-stdout", Stderr: "some-stderr"},
+			}, nil)
+		})
+
+		It("runs the errand and returns its results", func() {
+			results, err := director.RunErrand(bosh.ErrandParams{
+				Name:        "smoke-tests",
+				Instances:   []string{"smoke-tests/0"},
+				KeepAlive:   true,
+				WhenChanged: true,
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(results).To(Equal([]bosh.ErrandResult{
+				{Instance: "smoke-tests/abc-123", ExitCode: 1, Stdout: "some-stdout", Stderr: "some-stderr"},
+			}))
+
+			Expect(fakeBoshDirector.FindDeploymentArgsForCall(0)).To(Equal("cool-deployment"))
+			name, keepAlive, whenChanged, slugs := fakeDeployment.RunErrandArgsForCall(0)
+			Expect(name).To(Equal("smoke-tests"))
+			Expect(keepAlive).To(BeTrue())
+			Expect(whenChanged).To(BeTrue())
+			Expect(slugs).To(Equal([]boshdir.InstanceGroupOrInstanceSlug{
+				boshdir.NewInstanceGroupOrInstanceSlug("smoke-tests", "0"),
+			}))
+		})
+
+		It("writes the exit code and output of the errand", func() {
+			_, err := director.RunErrand(bosh.ErrandParams{Name: "smoke-tests"})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(loggerOutput.String()).To(ContainSubstring("Exit Code: 1"))
+			Expect(loggerOutput.String()).To(ContainSubstring("some-stdout"))
+			Expect(loggerOutput.String()).To(ContainSubstring("some-stderr"))
+		})
+
+		Context("when an instance is invalid", func() {
+			It("returns an error", func() {
+				_, err := director.RunErrand(bosh.ErrandParams{Name: "smoke-tests", Instances: []string{"/"}})
+				Expect(err).To(MatchError(ContainSubstring("could not parse errand instance /")))
+				Expect(fakeDeployment.RunErrandCallCount()).To(Equal(0))
+			})
+		})
+
+		Context("when running the errand fails", func() {
+			It("returns an error", func() {
+				fakeDeployment.RunErrandReturns(nil, errors.New("errand not found"))
+
+				_, err := director.RunErrand(bosh.ErrandParams{Name: "smoke-tests"})
+				Expect(err).To(MatchError(ContainSubstring("could not run errand smoke-tests: errand not found")))
+			})
+		})
+	})
+
 	Describe("WaitForDeployLock", func() {
 		It("waits for the lock to be released", func() {
-			err := director.WaitForDeployLock()
+			err := director.WaitForDeployLock(0)
 			Expect(err).ToNot(HaveOccurred())
 			Expect(fakeBoshDirector.LocksCallCount()).To(Equal(1))
 		})
@@ -594,7 +1329,7 @@ var _ = Describe("BoshDirector", func() {
 			})
 
 			It("waits for the lock to be released", func() {
-				err := director.WaitForDeployLock()
+				err := director.WaitForDeployLock(0)
 				Expect(err).ToNot(HaveOccurred())
 				Expect(fakeBoshDirector.LocksCallCount()).To(Equal(2))
 
@@ -603,13 +1338,45 @@ var _ = Describe("BoshDirector", func() {
 			})
 		})
 
+		Context("when the lock is not released in time", func() {
+			BeforeEach(func() {
+				fakeBoshDirector.LocksReturns(
+					[]boshdir.Lock{{Resource: []string{"cool-deployment"}, TaskID: "12"}},
+					nil,
+				)
+
+				lockingTask := new(boshdirfakes.FakeTask)
+				lockingTask.IDReturns(12)
+				lockingTask.DescriptionReturns("create deployment")
+				lockingTask.UserReturns("admin")
+				lockingTask.StartedAtReturns(time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC))
+				fakeBoshDirector.CurrentTasksReturns([]boshdir.Task{lockingTask}, nil)
+			})
+
+			It("returns an error with the task holding the lock", func() {
+				err := director.WaitForDeployLock(10 * time.Millisecond)
+				Expect(err).To(MatchError("Timed out after 10ms waiting for the deployment lock held by task 12 (create deployment by admin, started at 2024-01-02T03:04:05Z)"))
+
+				Expect(fakeBoshDirector.CurrentTasksArgsForCall(0)).To(Equal(boshdir.TasksFilter{Deployment: "cool-deployment"}))
+			})
+
+			Context("when the task can not be found", func() {
+				It("returns an error with the task ID", func() {
+					fakeBoshDirector.CurrentTasksReturns(nil, errors.New("director unavailable"))
+
+					err := director.WaitForDeployLock(10 * time.Millisecond)
+					Expect(err).To(MatchError("Timed out after 10ms waiting for the deployment lock held by task 12"))
+				})
+			})
+		})
+
 		Context("when checking the lock fails", func() {
 			BeforeEach(func() {
 				fakeBoshDirector.LocksReturns([]boshdir.Lock{{Resource: []string{}}}, errors.New("Failed to fetch locks"))
 			})
 
 			It("returns an error", func() {
-				err := director.WaitForDeployLock()
+				err := director.WaitForDeployLock(0)
 				Expect(err).To(HaveOccurred())
 			})
 		})
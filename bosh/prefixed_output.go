@@ -0,0 +1,85 @@
+package bosh
+
+import (
+	"bytes"
+	"io"
+	"sync"
+)
+
+// prefixWriter prefixes each line written to it, so the output of commands
+// running at the same time stays readable. Lines are written whole, under a
+// lock shared by all prefixWriters of the same output. Progress bars redraw
+// their line with a carriage return and are left out.
+type prefixWriter struct {
+	out    io.Writer
+	prefix string
+	lock   *sync.Mutex
+	line   []byte
+}
+
+func newPrefixWriter(out io.Writer, prefix string, lock *sync.Mutex) *prefixWriter {
+	return &prefixWriter{
+		out:    out,
+		prefix: prefix,
+		lock:   lock,
+	}
+}
+
+func (w *prefixWriter) Write(p []byte) (int, error) {
+	for _, b := range p {
+		switch b {
+		case '\r':
+			w.line = w.line[:0]
+		case '\n':
+			if err := w.writeLine(); err != nil {
+				return 0, err
+			}
+		default:
+			w.line = append(w.line, b)
+		}
+	}
+	return len(p), nil
+}
+
+// Flush writes a line that was not terminated yet.
+func (w *prefixWriter) Flush() error {
+	if len(bytes.TrimSpace(w.line)) == 0 {
+		w.line = w.line[:0]
+		return nil
+	}
+	return w.writeLine()
+}
+
+func (w *prefixWriter) writeLine() error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	line := append(append([]byte(w.prefix), w.line...), '\n')
+	w.line = w.line[:0]
+	_, err := w.out.Write(line)
+	return err
+}
+
+// prefixedRunner runs commands with their output going to a prefixWriter,
+// unless a command is given its own writer.
+type prefixedRunner struct {
+	runner Runner
+	writer *prefixWriter
+}
+
+func (r prefixedRunner) ExecuteWithWriter(commandOpts interface{}, writer io.Writer) error {
+	return r.runner.ExecuteWithWriter(commandOpts, writer)
+}
+
+func (r prefixedRunner) Execute(commandOpts interface{}) error {
+	defer r.writer.Flush() //nolint:errcheck
+	return r.runner.ExecuteWithWriter(commandOpts, r.writer)
+}
+
+func (r prefixedRunner) ExecuteWithDefaultOverride(commandOpts interface{}, override func(interface{}) (interface{}, error), writer io.Writer) error {
+	if writer == nil {
+		defer r.writer.Flush() //nolint:errcheck
+		writer = r.writer
+	}
+	return r.runner.ExecuteWithDefaultOverride(commandOpts, override, writer)
+}
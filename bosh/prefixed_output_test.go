@@ -0,0 +1,55 @@
+package bosh_test
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	boshcmdopts "github.com/cloudfoundry/bosh-cli/v7/cmd/opts"
+	boshdirfakes "github.com/cloudfoundry/bosh-cli/v7/director/directorfakes"
+
+	"github.com/cloudfoundry/bosh-deployment-resource/bosh"
+	"github.com/cloudfoundry/bosh-deployment-resource/bosh/boshfakes"
+	"github.com/cloudfoundry/bosh-deployment-resource/concourse"
+)
+
+var _ = Describe("WithOutputPrefix", func() {
+	var (
+		output        *bytes.Buffer
+		commandRunner *boshfakes.FakeRunner
+		director      bosh.Director
+	)
+
+	BeforeEach(func() {
+		output = new(bytes.Buffer)
+		commandRunner = new(boshfakes.FakeRunner)
+		director = bosh.NewBoshDirector(concourse.Source{}, commandRunner, new(boshdirfakes.FakeDirector), output)
+	})
+
+	It("prefixes each line of the command output", func() {
+		commandRunner.ExecuteWithWriterStub = func(_ interface{}, writer io.Writer) error {
+			fmt.Fprint(writer, "Uploading release\n0% \r50% \r100%\nDone") //nolint:errcheck
+			return nil
+		}
+
+		err := director.WithOutputPrefix("[cool-release v1] ").UploadRelease("cool-release.tgz")
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(commandRunner.ExecuteCallCount()).To(Equal(0))
+		uploadReleaseOpts, _ := commandRunner.ExecuteWithWriterArgsForCall(0)
+		Expect(string(uploadReleaseOpts.(*boshcmdopts.UploadReleaseOpts).Args.URL)).To(Equal("cool-release.tgz"))
+
+		Expect(output.String()).To(Equal("[cool-release v1] Uploading release\n[cool-release v1] 100%\n[cool-release v1] Done\n"))
+	})
+
+	It("does not change the output of the original director", func() {
+		director.WithOutputPrefix("[cool-release v1] ")
+
+		err := director.UploadRelease("cool-release.tgz")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(commandRunner.ExecuteCallCount()).To(Equal(1))
+	})
+})
@@ -13,6 +13,31 @@ type Release struct {
 
 	Version  string
 	FilePath string
+	URL      string `yaml:"-"`
+	Sha1     string `yaml:"-"`
+
+	// Repository is the bosh.io repository of a release read from a
+	// bosh-io-release resource, which does not know the name of the release.
+	// The name is that of the manifest release from the same repository, see
+	// DeploymentManifest.ReleaseName.
+	Repository string `yaml:"-"`
+
+	// Fingerprints of the jobs and packages in release.MF by name, and the
+	// stemcell (os/version) a compiled release was compiled against.
+	Fingerprints map[string]string `yaml:"-"`
+	Stemcell     string            `yaml:"-"`
+}
+
+type releaseManifest struct {
+	Jobs             []releaseManifestEntry `yaml:"jobs"`
+	Packages         []releaseManifestEntry `yaml:"packages"`
+	CompiledPackages []releaseManifestEntry `yaml:"compiled_packages"`
+}
+
+type releaseManifestEntry struct {
+	Name        string `yaml:"name"`
+	Fingerprint string `yaml:"fingerprint"`
+	Stemcell    string `yaml:"stemcell"`
 }
 
 func NewReleases(basePath string, releasePathGlobs []string) ([]Release, error) {
@@ -23,6 +48,11 @@ func NewReleases(basePath string, releasePathGlobs []string) ([]Release, error)
 
 	releases := []Release{}
 	for _, releasePath := range releasePaths {
+		newRelease := newRelease
+		if isBoshIOMetadataDir(releasePath) {
+			newRelease = newRemoteRelease
+		}
+
 		release, err := newRelease(releasePath)
 		if err != nil {
 			return nil, err
@@ -46,7 +76,39 @@ func newRelease(filePath string) (Release, error) {
 		return Release{}, fmt.Errorf("Release %s is not a valid release", filePath) //nolint:staticcheck
 	}
 
+	var contents releaseManifest
+	err = yaml.Unmarshal(releaseFileContents, &contents)
+	if err != nil {
+		return Release{}, fmt.Errorf("Release %s is not a valid release", filePath) //nolint:staticcheck
+	}
+
+	for _, entry := range append(append(contents.Jobs, contents.Packages...), contents.CompiledPackages...) {
+		if release.Fingerprints == nil {
+			release.Fingerprints = map[string]string{}
+		}
+		release.Fingerprints[entry.Name] = entry.Fingerprint
+		if entry.Stemcell != "" {
+			release.Stemcell = entry.Stemcell
+		}
+	}
+
 	release.FilePath = filePath
 
 	return release, nil
 }
+
+// newRemoteRelease reads a release from the url, version and sha1 files of a
+// bosh-io-release resource.
+func newRemoteRelease(dir string) (Release, error) {
+	url, version, sha1, err := readBoshIOMetadata(dir)
+	if err != nil {
+		return Release{}, err
+	}
+
+	return Release{
+		Version:    version,
+		URL:        url,
+		Sha1:       sha1,
+		Repository: boshIORepository(url),
+	}, nil
+}
@@ -1,6 +1,9 @@
 package bosh_test
 
 import (
+	"os"
+	"path/filepath"
+
 	"github.com/cloudfoundry/bosh-deployment-resource/bosh"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -21,6 +24,51 @@ var _ = Describe("Release", func() {
 			}))
 		})
 
+		It("reads the fingerprints and stemcell of a compiled release", func() {
+			release, err := bosh.NewReleases("fixtures", []string{"compiled-release.tgz"})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(release).To(Equal([]bosh.Release{
+				{
+					Name:     "compiled-release",
+					Version:  "1.2.3",
+					FilePath: "fixtures/compiled-release.tgz",
+					Fingerprints: map[string]string{
+						"web":   "web-fingerprint",
+						"nginx": "nginx-fingerprint",
+					},
+					Stemcell: "ubuntu-jammy/1.50",
+				},
+			}))
+		})
+
+		Context("when the release is the output of a bosh-io-release resource without a tarball", func() {
+			It("reads the url, version and sha1 files", func() {
+				release, err := bosh.NewReleases("fixtures", []string{"bosh-io-release"})
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(release).To(Equal([]bosh.Release{
+					{
+						Version:    "0.280.0",
+						URL:        "https://bosh.io/d/github.com/cloudfoundry/routing-release?v=0.280.0",
+						Sha1:       "routing-sha",
+						Repository: "github.com/cloudfoundry/routing-release",
+					},
+				}))
+			})
+
+			Context("when a file is missing", func() {
+				It("returns an error", func() {
+					dir, _ := os.MkdirTemp("", "bosh-io-release")                                //nolint:errcheck
+					defer os.RemoveAll(dir)                                                      //nolint:errcheck
+					os.WriteFile(filepath.Join(dir, "url"), []byte("https://bosh.io/d/x"), 0600) //nolint:errcheck
+
+					_, err := bosh.NewReleases(dir, []string{"."})
+					Expect(err).To(MatchError(ContainSubstring("Could not read version from")))
+				})
+			})
+		})
+
 		Context("when the tgz is not a release", func() {
 			It("returns an error", func() {
 				_, err := bosh.NewReleases("fixtures", []string{"small-stemcell.tgz"})
@@ -0,0 +1,82 @@
+package bosh
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"time"
+
+	"github.com/cloudfoundry/bosh-deployment-resource/concourse"
+)
+
+const defaultRetryBackoff = 5 * time.Second
+
+// retryableErrors matches the messages of each class of error that is worth
+// retrying, as the director and UAA clients of the CLI report them.
+var retryableErrors = map[string]*regexp.Regexp{
+	"connection": regexp.MustCompile(`connection reset by peer|connection refused|broken pipe|i/o timeout|TLS handshake timeout|EOF`),
+	"server":     regexp.MustCompile(`Director responded with non-successful status code '5\d\d'`),
+	"uaa":        regexp.MustCompile(`UAA responded with non-successful status code '(5\d\d|429)'`),
+}
+
+// retryPolicy retries the director operations that are safe to run again.
+type retryPolicy struct {
+	attempts   int
+	backoff    time.Duration
+	maxBackoff time.Duration
+	errors     []*regexp.Regexp
+}
+
+func newRetryPolicy(config concourse.RetryConfig) retryPolicy {
+	policy := retryPolicy{
+		attempts:   config.Attempts,
+		backoff:    time.Duration(config.Backoff),
+		maxBackoff: time.Duration(config.MaxBackoff),
+	}
+	if policy.attempts < 1 {
+		policy.attempts = 1
+	}
+	if policy.backoff == 0 {
+		policy.backoff = defaultRetryBackoff
+	}
+
+	classes := config.Errors
+	if len(classes) == 0 {
+		classes = concourse.RetryErrorClasses
+	}
+	for _, class := range classes {
+		policy.errors = append(policy.errors, retryableErrors[class])
+	}
+
+	return policy
+}
+
+// Do runs the operation until it succeeds, fails with an error that is not
+// retryable, or runs out of attempts. The backoff doubles after each attempt
+// up to the max backoff.
+func (p retryPolicy) Do(writer io.Writer, operation string, fn func() error) error {
+	backoff := p.backoff
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil || attempt >= p.attempts || !p.retryable(err) {
+			return err
+		}
+
+		fmt.Fprintf(writer, "Could not %s, retrying in %s (attempt %d of %d): %s\n", operation, backoff, attempt+1, p.attempts, err) //nolint:errcheck
+		time.Sleep(backoff)
+
+		backoff *= 2
+		if p.maxBackoff > 0 && backoff > p.maxBackoff {
+			backoff = p.maxBackoff
+		}
+	}
+}
+
+func (p retryPolicy) retryable(err error) bool {
+	for _, pattern := range p.errors {
+		if pattern.MatchString(err.Error()) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,131 @@
+package bosh_test
+
+import (
+	"bytes"
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	boshdir "github.com/cloudfoundry/bosh-cli/v7/director"
+	boshdirfakes "github.com/cloudfoundry/bosh-cli/v7/director/directorfakes"
+
+	"github.com/cloudfoundry/bosh-deployment-resource/bosh"
+	"github.com/cloudfoundry/bosh-deployment-resource/bosh/boshfakes"
+	"github.com/cloudfoundry/bosh-deployment-resource/concourse"
+)
+
+var _ = Describe("Retries", func() {
+	var (
+		output           *bytes.Buffer
+		commandRunner    *boshfakes.FakeRunner
+		fakeBoshDirector *boshdirfakes.FakeDirector
+		retry            concourse.RetryConfig
+		director         bosh.BoshDirector
+	)
+
+	connectionError := errors.New("Performing request GET 'https://10.0.0.6:25555/info': read tcp: connection reset by peer")
+	serverError := errors.New("Director responded with non-successful status code '502' response 'Bad Gateway'")
+	clientError := errors.New("Director responded with non-successful status code '404' response 'Not Found'")
+
+	BeforeEach(func() {
+		output = new(bytes.Buffer)
+		commandRunner = new(boshfakes.FakeRunner)
+		fakeBoshDirector = new(boshdirfakes.FakeDirector)
+		retry = concourse.RetryConfig{Attempts: 3, Backoff: concourse.Duration(time.Millisecond)}
+	})
+
+	JustBeforeEach(func() {
+		director = bosh.NewBoshDirector(
+			concourse.Source{Deployment: "cool-deployment", Retry: retry},
+			commandRunner,
+			fakeBoshDirector,
+			output,
+		)
+	})
+
+	It("retries retryable errors until the operation succeeds", func() {
+		fakeBoshDirector.InfoReturnsOnCall(0, boshdir.Info{}, connectionError)
+		fakeBoshDirector.InfoReturnsOnCall(1, boshdir.Info{}, serverError)
+		fakeBoshDirector.InfoReturnsOnCall(2, boshdir.Info{Name: "cool-director"}, nil)
+
+		info, err := director.Info()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(info.Name).To(Equal("cool-director"))
+		Expect(fakeBoshDirector.InfoCallCount()).To(Equal(3))
+		Expect(output.String()).To(ContainSubstring("Could not fetch director info, retrying in 1ms (attempt 2 of 3)"))
+		Expect(output.String()).To(ContainSubstring("Could not fetch director info, retrying in 2ms (attempt 3 of 3)"))
+	})
+
+	It("gives up after the last attempt", func() {
+		commandRunner.ExecuteReturns(connectionError)
+
+		err := director.UploadRelease("https://example.com/release.tgz")
+		Expect(err).To(MatchError(ContainSubstring("connection reset by peer")))
+		Expect(commandRunner.ExecuteCallCount()).To(Equal(3))
+	})
+
+	It("does not retry errors that are not retryable", func() {
+		fakeBoshDirector.FindDeploymentReturns(nil, clientError)
+
+		_, err := director.DownloadManifest()
+		Expect(err).To(HaveOccurred())
+		Expect(fakeBoshDirector.FindDeploymentCallCount()).To(Equal(1))
+	})
+
+	It("does not retry the deploy", func() {
+		commandRunner.ExecuteReturns(connectionError)
+
+		err := director.Deploy([]byte("name: cool-deployment"), bosh.DeployParams{})
+		Expect(err).To(HaveOccurred())
+		Expect(commandRunner.ExecuteCallCount()).To(Equal(1))
+	})
+
+	Context("when only some error classes are retryable", func() {
+		BeforeEach(func() {
+			retry.Errors = []string{"server"}
+		})
+
+		It("retries only those errors", func() {
+			fakeBoshDirector.LocksReturns(nil, connectionError)
+
+			err := director.WaitForDeployLock(0)
+			Expect(err).To(HaveOccurred())
+			Expect(fakeBoshDirector.LocksCallCount()).To(Equal(1))
+		})
+	})
+
+	Context("when there is a max backoff", func() {
+		BeforeEach(func() {
+			retry.Attempts = 4
+			retry.Backoff = concourse.Duration(2 * time.Millisecond)
+			retry.MaxBackoff = concourse.Duration(3 * time.Millisecond)
+		})
+
+		It("does not back off longer than the max", func() {
+			fakeBoshDirector.StemcellNeedsUploadReturns(false, serverError)
+
+			_, err := director.StemcellNeedsUpload(bosh.Stemcell{Name: "ubuntu", Version: "1"})
+			Expect(err).To(HaveOccurred())
+			Expect(fakeBoshDirector.StemcellNeedsUploadCallCount()).To(Equal(4))
+			Expect(output.String()).To(ContainSubstring("retrying in 2ms (attempt 2 of 4)"))
+			Expect(output.String()).To(ContainSubstring("retrying in 3ms (attempt 3 of 4)"))
+			Expect(output.String()).To(ContainSubstring("retrying in 3ms (attempt 4 of 4)"))
+		})
+	})
+
+	Context("when there is no retry policy", func() {
+		BeforeEach(func() {
+			retry = concourse.RetryConfig{}
+		})
+
+		It("runs the operation once", func() {
+			fakeBoshDirector.InfoReturns(boshdir.Info{}, connectionError)
+
+			_, err := director.Info()
+			Expect(err).To(HaveOccurred())
+			Expect(fakeBoshDirector.InfoCallCount()).To(Equal(1))
+		})
+	})
+})
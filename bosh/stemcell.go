@@ -13,6 +13,8 @@ type Stemcell struct {
 	OperatingSystem string `yaml:"operating_system"`
 	Version         string
 	FilePath        string
+	URL             string `yaml:"-"`
+	Sha1            string `yaml:"-"`
 }
 
 func NewStemcells(basePath string, stemcellPathGlobs []string) ([]Stemcell, error) {
@@ -23,6 +25,11 @@ func NewStemcells(basePath string, stemcellPathGlobs []string) ([]Stemcell, erro
 
 	stemcells := []Stemcell{}
 	for _, stemcellPath := range stemcellPaths {
+		newStemcell := newStemcell
+		if isBoshIOMetadataDir(stemcellPath) {
+			newStemcell = newRemoteStemcell
+		}
+
 		stemcell, err := newStemcell(stemcellPath)
 		if err != nil {
 			return nil, err
@@ -50,3 +57,21 @@ func newStemcell(filePath string) (Stemcell, error) {
 
 	return stemcell, nil
 }
+
+// newRemoteStemcell reads a stemcell from the url, version and sha1 files of a
+// bosh-io-stemcell resource.
+func newRemoteStemcell(dir string) (Stemcell, error) {
+	url, version, sha1, err := readBoshIOMetadata(dir)
+	if err != nil {
+		return Stemcell{}, err
+	}
+
+	name := stemcellNameFromURL(url, version)
+	return Stemcell{
+		Name:            name,
+		OperatingSystem: stemcellOS(name),
+		Version:         version,
+		URL:             url,
+		Sha1:            sha1,
+	}, nil
+}
@@ -1,6 +1,9 @@
 package bosh_test
 
 import (
+	"os"
+	"path/filepath"
+
 	"github.com/cloudfoundry/bosh-deployment-resource/bosh"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -22,6 +25,40 @@ var _ = Describe("Stemcell", func() {
 			}))
 		})
 
+		Context("when the stemcell is the output of a bosh-io-stemcell resource without a tarball", func() {
+			It("reads the url, version and sha1 files", func() {
+				stemcell, err := bosh.NewStemcells("fixtures", []string{"bosh-io-stemcell"})
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(stemcell).To(Equal([]bosh.Stemcell{
+					{
+						Name:            "bosh-google-kvm-ubuntu-jammy-go_agent",
+						OperatingSystem: "ubuntu-jammy",
+						Version:         "1.50",
+						URL:             "https://storage.googleapis.com/bosh-gce-light-stemcells/1.50/light-bosh-stemcell-1.50-google-kvm-ubuntu-jammy-go_agent.tgz",
+						Sha1:            "stemcell-sha",
+					},
+				}))
+			})
+
+			It("understands bosh.io download urls", func() {
+				dir, _ := os.MkdirTemp("", "bosh-io-stemcell") //nolint:errcheck
+				defer os.RemoveAll(dir)                        //nolint:errcheck
+				for name, contents := range map[string]string{
+					"url":     "https://bosh.io/d/stemcells/bosh-aws-xen-hvm-ubuntu-jammy-go_agent?v=1.50",
+					"version": "1.50",
+					"sha1":    "stemcell-sha",
+				} {
+					os.WriteFile(filepath.Join(dir, name), []byte(contents), 0600) //nolint:errcheck
+				}
+
+				stemcell, err := bosh.NewStemcells(dir, []string{"."})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(stemcell[0].Name).To(Equal("bosh-aws-xen-hvm-ubuntu-jammy-go_agent"))
+				Expect(stemcell[0].OperatingSystem).To(Equal("ubuntu-jammy"))
+			})
+		})
+
 		Context("when the tgz is not a stemcell", func() {
 			It("returns an error", func() {
 				_, err := bosh.NewStemcells("fixtures", []string{"small-release.tgz"})
@@ -0,0 +1,195 @@
+package bosh
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	boshcmdopts "github.com/cloudfoundry/bosh-cli/v7/cmd/opts"
+	boshdir "github.com/cloudfoundry/bosh-cli/v7/director"
+)
+
+// cancelRetryInterval is how long to wait at most before trying again to
+// cancel the task of a command that timed out.
+const cancelRetryInterval = 10 * time.Second
+
+// executeTask runs a command that starts a task of the deployment. When the
+// command does not finish within the timeout, the task it started is
+// cancelled, which is found by the context ID. A zero timeout waits for the
+// command to finish.
+func (d BoshDirector) executeTask(commandOpts interface{}, timeout time.Duration) error {
+	if timeout == 0 {
+		return d.commandRunner.Execute(commandOpts)
+	}
+
+	if d.contextID == "" {
+		return fmt.Errorf("a timeout of %s needs a context ID to find the task to cancel", timeout)
+	}
+
+	previousTaskIDs, err := d.contextTaskIDs()
+	if err != nil {
+		return err
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- d.commandRunner.Execute(commandOpts)
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(timeout):
+	}
+
+	// Keep trying to cancel until the command returns, so it can not start a
+	// task after the timeout was reported.
+	retryInterval := timeout
+	if retryInterval > cancelRetryInterval {
+		retryInterval = cancelRetryInterval
+	}
+	for {
+		cancelledTaskIDs, err := d.cancelNewTasks(previousTaskIDs)
+		if err == nil {
+			// Wait for the command to notice its cancelled task, so its output ends before the error.
+			<-done
+			return fmt.Errorf("timed out after %s, cancelled task(s) %s", timeout, strings.Join(cancelledTaskIDs, ", "))
+		}
+		fmt.Fprintf(d.writer, "Could not cancel the director task yet: %s\n", err) //nolint:errcheck
+
+		select {
+		case <-done:
+			return fmt.Errorf("timed out after %s and could not cancel the director task: %s", timeout, err)
+		case <-time.After(retryInterval):
+		}
+	}
+}
+
+// CancelTasks cancels the unfinished tasks tagged with the context ID of
+// this director, which are the tasks started by its commands.
+func (d BoshDirector) CancelTasks() error {
+	if d.contextID == "" {
+		return errors.New("there is no context ID to find the tasks to cancel by")
+	}
+
+	tasks, err := d.cliDirector.FindTasksByContextId(d.contextID)
+	if err != nil {
+		return fmt.Errorf("could not find tasks with context ID %s: %s", d.contextID, err)
+	}
+
+	sort.Slice(tasks, func(i, j int) bool { return tasks[i].ID() < tasks[j].ID() })
+
+	errs := []string{}
+	for _, task := range tasks {
+		if !taskIsUnfinished(task) {
+			continue
+		}
+
+		fmt.Fprintf(d.writer, "Cancelling task %d\n", task.ID()) //nolint:errcheck
+		if err := task.Cancel(); err != nil {
+			errs = append(errs, fmt.Sprintf("could not cancel task %d: %s", task.ID(), err))
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("%s", strings.Join(errs, "\n"))
+	}
+
+	return nil
+}
+
+// AttachToDeployTask follows an unfinished deploy task of the deployment
+// that is tagged with the context ID, e.g. one started by a previous attempt
+// of the same put. The context ID has to be unique to the put, tasks tagged
+// with any other context ID or deploying another deployment are never
+// followed. It returns the ID of the task, or 0 when there is none.
+func (d BoshDirector) AttachToDeployTask() (int, error) {
+	if d.contextID == "" {
+		return 0, nil
+	}
+
+	tasks, err := d.cliDirector.FindTasksByContextId(d.contextID)
+	if err != nil {
+		return 0, fmt.Errorf("could not find tasks with context ID %s: %s", d.contextID, err)
+	}
+
+	var deployTask boshdir.Task
+	for _, task := range tasks {
+		if task.ContextID() == d.contextID && task.DeploymentName() == d.source.Deployment && task.Description() == "create deployment" && taskIsUnfinished(task) {
+			deployTask = task
+		}
+	}
+	if deployTask == nil {
+		return 0, nil
+	}
+
+	fmt.Fprintf(d.writer, "Attaching to deploy task %d started by a previous attempt\n", deployTask.ID()) //nolint:errcheck
+
+	if err := d.commandRunner.Execute(&boshcmdopts.TaskOpts{Args: boshcmdopts.TaskArgs{ID: deployTask.ID()}}); err != nil {
+		return deployTask.ID(), fmt.Errorf("could not follow task %d: %s", deployTask.ID(), err)
+	}
+
+	finishedTask, err := d.cliDirector.FindTask(deployTask.ID())
+	if err != nil {
+		return deployTask.ID(), fmt.Errorf("could not find task %d: %s", deployTask.ID(), err)
+	}
+
+	if finishedTask.DeploymentName() != d.source.Deployment {
+		return deployTask.ID(), fmt.Errorf("deploy task %d deployed %s instead of %s", deployTask.ID(), finishedTask.DeploymentName(), d.source.Deployment)
+	}
+
+	if finishedTask.State() != "done" {
+		return deployTask.ID(), fmt.Errorf("deploy task %d finished with state %s", deployTask.ID(), finishedTask.State())
+	}
+
+	return deployTask.ID(), nil
+}
+
+// contextTaskIDs returns the IDs of the tasks tagged with the context ID.
+func (d BoshDirector) contextTaskIDs() (map[int]bool, error) {
+	tasks, err := d.cliDirector.FindTasksByContextId(d.contextID)
+	if err != nil {
+		return nil, fmt.Errorf("could not find tasks with context ID %s: %s", d.contextID, err)
+	}
+
+	taskIDs := map[int]bool{}
+	for _, task := range tasks {
+		taskIDs[task.ID()] = true
+	}
+	return taskIDs, nil
+}
+
+// cancelNewTasks cancels the unfinished tasks of the deployment that are
+// tagged with the context ID and were not before.
+func (d BoshDirector) cancelNewTasks(previousTaskIDs map[int]bool) ([]string, error) {
+	tasks, err := d.cliDirector.FindTasksByContextId(d.contextID)
+	if err != nil {
+		return nil, fmt.Errorf("could not find tasks with context ID %s: %s", d.contextID, err)
+	}
+
+	cancelledTaskIDs := []string{}
+	for _, task := range tasks {
+		if previousTaskIDs[task.ID()] || task.DeploymentName() != d.source.Deployment || !taskIsUnfinished(task) {
+			continue
+		}
+
+		fmt.Fprintf(d.writer, "Cancelling task %d\n", task.ID()) //nolint:errcheck
+		if err := task.Cancel(); err != nil {
+			return nil, fmt.Errorf("could not cancel task %d: %s", task.ID(), err)
+		}
+		cancelledTaskIDs = append(cancelledTaskIDs, strconv.Itoa(task.ID()))
+	}
+
+	if len(cancelledTaskIDs) == 0 {
+		return nil, fmt.Errorf("no task of %s was started", d.source.Deployment)
+	}
+
+	return cancelledTaskIDs, nil
+}
+
+func taskIsUnfinished(task boshdir.Task) bool {
+	return task.State() == "queued" || task.State() == "processing"
+}
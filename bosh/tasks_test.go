@@ -0,0 +1,201 @@
+package bosh_test
+
+import (
+	"bytes"
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	boshcmdopts "github.com/cloudfoundry/bosh-cli/v7/cmd/opts"
+	boshdir "github.com/cloudfoundry/bosh-cli/v7/director"
+	boshdirfakes "github.com/cloudfoundry/bosh-cli/v7/director/directorfakes"
+
+	"github.com/cloudfoundry/bosh-deployment-resource/bosh"
+	"github.com/cloudfoundry/bosh-deployment-resource/bosh/boshfakes"
+	"github.com/cloudfoundry/bosh-deployment-resource/concourse"
+)
+
+var _ = Describe("Tasks", func() {
+	var (
+		output           *bytes.Buffer
+		commandRunner    *boshfakes.FakeRunner
+		fakeBoshDirector *boshdirfakes.FakeDirector
+		director         bosh.BoshDirector
+	)
+
+	newTask := func(id int, state string) *boshdirfakes.FakeTask {
+		task := new(boshdirfakes.FakeTask)
+		task.IDReturns(id)
+		task.StateReturns(state)
+		return task
+	}
+
+	BeforeEach(func() {
+		output = new(bytes.Buffer)
+		commandRunner = new(boshfakes.FakeRunner)
+		fakeBoshDirector = new(boshdirfakes.FakeDirector)
+		fakeBoshDirector.WithContextReturns(fakeBoshDirector)
+		director = bosh.NewBoshDirector(
+			concourse.Source{Deployment: "cool-deployment"},
+			commandRunner,
+			fakeBoshDirector,
+			output,
+		).WithContext("some-context")
+	})
+
+	It("tags the director API with the context ID", func() {
+		Expect(fakeBoshDirector.WithContextArgsForCall(0)).To(Equal("some-context"))
+	})
+
+	Describe("CancelTasks", func() {
+		It("cancels the unfinished tasks with the context ID", func() {
+			runningTask := newTask(12, "processing")
+			finishedTask := newTask(11, "done")
+			fakeBoshDirector.FindTasksByContextIdReturns([]boshdir.Task{finishedTask, runningTask}, nil)
+
+			Expect(director.CancelTasks()).To(Succeed())
+
+			Expect(fakeBoshDirector.FindTasksByContextIdArgsForCall(0)).To(Equal("some-context"))
+			Expect(runningTask.CancelCallCount()).To(Equal(1))
+			Expect(finishedTask.CancelCallCount()).To(Equal(0))
+			Expect(output.String()).To(ContainSubstring("Cancelling task 12"))
+		})
+
+		It("does not look at other tasks of the deployment", func() {
+			fakeBoshDirector.CurrentTasksReturns([]boshdir.Task{newTask(13, "processing")}, nil)
+
+			Expect(director.CancelTasks()).To(Succeed())
+			Expect(fakeBoshDirector.CurrentTasksCallCount()).To(Equal(0))
+		})
+
+		Context("when there is no context ID", func() {
+			It("returns an error", func() {
+				director = bosh.NewBoshDirector(concourse.Source{Deployment: "cool-deployment"}, commandRunner, fakeBoshDirector, output)
+
+				err := director.CancelTasks()
+				Expect(err).To(MatchError("there is no context ID to find the tasks to cancel by"))
+				Expect(fakeBoshDirector.FindTasksByContextIdCallCount()).To(Equal(0))
+			})
+		})
+
+		Context("when cancelling a task fails", func() {
+			It("cancels the other tasks and returns an error", func() {
+				failingTask := newTask(12, "processing")
+				failingTask.CancelReturns(errors.New("task already finished"))
+				runningTask := newTask(13, "queued")
+				fakeBoshDirector.FindTasksByContextIdReturns([]boshdir.Task{runningTask, failingTask}, nil)
+
+				err := director.CancelTasks()
+				Expect(err).To(MatchError("could not cancel task 12: task already finished"))
+				Expect(runningTask.CancelCallCount()).To(Equal(1))
+			})
+		})
+
+		Context("when finding the tasks fails", func() {
+			It("returns an error", func() {
+				fakeBoshDirector.FindTasksByContextIdReturns(nil, errors.New("director unavailable"))
+
+				err := director.CancelTasks()
+				Expect(err).To(MatchError("could not find tasks with context ID some-context: director unavailable"))
+			})
+		})
+	})
+
+	Describe("AttachToDeployTask", func() {
+		var deployTask, finishedTask *boshdirfakes.FakeTask
+
+		BeforeEach(func() {
+			deployTask = newTask(12, "processing")
+			deployTask.ContextIDReturns("some-context")
+			deployTask.DeploymentNameReturns("cool-deployment")
+			deployTask.DescriptionReturns("create deployment")
+
+			errandTask := newTask(13, "processing")
+			errandTask.ContextIDReturns("some-context")
+			errandTask.DeploymentNameReturns("cool-deployment")
+			errandTask.DescriptionReturns("run errand smoke-tests")
+
+			fakeBoshDirector.FindTasksByContextIdReturns([]boshdir.Task{deployTask, errandTask}, nil)
+
+			finishedTask = newTask(12, "done")
+			finishedTask.DeploymentNameReturns("cool-deployment")
+			fakeBoshDirector.FindTaskReturns(finishedTask, nil)
+		})
+
+		It("follows the unfinished deploy task with the context ID", func() {
+			taskID, err := director.AttachToDeployTask()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(taskID).To(Equal(12))
+
+			Expect(fakeBoshDirector.FindTasksByContextIdArgsForCall(0)).To(Equal("some-context"))
+			Expect(commandRunner.ExecuteCallCount()).To(Equal(1))
+			Expect(commandRunner.ExecuteArgsForCall(0)).To(Equal(&boshcmdopts.TaskOpts{Args: boshcmdopts.TaskArgs{ID: 12}}))
+			Expect(fakeBoshDirector.FindTaskArgsForCall(0)).To(Equal(12))
+			Expect(output.String()).To(ContainSubstring("Attaching to deploy task 12"))
+		})
+
+		Context("when the deploy task fails", func() {
+			It("returns an error", func() {
+				finishedTask.StateReturns("error")
+
+				taskID, err := director.AttachToDeployTask()
+				Expect(taskID).To(Equal(12))
+				Expect(err).To(MatchError("deploy task 12 finished with state error"))
+			})
+		})
+
+		Context("when there is no unfinished deploy task", func() {
+			It("returns no task", func() {
+				deployTask.StateReturns("done")
+
+				taskID, err := director.AttachToDeployTask()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(taskID).To(Equal(0))
+				Expect(commandRunner.ExecuteCallCount()).To(Equal(0))
+			})
+		})
+
+		Context("when the deploy task belongs to another deployment", func() {
+			It("returns no task", func() {
+				deployTask.DeploymentNameReturns("other-deployment")
+
+				taskID, err := director.AttachToDeployTask()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(taskID).To(Equal(0))
+			})
+		})
+
+		Context("when the deploy task is tagged with the context ID of another put", func() {
+			It("returns no task", func() {
+				deployTask.ContextIDReturns("some-context/of-another-put")
+
+				taskID, err := director.AttachToDeployTask()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(taskID).To(Equal(0))
+				Expect(commandRunner.ExecuteCallCount()).To(Equal(0))
+			})
+		})
+
+		Context("when the followed task deployed another deployment", func() {
+			It("returns an error", func() {
+				finishedTask.DeploymentNameReturns("other-deployment")
+
+				taskID, err := director.AttachToDeployTask()
+				Expect(taskID).To(Equal(12))
+				Expect(err).To(MatchError("deploy task 12 deployed other-deployment instead of cool-deployment"))
+			})
+		})
+
+		Context("when there is no context ID", func() {
+			It("returns no task", func() {
+				director = bosh.NewBoshDirector(concourse.Source{Deployment: "cool-deployment"}, commandRunner, fakeBoshDirector, output)
+
+				taskID, err := director.AttachToDeployTask()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(taskID).To(Equal(0))
+				Expect(fakeBoshDirector.FindTasksByContextIdCallCount()).To(Equal(0))
+			})
+		})
+	})
+})
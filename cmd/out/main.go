@@ -1,11 +1,14 @@
 package main
 
 import (
+	"crypto/sha256"
 	"encoding/json"
 	"fmt"
 	"io"
 	"log"
 	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
 	proxy "github.com/cloudfoundry/socks5-proxy"
@@ -42,7 +45,11 @@ func main() {
 	hostKeyGetter := proxy.NewHostKey()
 	socks5Proxy := proxy.NewSocks5Proxy(hostKeyGetter, log.New(io.Discard, "", log.LstdFlags), 1*time.Minute)
 	cliCoordinator := bosh.NewCLICoordinator(outRequest.Source, os.Stderr, socks5Proxy)
+	buildContextID := contextID(stdin)
 	commandRunner := bosh.NewCommandRunner(cliCoordinator)
+	if tagsTasks(outRequest) {
+		commandRunner = commandRunner.WithContext(buildContextID)
+	}
 	cliDirector, err := cliCoordinator.Director()
 	if err != nil {
 		fmt.Fprint(os.Stderr, err) //nolint:errcheck
@@ -53,9 +60,13 @@ func main() {
 		commandRunner,
 		cliDirector,
 		os.Stderr,
-	)
+	).WithContext(buildContextID)
+
+	if outRequest.Params.CancelOnAbort {
+		cancelTasksOnSignal(director)
+	}
 
-	storageClient, err := storage.NewStorageClient(outRequest.Source)
+	storageClient, err := storage.NewStorageClient(outRequest.Source, buildName())
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Invalid vars store: %s\n", err)
 		os.Exit(1)
@@ -76,3 +87,59 @@ func main() {
 
 	fmt.Fprintf(os.Stdout, "%s", concourseOutputFormatted) //nolint:errcheck
 }
+
+// contextID identifies the director tasks started by this put. It is made
+// from the build and a digest of the request, so two puts of the same build
+// never share it, while every attempt of a put gets the same one and a
+// retried put can find the deploy task of a previous attempt.
+func contextID(request []byte) string {
+	put := fmt.Sprintf("%x", sha256.Sum256(request))[:12]
+
+	team, pipeline, job, build := os.Getenv("BUILD_TEAM_NAME"), os.Getenv("BUILD_PIPELINE_NAME"), os.Getenv("BUILD_JOB_NAME"), os.Getenv("BUILD_NAME")
+	if team != "" && pipeline != "" && job != "" && build != "" {
+		return fmt.Sprintf("concourse/%s/%s/%s/%s/%s", team, pipeline, job, build, put)
+	}
+	if buildID := os.Getenv("BUILD_ID"); buildID != "" {
+		return fmt.Sprintf("concourse-build-%s-%s", buildID, put)
+	}
+	return fmt.Sprintf("bosh-deployment-resource-%d-%d", os.Getpid(), time.Now().Unix())
+}
+
+// tagsTasks tells whether the tasks of the put need to be found by the
+// context ID: to cancel them when the build is aborted or the deploy timed
+// out, or because a retried put may attach to the deploy task of a previous
+// attempt. Only then are they tagged, which needs commands wired up outside
+// the CLI.
+func tagsTasks(outRequest concourse.OutRequest) bool {
+	params := outRequest.Params
+	if params.CancelOnAbort || params.DeployTimeout != 0 {
+		return true
+	}
+	return outRequest.Source.VarsStore.Provider == "" && !params.Delete.Enabled && params.Action == nil && !params.DryRun && !params.Plan
+}
+
+// buildName names the Concourse build running the put, to tag the revisions
+// of the vars store it writes with.
+func buildName() string {
+	team, pipeline, job, build := os.Getenv("BUILD_TEAM_NAME"), os.Getenv("BUILD_PIPELINE_NAME"), os.Getenv("BUILD_JOB_NAME"), os.Getenv("BUILD_NAME")
+	if team != "" && pipeline != "" && job != "" && build != "" {
+		return fmt.Sprintf("%s/%s/%s/%s", team, pipeline, job, build)
+	}
+	return os.Getenv("BUILD_ID")
+}
+
+// cancelTasksOnSignal cancels the director tasks started by this process
+// when the build is aborted, so they do not keep the deployment locked.
+func cancelTasksOnSignal(director bosh.BoshDirector) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGTERM, syscall.SIGINT)
+
+	go func() {
+		sig := <-signals
+		fmt.Fprintf(os.Stderr, "\nReceived %s, cancelling director tasks\n", sig) //nolint:errcheck
+		if err := director.CancelTasks(); err != nil {
+			fmt.Fprintf(os.Stderr, "Could not cancel director tasks: %s\n", err) //nolint:errcheck
+		}
+		os.Exit(1)
+	}()
+}
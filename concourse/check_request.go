@@ -17,5 +17,9 @@ func NewCheckRequest(request []byte) (CheckRequest, error) {
 		return CheckRequest{}, fmt.Errorf("Invalid parameters: %s\n", err) //nolint:staticcheck
 	}
 
+	if err := checkRetryConfig(checkRequest.Source.Retry); err != nil {
+		return CheckRequest{}, err
+	}
+
 	return checkRequest, nil
 }
@@ -20,6 +20,10 @@ func NewInRequest(request []byte) (InRequest, error) {
 		return InRequest{}, fmt.Errorf("Invalid parameters: %s\n", err) //nolint:staticcheck
 	}
 
+	if err := checkRetryConfig(inRequest.Source.Retry); err != nil {
+		return InRequest{}, err
+	}
+
 	if inRequest.Source.Target == "" {
 		inRequest.Source.Target = MissingTarget
 	}
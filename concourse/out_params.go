@@ -1,25 +1,92 @@
 package concourse
 
 type OutParams struct {
-	Manifest           string                 `json:"manifest"`
-	NoRedact           bool                   `json:"no_redact,omitempty"`
-	DryRun             bool                   `json:"dry_run,omitempty"`
-	MaxInFlight        int                    `json:"max_in_flight,omitempty"`
-	Recreate           bool                   `json:"recreate,omitempty"`
-	SkipDrain          []string               `json:"skip_drain,omitempty"`
-	Cleanup            bool                   `json:"cleanup,omitempty"`
-	Fix                bool                   `json:"fix,omitempty"`
-	Releases           []string               `json:"releases,omitempty"`
-	Stemcells          []string               `json:"stemcells,omitempty"`
-	Vars               map[string]interface{} `json:"vars,omitempty"`
-	VarsFiles          []string               `json:"vars_files,omitempty"`
-	VarFiles           map[string]string      `json:"var_files,omitempty"`
-	OpsFiles           []string               `json:"ops_files,omitempty"`
-	BoshIOStemcellType string                 `json:"bosh_io_stemcell_type,omitempty"`
-	Delete             DeleteParams           `json:"delete,omitempty"`
+	Manifest           string                   `json:"manifest"`
+	NoRedact           bool                     `json:"no_redact,omitempty"`
+	DryRun             bool                     `json:"dry_run,omitempty"`
+	MaxInFlight        int                      `json:"max_in_flight,omitempty"`
+	Recreate           bool                     `json:"recreate,omitempty"`
+	SkipDrain          []string                 `json:"skip_drain,omitempty"`
+	Cleanup            bool                     `json:"cleanup,omitempty"`
+	Fix                bool                     `json:"fix,omitempty"`
+	Releases           []string                 `json:"releases,omitempty"`
+	Stemcells          []string                 `json:"stemcells,omitempty"`
+	Vars               map[string]interface{}   `json:"vars,omitempty"`
+	VarsFiles          []string                 `json:"vars_files,omitempty"`
+	VarFiles           map[string]string        `json:"var_files,omitempty"`
+	OpsFiles           []string                 `json:"ops_files,omitempty"`
+	BoshIOStemcellType string                   `json:"bosh_io_stemcell_type,omitempty"`
+	BoshIOReleases     bool                     `json:"bosh_io_releases,omitempty"`
+	ReleaseDirs        []ReleaseDirParams       `json:"release_dirs,omitempty"`
+	UploadConcurrency  int                      `json:"upload_concurrency,omitempty"`
+	Configs            []ConfigParams           `json:"configs,omitempty"`
+	Errands            []ErrandParams           `json:"errands,omitempty"`
+	RollbackOnFailure  bool                     `json:"rollback_on_failure,omitempty"`
+	Plan               bool                     `json:"plan,omitempty"`
+	ApplyPlan          bool                     `json:"apply_plan,omitempty"`
+	Guards             *GuardParams             `json:"guards,omitempty"`
+	Delete             DeleteParams             `json:"delete,omitempty"`
+	Action             *ActionParams            `json:"action,omitempty"`
+	LockTimeout        Duration                 `json:"lock_timeout,omitempty"`
+	DeployTimeout      Duration                 `json:"deploy_timeout,omitempty"`
+	CancelOnAbort      bool                     `json:"cancel_on_abort,omitempty"`
+	CertificateExpiry  *CertificateExpiryParams `json:"certificate_expiry,omitempty"`
+	RotateVariables    []string                 `json:"rotate_variables,omitempty"`
+	TransitionalCAs    bool                     `json:"transitional_ca_rotation,omitempty"`
+	RetireOldCAs       []string                 `json:"retire_old_cas,omitempty"`
+	RestoreRevision    string                   `json:"restore_vars_store_revision,omitempty"`
+}
+
+type ConfigParams struct {
+	Type     string                 `json:"type"`
+	Name     string                 `json:"name,omitempty"`
+	File     string                 `json:"file"`
+	OpsFiles []string               `json:"ops_files,omitempty"`
+	Vars     map[string]interface{} `json:"vars,omitempty"`
+}
+
+type ErrandParams struct {
+	Name          string   `json:"name"`
+	Instances     []string `json:"instances,omitempty"`
+	KeepAlive     bool     `json:"keep_alive,omitempty"`
+	WhenChanged   bool     `json:"when_changed,omitempty"`
+	IgnoreFailure bool     `json:"ignore_failure,omitempty"`
+}
+
+type ReleaseDirParams struct {
+	Dir     string `json:"dir"`
+	Version string `json:"version,omitempty"`
+	Force   bool   `json:"force,omitempty"`
+	Final   bool   `json:"final,omitempty"`
+}
+
+type GuardParams struct {
+	MaxInstancesDecreasePercent int  `json:"max_instances_decrease_percent,omitempty"`
+	MinInstances                int  `json:"min_instances,omitempty"`
+	AllowInstanceGroupRemoval   bool `json:"allow_instance_group_removal,omitempty"`
+	AllowInstancesDecrease      bool `json:"allow_instances_decrease,omitempty"`
+	AllowPersistentDiskShrink   bool `json:"allow_persistent_disk_shrink,omitempty"`
+	AllowReleaseRemoval         bool `json:"allow_release_removal,omitempty"`
+}
+
+type CertificateExpiryParams struct {
+	Days   int  `json:"days"`
+	Fail   bool `json:"fail,omitempty"`
+	Rotate bool `json:"rotate,omitempty"`
 }
 
 type DeleteParams struct {
 	Enabled bool `json:"enabled,omitempty"`
 	Force   bool `json:"force,omitempty"`
 }
+
+type ActionParams struct {
+	Name        string            `json:"name"`
+	Instances   []string          `json:"instances,omitempty"`
+	Hard        bool              `json:"hard,omitempty"`
+	SkipDrain   bool              `json:"skip_drain,omitempty"`
+	Canaries    int               `json:"canaries,omitempty"`
+	MaxInFlight int               `json:"max_in_flight,omitempty"`
+	Policy      map[string]string `json:"policy,omitempty"`
+	ReportOnly  bool              `json:"report_only,omitempty"`
+}
@@ -35,16 +35,68 @@ func NewOutRequest(request []byte, sourcesDir string) (OutRequest, error) {
 		return OutRequest{}, err
 	}
 
+	if err := checkPlanParameters(outRequest.Params, outRequest.Source); err != nil {
+		return OutRequest{}, err
+	}
+
+	if err := checkActionParameters(outRequest.Params); err != nil {
+		return OutRequest{}, err
+	}
+
+	if err := checkRotateVariablesParameters(outRequest.Params, outRequest.Source); err != nil {
+		return OutRequest{}, err
+	}
+
+	if err := checkCertificateExpiryParameters(outRequest.Params, outRequest.Source); err != nil {
+		return OutRequest{}, err
+	}
+
+	if outRequest.Params.UploadConcurrency < 0 {
+		return OutRequest{}, fmt.Errorf("upload_concurrency must be at least 1 got: %d", outRequest.Params.UploadConcurrency)
+	}
+
 	return outRequest, nil
 }
 
 func checkRequiredOutParameters(params OutParams) error {
 	missingParameters := []string{}
 
-	if params.Manifest == "" && !params.Delete.Enabled {
+	if params.Manifest == "" && !params.Delete.Enabled && params.Action == nil {
 		missingParameters = append(missingParameters, "manifest")
 	}
 
+	for _, config := range params.Configs {
+		if config.Type == "" {
+			missingParameters = append(missingParameters, "configs.type")
+			break
+		}
+	}
+
+	for _, config := range params.Configs {
+		if config.File == "" {
+			missingParameters = append(missingParameters, "configs.file")
+			break
+		}
+	}
+
+	for _, errand := range params.Errands {
+		if errand.Name == "" {
+			missingParameters = append(missingParameters, "errands.name")
+			break
+		}
+	}
+
+	if params.Action != nil && params.Action.Name == "" {
+		missingParameters = append(missingParameters, "action.name")
+	}
+
+	for _, releaseDir := range params.ReleaseDirs {
+		if releaseDir.Dir == "" {
+			missingParameters = append(missingParameters, "release_dirs.dir")
+			break
+		}
+	}
+
 	if len(missingParameters) > 0 {
 		parametersString := "parameter"
 		if len(missingParameters) > 2 {
@@ -67,3 +119,75 @@ func checkAllowedStemcellType(val string) error {
 	}
 	return nil
 }
+
+func checkPlanParameters(params OutParams, source Source) error {
+	if !params.Plan && !params.ApplyPlan {
+		return nil
+	}
+	if params.Plan && params.ApplyPlan {
+		return errors.New("plan and apply_plan can not be used together")
+	}
+	if source.VarsStore.Provider == "" {
+		return errors.New("plan and apply_plan require a vars_store to store the plan in")
+	}
+	if len(params.Configs) > 0 {
+		return errors.New("plan and apply_plan can not be used with configs, the plan does not include config changes")
+	}
+	return nil
+}
+
+func checkActionParameters(params OutParams) error {
+	if params.Action == nil {
+		return nil
+	}
+	if params.Delete.Enabled {
+		return errors.New("action and delete can not be used together")
+	}
+	switch params.Action.Name {
+	case "", "start", "stop", "restart", "recreate", "cloud_check":
+	default:
+		return fmt.Errorf("action only supports 'start', 'stop', 'restart', 'recreate' or 'cloud_check' got: %s", params.Action.Name)
+	}
+	if params.Action.Hard && params.Action.Name != "stop" {
+		return fmt.Errorf("action.hard is only supported by 'stop' got: %s", params.Action.Name)
+	}
+	if params.Action.SkipDrain && (params.Action.Name == "start" || params.Action.Name == "cloud_check") {
+		return fmt.Errorf("action.skip_drain is only supported by 'stop', 'restart' or 'recreate' got: %s", params.Action.Name)
+	}
+	if (len(params.Action.Policy) > 0 || params.Action.ReportOnly) && params.Action.Name != "cloud_check" {
+		return fmt.Errorf("action.policy and action.report_only are only supported by 'cloud_check' got: %s", params.Action.Name)
+	}
+	return nil
+}
+
+func checkCertificateExpiryParameters(params OutParams, source Source) error {
+	if params.CertificateExpiry == nil {
+		return nil
+	}
+	if params.CertificateExpiry.Days < 1 {
+		return fmt.Errorf("certificate_expiry.days must be at least 1 got: %d", params.CertificateExpiry.Days)
+	}
+	if params.CertificateExpiry.Rotate && source.VarsStore.Provider == "" {
+		return errors.New("certificate_expiry.rotate requires a vars_store to rotate the certificates in")
+	}
+	return nil
+}
+
+func checkRotateVariablesParameters(params OutParams, source Source) error {
+	if len(params.RotateVariables) > 0 && source.VarsStore.Provider == "" {
+		return errors.New("rotate_variables requires a vars_store to rotate the variables in")
+	}
+	if params.RestoreRevision != "" && source.VarsStore.Provider == "" {
+		return errors.New("restore_vars_store_revision requires a vars_store to restore")
+	}
+	if params.TransitionalCAs && len(params.RotateVariables) == 0 {
+		return errors.New("transitional_ca_rotation requires rotate_variables")
+	}
+	if len(params.RetireOldCAs) > 0 && source.VarsStore.Provider == "" {
+		return errors.New("retire_old_cas requires a vars_store to retire the old CAs in")
+	}
+	if len(params.RetireOldCAs) > 0 && params.TransitionalCAs {
+		return errors.New("retire_old_cas can not be used with transitional_ca_rotation, retire the old CAs in a later put")
+	}
+	return nil
+}
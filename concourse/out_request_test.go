@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"time"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -285,4 +286,321 @@ var _ = Describe("NewOutRequest", func() {
 			Expect(err.Error()).To(ContainSubstring("manifest"))
 		})
 	})
+
+	Context("when an errand is missing a name", func() {
+		It("returns an error", func() {
+			config := []byte(`{
+				"source": {
+					"deployment": "mydeployment",
+					"target": "director.example.com",
+					"client": "foo",
+					"client_secret": "foobar"
+				},
+				"params": {
+					"manifest": "path/to/manifest.yml",
+					"errands": [{"keep_alive": true}]
+				}
+			}`)
+
+			_, err := concourse.NewOutRequest(config, "")
+			Expect(err).To(MatchError(ContainSubstring("errands.name")))
+		})
+	})
+
+	Context("when a config is missing its type or file", func() {
+		It("returns an error", func() {
+			config := []byte(`{
+				"source": {
+					"deployment": "mydeployment",
+					"target": "director.example.com",
+					"client": "foo",
+					"client_secret": "foobar"
+				},
+				"params": {
+					"manifest": "path/to/manifest.yml",
+					"configs": [{"name": "dns"}]
+				}
+			}`)
+
+			_, err := concourse.NewOutRequest(config, "")
+			Expect(err).To(MatchError(ContainSubstring("configs.type, configs.file")))
+		})
+	})
+
+	Context("when a release dir is missing its dir", func() {
+		It("returns an error", func() {
+			config := []byte(`{
+				"source": {
+					"deployment": "mydeployment",
+					"target": "director.example.com",
+					"client": "foo",
+					"client_secret": "foobar"
+				},
+				"params": {
+					"manifest": "path/to/manifest.yml",
+					"release_dirs": [{"force": true}]
+				}
+			}`)
+
+			_, err := concourse.NewOutRequest(config, "")
+			Expect(err).To(MatchError(ContainSubstring("release_dirs.dir")))
+		})
+	})
+
+	Context("when plan or apply_plan are set", func() {
+		var config string
+
+		BeforeEach(func() {
+			config = `{
+				"source": {
+					"deployment": "mydeployment",
+					"target": "director.example.com",
+					"client": "foo",
+					"client_secret": "foobar"%s
+				},
+				"params": {
+					"manifest": "path/to/manifest.yml"%s
+				}
+			}`
+		})
+
+		It("requires a vars store", func() {
+			_, err := concourse.NewOutRequest([]byte(fmt.Sprintf(config, "", `, "plan": true`)), "")
+			Expect(err).To(MatchError("plan and apply_plan require a vars_store to store the plan in"))
+		})
+
+		It("does not allow both", func() {
+			_, err := concourse.NewOutRequest([]byte(fmt.Sprintf(config, `, "vars_store": {"provider": "gcs"}`, `, "plan": true, "apply_plan": true`)), "")
+			Expect(err).To(MatchError("plan and apply_plan can not be used together"))
+		})
+
+		It("does not allow configs", func() {
+			_, err := concourse.NewOutRequest([]byte(fmt.Sprintf(config, `, "vars_store": {"provider": "gcs"}`, `, "apply_plan": true, "configs": [{"type": "runtime", "file": "runtime.yml"}]`)), "")
+			Expect(err).To(MatchError("plan and apply_plan can not be used with configs, the plan does not include config changes"))
+		})
+
+		It("sets Plan and ApplyPlan in OutParams", func() {
+			outRequest, err := concourse.NewOutRequest([]byte(fmt.Sprintf(config, `, "vars_store": {"provider": "gcs"}`, `, "apply_plan": true`)), "")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(outRequest.Params.ApplyPlan).To(BeTrue())
+		})
+	})
+
+	Context("when timeouts are set", func() {
+		It("parses them as durations", func() {
+			config := []byte(`{
+				"source": {
+					"deployment": "mydeployment",
+					"target": "director.example.com",
+					"client": "foo",
+					"client_secret": "foobar"
+				},
+				"params": {
+					"manifest": "path/to/manifest.yml",
+					"lock_timeout": "10m",
+					"deploy_timeout": "1h30m"
+				}
+			}`)
+
+			outRequest, err := concourse.NewOutRequest(config, "")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(outRequest.Params.LockTimeout).To(Equal(concourse.Duration(10 * time.Minute)))
+			Expect(outRequest.Params.DeployTimeout).To(Equal(concourse.Duration(90 * time.Minute)))
+		})
+
+		It("returns an error for an invalid duration", func() {
+			config := []byte(`{
+				"source": {
+					"deployment": "mydeployment",
+					"target": "director.example.com",
+					"client": "foo",
+					"client_secret": "foobar"
+				},
+				"params": {
+					"manifest": "path/to/manifest.yml",
+					"lock_timeout": "ten minutes"
+				}
+			}`)
+
+			_, err := concourse.NewOutRequest(config, "")
+			Expect(err).To(MatchError(ContainSubstring("Invalid parameters")))
+		})
+	})
+
+	Context("when upload_concurrency is negative", func() {
+		It("returns an error", func() {
+			config := []byte(`{
+				"source": {
+					"deployment": "mydeployment",
+					"target": "director.example.com",
+					"client": "foo",
+					"client_secret": "foobar"
+				},
+				"params": {
+					"manifest": "path/to/manifest.yml",
+					"upload_concurrency": -1
+				}
+			}`)
+
+			_, err := concourse.NewOutRequest(config, "")
+			Expect(err).To(MatchError("upload_concurrency must be at least 1 got: -1"))
+		})
+	})
+
+	Context("when certificate_expiry is set", func() {
+		config := func(source, certificateExpiry string) []byte {
+			return []byte(fmt.Sprintf(`{
+				"source": {
+					"deployment": "mydeployment",
+					"target": "director.example.com",
+					"client": "foo",
+					"client_secret": "foobar"%s
+				},
+				"params": {
+					"manifest": "path/to/manifest.yml",
+					"certificate_expiry": %s
+				}
+			}`, source, certificateExpiry))
+		}
+
+		It("parses the params", func() {
+			outRequest, err := concourse.NewOutRequest(config("", `{"days": 30, "fail": true}`), "")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(outRequest.Params.CertificateExpiry).To(Equal(&concourse.CertificateExpiryParams{Days: 30, Fail: true}))
+		})
+
+		It("requires the days", func() {
+			_, err := concourse.NewOutRequest(config("", `{"fail": true}`), "")
+			Expect(err).To(MatchError("certificate_expiry.days must be at least 1 got: 0"))
+		})
+
+		It("requires a vars store to rotate", func() {
+			_, err := concourse.NewOutRequest(config("", `{"days": 30, "rotate": true}`), "")
+			Expect(err).To(MatchError("certificate_expiry.rotate requires a vars_store to rotate the certificates in"))
+
+			_, err = concourse.NewOutRequest(config(`, "vars_store": {"provider": "gcs"}`, `{"days": 30, "rotate": true}`), "")
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+
+	Context("when rotate_variables is set", func() {
+		config := func(source, params string) []byte {
+			return []byte(fmt.Sprintf(`{
+				"source": {
+					"deployment": "mydeployment",
+					"target": "director.example.com",
+					"client": "foo",
+					"client_secret": "foobar"%s
+				},
+				"params": {
+					"manifest": "path/to/manifest.yml"%s
+				}
+			}`, source, params))
+		}
+
+		It("parses the params", func() {
+			outRequest, err := concourse.NewOutRequest(config(`, "vars_store": {"provider": "gcs"}`, `, "rotate_variables": ["ca"], "transitional_ca_rotation": true`), "")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(outRequest.Params.RotateVariables).To(Equal([]string{"ca"}))
+			Expect(outRequest.Params.TransitionalCAs).To(BeTrue())
+		})
+
+		It("requires a vars store", func() {
+			_, err := concourse.NewOutRequest(config("", `, "rotate_variables": ["ca"]`), "")
+			Expect(err).To(MatchError("rotate_variables requires a vars_store to rotate the variables in"))
+		})
+
+		It("requires a vars store to restore a revision", func() {
+			_, err := concourse.NewOutRequest(config("", `, "restore_vars_store_revision": "20261018T120000Z"`), "")
+			Expect(err).To(MatchError("restore_vars_store_revision requires a vars_store to restore"))
+		})
+
+		It("requires rotate_variables for transitional_ca_rotation", func() {
+			_, err := concourse.NewOutRequest(config(`, "vars_store": {"provider": "gcs"}`, `, "transitional_ca_rotation": true`), "")
+			Expect(err).To(MatchError("transitional_ca_rotation requires rotate_variables"))
+		})
+
+		It("requires a vars store to retire old CAs", func() {
+			_, err := concourse.NewOutRequest(config("", `, "retire_old_cas": ["ca"]`), "")
+			Expect(err).To(MatchError("retire_old_cas requires a vars_store to retire the old CAs in"))
+		})
+
+		It("does not retire old CAs in the put that rotates them", func() {
+			_, err := concourse.NewOutRequest(config(`, "vars_store": {"provider": "gcs"}`, `, "rotate_variables": ["ca"], "transitional_ca_rotation": true, "retire_old_cas": ["ca"]`), "")
+			Expect(err).To(MatchError("retire_old_cas can not be used with transitional_ca_rotation, retire the old CAs in a later put"))
+		})
+	})
+
+	Context("when an action is specified", func() {
+		var action string
+
+		config := func() []byte {
+			return []byte(`{
+				"source": {
+					"deployment": "mydeployment",
+					"target": "director.example.com",
+					"client": "foo",
+					"client_secret": "foobar"
+				},
+				"params": {
+					"action": ` + action + `
+				}
+			}`)
+		}
+
+		It("does not require the manifest parameter", func() {
+			action = `{"name": "stop", "instances": ["web/0"], "hard": true}`
+
+			outRequest, err := concourse.NewOutRequest(config(), "")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(outRequest.Params.Action).To(Equal(&concourse.ActionParams{
+				Name:      "stop",
+				Instances: []string{"web/0"},
+				Hard:      true,
+			}))
+		})
+
+		It("requires a name", func() {
+			action = `{"instances": ["web/0"]}`
+
+			_, err := concourse.NewOutRequest(config(), "")
+			Expect(err).To(MatchError(ContainSubstring("action.name")))
+		})
+
+		It("returns an error for an unknown action", func() {
+			action = `{"name": "reboot"}`
+
+			_, err := concourse.NewOutRequest(config(), "")
+			Expect(err).To(MatchError("action only supports 'start', 'stop', 'restart', 'recreate' or 'cloud_check' got: reboot"))
+		})
+
+		It("only allows hard for stop", func() {
+			action = `{"name": "restart", "hard": true}`
+
+			_, err := concourse.NewOutRequest(config(), "")
+			Expect(err).To(MatchError("action.hard is only supported by 'stop' got: restart"))
+		})
+
+		It("does not allow skip_drain for start", func() {
+			action = `{"name": "start", "skip_drain": true}`
+
+			_, err := concourse.NewOutRequest(config(), "")
+			Expect(err).To(MatchError("action.skip_drain is only supported by 'stop', 'restart' or 'recreate' got: start"))
+		})
+
+		It("accepts a cloud check policy", func() {
+			action = `{"name": "cloud_check", "policy": {"missing_vm": "recreate_vm"}}`
+
+			outRequest, err := concourse.NewOutRequest(config(), "")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(outRequest.Params.Action.Policy).To(Equal(map[string]string{"missing_vm": "recreate_vm"}))
+		})
+
+		It("only allows policy and report_only for cloud_check", func() {
+			action = `{"name": "start", "report_only": true}`
+
+			_, err := concourse.NewOutRequest(config(), "")
+			Expect(err).To(MatchError("action.policy and action.report_only are only supported by 'cloud_check' got: start"))
+		})
+	})
 })
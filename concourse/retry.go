@@ -0,0 +1,33 @@
+package concourse
+
+import (
+	"fmt"
+)
+
+// RetryErrorClasses are the kinds of errors a RetryConfig can retry.
+var RetryErrorClasses = []string{"connection", "server", "uaa"}
+
+type RetryConfig struct {
+	Attempts   int      `json:"attempts,omitempty" yaml:"attempts"`
+	Backoff    Duration `json:"backoff,omitempty" yaml:"backoff"`
+	MaxBackoff Duration `json:"max_backoff,omitempty" yaml:"max_backoff"`
+	Errors     []string `json:"errors,omitempty" yaml:"errors"`
+}
+
+func checkRetryConfig(retry RetryConfig) error {
+	if retry.Attempts < 0 {
+		return fmt.Errorf("retry.attempts must be at least 1 got: %d", retry.Attempts)
+	}
+
+	for _, class := range retry.Errors {
+		known := false
+		for _, retryClass := range RetryErrorClasses {
+			known = known || class == retryClass
+		}
+		if !known {
+			return fmt.Errorf("retry.errors only supports 'connection', 'server' or 'uaa' got: %s", class)
+		}
+	}
+
+	return nil
+}
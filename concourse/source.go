@@ -13,16 +13,17 @@ import (
 )
 
 type Source struct {
-	Deployment      string    `json:"deployment,omitempty" yaml:"deployment"`
-	Client          string    `json:"client,omitempty" yaml:"client"`
-	ClientSecret    string    `json:"client_secret,omitempty" yaml:"client_secret"`
-	Target          string    `json:"target,omitempty" yaml:"target"`
-	CACert          string    `json:"ca_cert,omitempty" yaml:"ca_cert"`
-	JumpboxSSHKey   string    `json:"jumpbox_ssh_key,omitempty" yaml:"jumpbox_ssh_key"`
-	JumpboxURL      string    `json:"jumpbox_url,omitempty" yaml:"jumpbox_url"`
-	JumpboxUsername string    `json:"jumpbox_username,omitempty" yaml:"jumpbox_username"`
-	VarsStore       VarsStore `json:"vars_store,omitempty" yaml:"vars_store"`
-	SkipCheck       bool      `json:"skip_check,omitempty" yaml:"skip_check"`
+	Deployment      string      `json:"deployment,omitempty" yaml:"deployment"`
+	Client          string      `json:"client,omitempty" yaml:"client"`
+	ClientSecret    string      `json:"client_secret,omitempty" yaml:"client_secret"`
+	Target          string      `json:"target,omitempty" yaml:"target"`
+	CACert          string      `json:"ca_cert,omitempty" yaml:"ca_cert"`
+	JumpboxSSHKey   string      `json:"jumpbox_ssh_key,omitempty" yaml:"jumpbox_ssh_key"`
+	JumpboxURL      string      `json:"jumpbox_url,omitempty" yaml:"jumpbox_url"`
+	JumpboxUsername string      `json:"jumpbox_username,omitempty" yaml:"jumpbox_username"`
+	VarsStore       VarsStore   `json:"vars_store,omitempty" yaml:"vars_store"`
+	SkipCheck       bool        `json:"skip_check,omitempty" yaml:"skip_check"`
+	Retry           RetryConfig `json:"retry,omitempty" yaml:"retry"`
 }
 
 type sourceRequest struct {
@@ -66,6 +67,10 @@ func NewDynamicSource(config []byte, sourcesDir string) (Source, error) {
 		return Source{}, err
 	}
 
+	if err := checkRetryConfig(sourceRequest.Source.Retry); err != nil {
+		return Source{}, err
+	}
+
 	return sourceRequest.Source, nil
 }
 
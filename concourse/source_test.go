@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"time"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -143,6 +144,72 @@ var _ = Describe("NewDynamicSource", func() {
 		})
 	})
 
+	Context("when a retry policy is given", func() {
+		It("parses the backoffs", func() {
+			config := []byte(`{
+				"source": {
+					"deployment": "mydeployment",
+					"target": "director.example.com",
+					"client": "foo",
+					"client_secret": "foobar",
+					"retry": {"attempts": 3, "backoff": "2s", "max_backoff": "1m", "errors": ["connection", "uaa"]}
+				}
+			}`)
+
+			source, err := concourse.NewDynamicSource(config, "")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(source.Retry).To(Equal(concourse.RetryConfig{
+				Attempts:   3,
+				Backoff:    concourse.Duration(2 * time.Second),
+				MaxBackoff: concourse.Duration(time.Minute),
+				Errors:     []string{"connection", "uaa"},
+			}))
+		})
+
+		It("reads the retry policy from the source_file", func() {
+			sourceFile, _ := os.CreateTemp("", "") //nolint:errcheck
+			sourceFile.Write(properYaml(           //nolint:errcheck
+				`
+				retry:
+					attempts: 4
+					backoff: 10s
+			`))
+			sourceFile.Close() //nolint:errcheck
+
+			config := []byte(fmt.Sprintf(`{
+				"params": {"source_file": "%s"},
+				"source": {
+					"deployment": "mydeployment",
+					"target": "director.example.com",
+					"client": "foo",
+					"client_secret": "foobar"
+				}
+			}`, filepath.Base(sourceFile.Name())))
+
+			source, err := concourse.NewDynamicSource(config, filepath.Dir(sourceFile.Name()))
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(source.Retry.Attempts).To(Equal(4))
+			Expect(source.Retry.Backoff).To(Equal(concourse.Duration(10 * time.Second)))
+		})
+
+		It("errors on an unknown error class", func() {
+			config := []byte(`{
+				"source": {
+					"deployment": "mydeployment",
+					"target": "director.example.com",
+					"client": "foo",
+					"client_secret": "foobar",
+					"retry": {"attempts": 3, "errors": ["timeouts"]}
+				}
+			}`)
+
+			_, err := concourse.NewDynamicSource(config, "")
+			Expect(err).To(MatchError("retry.errors only supports 'connection', 'server' or 'uaa' got: timeouts"))
+		})
+	})
+
 	Context("when decoding fails", func() {
 		It("errors", func() {
 			reader := []byte("not-json")
@@ -0,0 +1,64 @@
+// Package encryption encrypts the vars store in the age format
+// (age-encryption.org/v1) with filippo.io/age, the library of the age CLI,
+// so it can also be decrypted with the age CLI.
+package encryption
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+
+	"filippo.io/age"
+)
+
+const ageIntro = "age-encryption.org/v1"
+
+type Cipher interface {
+	Encrypt(plaintext []byte) ([]byte, error)
+	Decrypt(ciphertext []byte) ([]byte, error)
+}
+
+type ageCipher struct {
+	recipients []age.Recipient
+	identities []age.Identity
+}
+
+// IsEncrypted reports whether the contents are in the age format.
+func IsEncrypted(contents []byte) bool {
+	return bytes.HasPrefix(contents, []byte(ageIntro+"\n"))
+}
+
+func (c ageCipher) Encrypt(plaintext []byte) ([]byte, error) {
+	ciphertext := &bytes.Buffer{}
+	w, err := age.Encrypt(ciphertext, c.recipients...)
+	if err != nil {
+		return nil, fmt.Errorf("could not encrypt the vars store: %s", err)
+	}
+	if _, err := w.Write(plaintext); err != nil {
+		return nil, fmt.Errorf("could not encrypt the vars store: %s", err)
+	}
+	if err := w.Close(); err != nil {
+		return nil, fmt.Errorf("could not encrypt the vars store: %s", err)
+	}
+
+	return ciphertext.Bytes(), nil
+}
+
+func (c ageCipher) Decrypt(ciphertext []byte) ([]byte, error) {
+	r, err := age.Decrypt(bytes.NewReader(ciphertext), c.identities...)
+	var noMatch *age.NoIdentityMatchError
+	if errors.As(err, &noMatch) {
+		return nil, errors.New("could not decrypt the vars store, none of the keys matches")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not decrypt the vars store: %s", err)
+	}
+
+	plaintext, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("could not decrypt the vars store: %s", err)
+	}
+
+	return plaintext, nil
+}
@@ -0,0 +1,174 @@
+package encryption_test
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/service/kms"
+	"github.com/aws/aws-sdk-go/service/kms/kmsiface"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry/bosh-deployment-resource/encryption"
+)
+
+// fakeKMS wraps keys by reversing them.
+type fakeKMS struct {
+	kmsiface.KMSAPI
+	keyIDs []string
+}
+
+func (f *fakeKMS) Encrypt(input *kms.EncryptInput) (*kms.EncryptOutput, error) {
+	f.keyIDs = append(f.keyIDs, *input.KeyId)
+	return &kms.EncryptOutput{CiphertextBlob: reversed(input.Plaintext)}, nil
+}
+
+func (f *fakeKMS) Decrypt(input *kms.DecryptInput) (*kms.DecryptOutput, error) {
+	if *input.KeyId != "some-key" {
+		return nil, errors.New("AccessDeniedException")
+	}
+	return &kms.DecryptOutput{Plaintext: reversed(input.CiphertextBlob)}, nil
+}
+
+func reversed(b []byte) []byte {
+	r := make([]byte, len(b))
+	for i := range b {
+		r[len(b)-1-i] = b[i]
+	}
+	return r
+}
+
+var _ = Describe("Cipher", func() {
+	var identity string
+
+	BeforeEach(func() {
+		var err error
+		identity, err = encryption.GenerateX25519Identity()
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	Describe("NewX25519Cipher", func() {
+		It("encrypts in the age format and decrypts again", func() {
+			cipher, err := encryption.NewX25519Cipher(identity, nil)
+			Expect(err).NotTo(HaveOccurred())
+
+			ciphertext, err := cipher.Encrypt([]byte("password: secret\n"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(encryption.IsEncrypted(ciphertext)).To(BeTrue())
+			Expect(string(ciphertext)).To(HavePrefix("age-encryption.org/v1\n-> X25519 "))
+			Expect(string(ciphertext)).NotTo(ContainSubstring("secret"))
+
+			plaintext, err := cipher.Decrypt(ciphertext)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(plaintext)).To(Equal("password: secret\n"))
+		})
+
+		It("encrypts for the additional recipients", func() {
+			otherIdentity, err := encryption.GenerateX25519Identity()
+			Expect(err).NotTo(HaveOccurred())
+			otherRecipient, err := encryption.X25519Recipient(otherIdentity)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(otherRecipient).To(HavePrefix("age1"))
+
+			cipher, err := encryption.NewX25519Cipher(identity, []string{otherRecipient})
+			Expect(err).NotTo(HaveOccurred())
+			ciphertext, err := cipher.Encrypt([]byte("password: secret\n"))
+			Expect(err).NotTo(HaveOccurred())
+
+			otherCipher, err := encryption.NewX25519Cipher(otherIdentity, nil)
+			Expect(err).NotTo(HaveOccurred())
+			plaintext, err := otherCipher.Decrypt(ciphertext)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(plaintext)).To(Equal("password: secret\n"))
+		})
+
+		It("does not decrypt with another identity", func() {
+			cipher, err := encryption.NewX25519Cipher(identity, nil)
+			Expect(err).NotTo(HaveOccurred())
+			ciphertext, err := cipher.Encrypt([]byte("password: secret\n"))
+			Expect(err).NotTo(HaveOccurred())
+
+			otherIdentity, err := encryption.GenerateX25519Identity()
+			Expect(err).NotTo(HaveOccurred())
+			otherCipher, err := encryption.NewX25519Cipher(otherIdentity, nil)
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = otherCipher.Decrypt(ciphertext)
+			Expect(err).To(MatchError("could not decrypt the vars store, none of the keys matches"))
+		})
+
+		It("detects a tampered payload", func() {
+			cipher, err := encryption.NewX25519Cipher(identity, nil)
+			Expect(err).NotTo(HaveOccurred())
+			ciphertext, err := cipher.Encrypt([]byte("password: secret\n"))
+			Expect(err).NotTo(HaveOccurred())
+
+			ciphertext[len(ciphertext)-1] ^= 1
+			_, err = cipher.Decrypt(ciphertext)
+			Expect(err).To(MatchError(HavePrefix("could not decrypt the vars store: ")))
+		})
+
+		It("encrypts vars stores of any size", func() {
+			cipher, err := encryption.NewX25519Cipher(identity, nil)
+			Expect(err).NotTo(HaveOccurred())
+
+			for _, size := range []int{0, 1, 64 * 1024, 64*1024 + 1, 200 * 1024} {
+				plaintext := bytes.Repeat([]byte("a"), size)
+				ciphertext, err := cipher.Encrypt(plaintext)
+				Expect(err).NotTo(HaveOccurred())
+
+				decrypted, err := cipher.Decrypt(ciphertext)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(decrypted).To(Equal(plaintext))
+			}
+		})
+
+		It("rejects invalid keys", func() {
+			_, err := encryption.NewX25519Cipher("not-a-key", nil)
+			Expect(err).To(MatchError("invalid age identity, expected AGE-SECRET-KEY-1..."))
+
+			_, err = encryption.NewX25519Cipher(identity, []string{strings.ToLower(identity)})
+			Expect(err).To(MatchError(HavePrefix("invalid age recipient")))
+		})
+	})
+
+	Describe("NewPassphraseCipher", func() {
+		It("encrypts with the passphrase and decrypts again", func() {
+			cipher, err := encryption.NewPassphraseCipher("correct horse battery staple")
+			Expect(err).NotTo(HaveOccurred())
+
+			ciphertext, err := cipher.Encrypt([]byte("password: secret\n"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(ciphertext)).To(HavePrefix("age-encryption.org/v1\n-> scrypt "))
+
+			plaintext, err := cipher.Decrypt(ciphertext)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(plaintext)).To(Equal("password: secret\n"))
+
+			wrongCipher, err := encryption.NewPassphraseCipher("wrong")
+			Expect(err).NotTo(HaveOccurred())
+			_, err = wrongCipher.Decrypt(ciphertext)
+			Expect(err).To(MatchError("could not decrypt the vars store, none of the keys matches"))
+		})
+	})
+
+	Describe("NewKMSCipher", func() {
+		It("wraps the file key with the KMS key", func() {
+			client := &fakeKMS{}
+			cipher := encryption.NewKMSCipher(client, "some-key")
+
+			ciphertext, err := cipher.Encrypt([]byte("password: secret\n"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(ciphertext)).To(HavePrefix("age-encryption.org/v1\n-> aws-kms\n"))
+			Expect(client.keyIDs).To(Equal([]string{"some-key"}))
+
+			plaintext, err := cipher.Decrypt(ciphertext)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(plaintext)).To(Equal("password: secret\n"))
+
+			_, err = encryption.NewKMSCipher(client, "other-key").Decrypt(ciphertext)
+			Expect(err).To(MatchError("could not decrypt the vars store: could not decrypt with KMS key other-key: AccessDeniedException"))
+		})
+	})
+})
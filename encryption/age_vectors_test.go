@@ -0,0 +1,136 @@
+package encryption_test
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"io/fs"
+	"strings"
+
+	agetest "c2sp.org/CCTV/age"
+	"filippo.io/age"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry/bosh-deployment-resource/encryption"
+)
+
+// ageVector is a test vector of the age test kit (c2sp.org/CCTV/age).
+type ageVector struct {
+	expect      string
+	payloadHash string
+	identities  []string
+	passphrases []string
+	armored     bool
+	file        []byte
+}
+
+func parseAgeVector(contents []byte) ageVector {
+	v := ageVector{}
+	for {
+		line, rest, ok := bytes.Cut(contents, []byte("\n"))
+		Expect(ok).To(BeTrue(), "the vector has no payload")
+		contents = rest
+		if len(line) == 0 {
+			break
+		}
+
+		key, value, _ := strings.Cut(string(line), ": ")
+		switch key {
+		case "expect":
+			v.expect = value
+		case "payload":
+			v.payloadHash = value
+		case "identity":
+			v.identities = append(v.identities, value)
+		case "passphrase":
+			v.passphrases = append(v.passphrases, value)
+		case "armored":
+			v.armored = true
+		}
+	}
+	v.file = contents
+	return v
+}
+
+// cipher returns the cipher the vector is decrypted with, or nil when the
+// vector needs keys a cipher can not be configured with.
+func (v ageVector) cipher() encryption.Cipher {
+	if v.armored || len(v.identities)+len(v.passphrases) != 1 {
+		return nil
+	}
+
+	if len(v.identities) == 1 {
+		cipher, err := encryption.NewX25519Cipher(v.identities[0], nil)
+		Expect(err).NotTo(HaveOccurred())
+		return cipher
+	}
+
+	cipher, err := encryption.NewPassphraseCipher(v.passphrases[0])
+	Expect(err).NotTo(HaveOccurred())
+	return cipher
+}
+
+var _ = Describe("age compatibility", func() {
+	It("passes the known-answer tests of the age test kit", func() {
+		names, err := fs.Glob(agetest.Vectors, "*")
+		Expect(err).NotTo(HaveOccurred())
+
+		tested := 0
+		for _, name := range names {
+			contents, err := fs.ReadFile(agetest.Vectors, name)
+			Expect(err).NotTo(HaveOccurred())
+
+			v := parseAgeVector(contents)
+			cipher := v.cipher()
+			if cipher == nil {
+				continue
+			}
+			tested++
+
+			plaintext, err := cipher.Decrypt(v.file)
+			if v.expect != "success" {
+				Expect(err).To(HaveOccurred(), name)
+				continue
+			}
+			Expect(err).NotTo(HaveOccurred(), name)
+
+			hash := sha256.Sum256(plaintext)
+			Expect(hex.EncodeToString(hash[:])).To(Equal(v.payloadHash), name)
+		}
+		Expect(tested).To(BeNumerically(">", 50))
+	})
+
+	It("writes files the age CLI decrypts with the identity", func() {
+		identity, err := encryption.GenerateX25519Identity()
+		Expect(err).NotTo(HaveOccurred())
+		cipher, err := encryption.NewX25519Cipher(identity, nil)
+		Expect(err).NotTo(HaveOccurred())
+		ciphertext, err := cipher.Encrypt([]byte("password: secret\n"))
+		Expect(err).NotTo(HaveOccurred())
+
+		ageIdentity, err := age.ParseX25519Identity(identity)
+		Expect(err).NotTo(HaveOccurred())
+		r, err := age.Decrypt(bytes.NewReader(ciphertext), ageIdentity)
+		Expect(err).NotTo(HaveOccurred())
+		plaintext, err := io.ReadAll(r)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(plaintext)).To(Equal("password: secret\n"))
+	})
+
+	It("writes files the age CLI decrypts with the passphrase", func() {
+		cipher, err := encryption.NewPassphraseCipher("correct horse battery staple")
+		Expect(err).NotTo(HaveOccurred())
+		ciphertext, err := cipher.Encrypt([]byte("password: secret\n"))
+		Expect(err).NotTo(HaveOccurred())
+
+		ageIdentity, err := age.NewScryptIdentity("correct horse battery staple")
+		Expect(err).NotTo(HaveOccurred())
+		r, err := age.Decrypt(bytes.NewReader(ciphertext), ageIdentity)
+		Expect(err).NotTo(HaveOccurred())
+		plaintext, err := io.ReadAll(r)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(plaintext)).To(Equal("password: secret\n"))
+	})
+})
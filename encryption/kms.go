@@ -0,0 +1,59 @@
+package encryption
+
+import (
+	"fmt"
+
+	"filippo.io/age"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/kms"
+	"github.com/aws/aws-sdk-go/service/kms/kmsiface"
+)
+
+const kmsStanzaType = "aws-kms"
+
+type kmsKey struct {
+	client kmsiface.KMSAPI
+	keyID  string
+}
+
+// NewKMSCipher encrypts and decrypts with a file key that is wrapped by the
+// AWS KMS key. The age CLI can not decrypt these files.
+func NewKMSCipher(client kmsiface.KMSAPI, keyID string) Cipher {
+	key := kmsKey{client: client, keyID: keyID}
+	return ageCipher{
+		recipients: []age.Recipient{key},
+		identities: []age.Identity{key},
+	}
+}
+
+func (k kmsKey) Wrap(fileKey []byte) ([]*age.Stanza, error) {
+	output, err := k.client.Encrypt(&kms.EncryptInput{
+		KeyId:     aws.String(k.keyID),
+		Plaintext: fileKey,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not encrypt with KMS key %s: %s", k.keyID, err)
+	}
+
+	return []*age.Stanza{{Type: kmsStanzaType, Body: output.CiphertextBlob}}, nil
+}
+
+func (k kmsKey) Unwrap(stanzas []*age.Stanza) ([]byte, error) {
+	for _, s := range stanzas {
+		if s.Type != kmsStanzaType {
+			continue
+		}
+
+		output, err := k.client.Decrypt(&kms.DecryptInput{
+			KeyId:          aws.String(k.keyID),
+			CiphertextBlob: s.Body,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("could not decrypt with KMS key %s: %s", k.keyID, err)
+		}
+
+		return output.Plaintext, nil
+	}
+
+	return nil, age.ErrIncorrectIdentity
+}
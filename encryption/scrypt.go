@@ -0,0 +1,24 @@
+package encryption
+
+import (
+	"filippo.io/age"
+)
+
+// NewPassphraseCipher encrypts and decrypts with a key derived from the
+// passphrase with scrypt.
+func NewPassphraseCipher(passphrase string) (Cipher, error) {
+	recipient, err := age.NewScryptRecipient(passphrase)
+	if err != nil {
+		return nil, err
+	}
+
+	identity, err := age.NewScryptIdentity(passphrase)
+	if err != nil {
+		return nil, err
+	}
+
+	return ageCipher{
+		recipients: []age.Recipient{recipient},
+		identities: []age.Identity{identity},
+	}, nil
+}
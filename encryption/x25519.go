@@ -0,0 +1,52 @@
+package encryption
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"filippo.io/age"
+)
+
+// NewX25519Cipher encrypts for the age identity and the additional age
+// recipients, and decrypts with the identity.
+func NewX25519Cipher(ageIdentity string, recipients []string) (Cipher, error) {
+	id, err := age.ParseX25519Identity(strings.TrimSpace(ageIdentity))
+	if err != nil {
+		return nil, errors.New("invalid age identity, expected AGE-SECRET-KEY-1...")
+	}
+
+	cipher := ageCipher{
+		recipients: []age.Recipient{id.Recipient()},
+		identities: []age.Identity{id},
+	}
+	for _, r := range recipients {
+		parsed, err := age.ParseX25519Recipient(r)
+		if err != nil {
+			return nil, fmt.Errorf("invalid age recipient %s", r)
+		}
+		cipher.recipients = append(cipher.recipients, parsed)
+	}
+
+	return cipher, nil
+}
+
+// GenerateX25519Identity returns a new age identity.
+func GenerateX25519Identity() (string, error) {
+	id, err := age.GenerateX25519Identity()
+	if err != nil {
+		return "", err
+	}
+
+	return id.String(), nil
+}
+
+// X25519Recipient returns the age recipient of an age identity.
+func X25519Recipient(ageIdentity string) (string, error) {
+	id, err := age.ParseX25519Identity(strings.TrimSpace(ageIdentity))
+	if err != nil {
+		return "", errors.New("invalid age identity, expected AGE-SECRET-KEY-1...")
+	}
+
+	return id.Recipient().String(), nil
+}
@@ -1,24 +1,42 @@
 package gcp
 
 import (
+	"bytes"
+	"context"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
+	"strings"
+	"time"
 
 	"golang.org/x/oauth2"
 	oauthgoogle "golang.org/x/oauth2/google"
 	"google.golang.org/api/googleapi"
 	"google.golang.org/api/storage/v1"
+
+	"github.com/cloudfoundry/bosh-deployment-resource/storage/provider"
 )
 
 type Storage struct {
 	bucket         string
 	objectPath     string
 	storageService *storage.Service
+	generation     *generation
+	revisions      int
+	build          string
+}
+
+// generation is the generation of the vars store that was downloaded last,
+// which the next upload of the vars store is conditional on.
+type generation struct {
+	known bool
+	value int64
 }
 
-func NewStorage(jsonKey, bucket, objectPath string) (Storage, error) {
+// NewStorage returns the storage of the vars store. The given number of
+// uploads of the vars store are kept as revisions, tagged with the build.
+func NewStorage(jsonKey, bucket, objectPath string, revisions int, build string) (Storage, error) {
 	var err error
 	var storageClient *http.Client
 	var userAgent = "bosh-deployment-resource"
@@ -35,61 +53,210 @@ func NewStorage(jsonKey, bucket, objectPath string) (Storage, error) {
 	}
 	storageService.UserAgent = userAgent
 
+	return NewStorageWithService(storageService, bucket, objectPath, revisions, build)
+}
+
+// NewStorageWithService returns the storage of the vars store that talks to
+// GCS through the given service.
+func NewStorageWithService(storageService *storage.Service, bucket, objectPath string, revisions int, build string) (Storage, error) {
+	if revisions < 0 {
+		return Storage{}, fmt.Errorf("revisions must not be negative got: %d", revisions)
+	}
+
 	return Storage{
 		bucket:         bucket,
 		objectPath:     objectPath,
 		storageService: storageService,
+		generation:     &generation{},
+		revisions:      revisions,
+		build:          build,
 	}, nil
 }
 
 func (s Storage) Download(filePath string) error {
-	getCall := s.storageService.Objects.Get(s.bucket, s.objectPath)
+	if err := s.DownloadReadOnly(filePath); err != nil {
+		return err
+	}
+
+	// Check that we can not only read the file, but can also write it
+	return s.write(filePath)
+}
 
-	_, err := getCall.Do()
+// DownloadReadOnly fetches the vars store without verifying that it can be
+// written, and leaves filePath untouched if the vars store does not exist yet.
+func (s Storage) DownloadReadOnly(filePath string) error {
+	objectGeneration, err := s.download(s.objectPath, filePath)
 	if err != nil {
 		switch err.(type) { //nolint:staticcheck
 		case *googleapi.Error:
 			if err.(*googleapi.Error).Code == 404 { //nolint:staticcheck
-				return s.Upload(filePath)
+				*s.generation = generation{known: true}
+				return nil
 			}
 		}
 
 		return err
 	}
 
-	response, err := getCall.Download()
+	*s.generation = generation{known: true, value: objectGeneration}
+	return nil
+}
+
+// Upload writes the vars store, unless it was changed since it was last
+// downloaded, in which case it returns provider.ErrConflict. The upload is
+// kept as a revision when revisions are configured.
+func (s Storage) Upload(filePath string) error {
+	if err := s.write(filePath); err != nil {
+		return err
+	}
+
+	return s.keepRevision(filePath)
+}
+
+func (s Storage) write(filePath string) error {
+	insertCall, err := s.insertCall(s.objectPath, filePath, nil)
 	if err != nil {
 		return err
 	}
-	defer response.Body.Close() //nolint:errcheck
 
-	responseBytes, err := io.ReadAll(response.Body)
+	if s.generation.known {
+		// A generation of 0 only matches when the object does not exist.
+		insertCall = insertCall.IfGenerationMatch(s.generation.value)
+	}
+
+	object, err := insertCall.Do()
+	if err != nil {
+		if apiError, ok := err.(*googleapi.Error); ok && apiError.Code == http.StatusPreconditionFailed {
+			return fmt.Errorf("Can not write to %s in bucket %s: %w", s.objectPath, s.bucket, provider.ErrConflict) //nolint:staticcheck
+		}
+		return fmt.Errorf("Can not write to %s in bucket %s", s.objectPath, s.bucket) //nolint:staticcheck
+	}
+
+	*s.generation = generation{known: true, value: object.Generation}
+	return nil
+}
+
+func (s Storage) DownloadObject(suffix, filePath string) error {
+	if _, err := s.download(s.objectPath+suffix, filePath); err != nil {
+		return fmt.Errorf("Can not read %s in bucket %s: %s", s.objectPath+suffix, s.bucket, err) //nolint:staticcheck
+	}
+
+	return nil
+}
+
+func (s Storage) UploadObject(suffix, filePath string) error {
+	return s.upload(s.objectPath+suffix, filePath)
+}
+
+// Revisions returns the IDs of the kept revisions of the vars store, oldest
+// first.
+func (s Storage) Revisions() ([]string, error) {
+	prefix := s.objectPath + provider.RevisionSuffix
+	revisions := []string{}
+	err := s.storageService.Objects.List(s.bucket).Prefix(prefix).Pages(context.Background(), func(objects *storage.Objects) error {
+		for _, object := range objects.Items {
+			revisions = append(revisions, strings.TrimPrefix(object.Name, prefix))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("Can not list the revisions of %s in bucket %s: %s", s.objectPath, s.bucket, err) //nolint:staticcheck
+	}
+
+	provider.SortRevisions(revisions)
+	return revisions, nil
+}
+
+func (s Storage) DownloadRevision(id, filePath string) error {
+	return s.DownloadObject(provider.RevisionSuffix+id, filePath)
+}
+
+// Close does nothing, the GCS storage keeps no local state.
+func (s Storage) Close() error {
+	return nil
+}
+
+// keepRevision copies the uploaded vars store to a new revision, and deletes
+// the oldest revisions beyond the ones to keep.
+func (s Storage) keepRevision(filePath string) error {
+	if s.revisions == 0 {
+		return nil
+	}
+
+	objectPath := s.objectPath + provider.RevisionSuffix + provider.NewRevisionID(time.Now())
+	var metadata map[string]string
+	if s.build != "" {
+		metadata = map[string]string{provider.BuildMetadataKey: s.build}
+	}
+
+	insertCall, err := s.insertCall(objectPath, filePath, metadata)
 	if err != nil {
 		return err
 	}
+	if _, err := insertCall.Do(); err != nil {
+		return fmt.Errorf("Can not write to %s in bucket %s", objectPath, s.bucket) //nolint:staticcheck
+	}
 
-	err = os.WriteFile(filePath, responseBytes, 0600)
+	revisions, err := s.Revisions()
 	if err != nil {
 		return err
 	}
 
-	// Check that we can not only read the file, but can also write it
-	return s.Upload(filePath)
+	for len(revisions) > s.revisions {
+		objectPath := s.objectPath + provider.RevisionSuffix + revisions[0]
+		if err := s.storageService.Objects.Delete(s.bucket, objectPath).Do(); err != nil {
+			return fmt.Errorf("Can not delete %s in bucket %s: %s", objectPath, s.bucket, err) //nolint:staticcheck
+		}
+		revisions = revisions[1:]
+	}
+
+	return nil
 }
 
-func (s Storage) Upload(filePath string) error {
-	f, err := os.Open(filePath)
+// download writes the object to filePath and returns its generation.
+func (s Storage) download(objectPath, filePath string) (int64, error) {
+	object, err := s.storageService.Objects.Get(s.bucket, objectPath).Do()
 	if err != nil {
-		return err
+		return 0, err
 	}
 
-	object := &storage.Object{
-		Name: s.objectPath,
+	response, err := s.storageService.Objects.Get(s.bucket, objectPath).Generation(object.Generation).Download()
+	if err != nil {
+		return 0, err
 	}
+	defer response.Body.Close() //nolint:errcheck
 
-	if _, err = s.storageService.Objects.Insert(s.bucket, object).Media(f).Do(); err != nil {
-		return fmt.Errorf("Can not write to %s in bucket %s", s.objectPath, s.bucket) //nolint:staticcheck
+	responseBytes, err := io.ReadAll(response.Body)
+	if err != nil {
+		return 0, err
+	}
+
+	return object.Generation, os.WriteFile(filePath, responseBytes, 0600)
+}
+
+func (s Storage) upload(objectPath, filePath string) error {
+	insertCall, err := s.insertCall(objectPath, filePath, nil)
+	if err != nil {
+		return err
+	}
+
+	if _, err = insertCall.Do(); err != nil {
+		return fmt.Errorf("Can not write to %s in bucket %s", objectPath, s.bucket) //nolint:staticcheck
 	}
 
 	return nil
 }
+
+func (s Storage) insertCall(objectPath, filePath string, metadata map[string]string) (*storage.ObjectsInsertCall, error) {
+	contents, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	object := &storage.Object{
+		Name:     objectPath,
+		Metadata: metadata,
+	}
+
+	return s.storageService.Objects.Insert(s.bucket, object).Media(bytes.NewReader(contents)), nil
+}
@@ -0,0 +1,322 @@
+package gcp_test
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"google.golang.org/api/storage/v1"
+
+	"github.com/cloudfoundry/bosh-deployment-resource/gcp"
+	"github.com/cloudfoundry/bosh-deployment-resource/storage/provider"
+)
+
+// fakeGCS serves the JSON API requests for the objects of a single bucket.
+type fakeGCS struct {
+	lock              sync.Mutex
+	objects           map[string]string
+	generations       map[string]int64
+	ifGenerationMatch map[string]string
+	metadata          map[string]map[string]string
+	lastGeneration    int64
+}
+
+func (f *fakeGCS) put(name, object string) {
+	f.lastGeneration++
+	f.objects[name] = object
+	f.generations[name] = f.lastGeneration
+}
+
+func (f *fakeGCS) fail(w http.ResponseWriter, code int) {
+	w.WriteHeader(code)
+	w.Write([]byte(`{"error":{"code":` + strconv.Itoa(code) + `,"message":"` + http.StatusText(code) + `"}}`)) //nolint:errcheck
+}
+
+func (f *fakeGCS) writeObject(w http.ResponseWriter, name string) {
+	json.NewEncoder(w).Encode(storage.Object{Name: name, Bucket: "some-bucket", Generation: f.generations[name]}) //nolint:errcheck
+}
+
+func (f *fakeGCS) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	query := r.URL.Query()
+	name := strings.TrimPrefix(r.URL.Path, "/storage/v1/b/some-bucket/o/")
+
+	switch {
+	case r.Method == http.MethodPost && r.URL.Path == "/upload/storage/v1/b/some-bucket/o":
+		_, params, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		if err != nil {
+			f.fail(w, http.StatusBadRequest)
+			return
+		}
+		parts := multipart.NewReader(r.Body, params["boundary"])
+
+		metadata, err := parts.NextPart()
+		if err != nil {
+			f.fail(w, http.StatusBadRequest)
+			return
+		}
+		object := storage.Object{}
+		if err := json.NewDecoder(metadata).Decode(&object); err != nil {
+			f.fail(w, http.StatusBadRequest)
+			return
+		}
+
+		media, err := parts.NextPart()
+		if err != nil {
+			f.fail(w, http.StatusBadRequest)
+			return
+		}
+		contents, err := io.ReadAll(media)
+		if err != nil {
+			f.fail(w, http.StatusBadRequest)
+			return
+		}
+
+		if query.Has("ifGenerationMatch") {
+			f.ifGenerationMatch[object.Name] = query.Get("ifGenerationMatch")
+			if query.Get("ifGenerationMatch") != strconv.FormatInt(f.generations[object.Name], 10) {
+				f.fail(w, http.StatusPreconditionFailed)
+				return
+			}
+		}
+
+		f.put(object.Name, string(contents))
+		f.metadata[object.Name] = object.Metadata
+		f.writeObject(w, object.Name)
+	case r.Method == http.MethodGet && r.URL.Path == "/storage/v1/b/some-bucket/o":
+		names := []string{}
+		for objectName := range f.objects {
+			if strings.HasPrefix(objectName, query.Get("prefix")) {
+				names = append(names, objectName)
+			}
+		}
+		sort.Strings(names)
+
+		objects := storage.Objects{}
+		for _, objectName := range names {
+			objects.Items = append(objects.Items, &storage.Object{Name: objectName})
+		}
+		json.NewEncoder(w).Encode(objects) //nolint:errcheck
+	case r.Method == http.MethodDelete:
+		if _, ok := f.objects[name]; !ok {
+			f.fail(w, http.StatusNotFound)
+			return
+		}
+		delete(f.objects, name)
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == http.MethodGet:
+		object, ok := f.objects[name]
+		if !ok {
+			f.fail(w, http.StatusNotFound)
+			return
+		}
+
+		if query.Get("alt") == "media" {
+			if query.Get("generation") != strconv.FormatInt(f.generations[name], 10) {
+				f.fail(w, http.StatusNotFound)
+				return
+			}
+			w.Write([]byte(object)) //nolint:errcheck
+			return
+		}
+		f.writeObject(w, name)
+	default:
+		f.fail(w, http.StatusMethodNotAllowed)
+	}
+}
+
+var _ = Describe("Storage", func() {
+	var (
+		fake           *fakeGCS
+		server         *httptest.Server
+		storageService *storage.Service
+		filePath       string
+	)
+
+	BeforeEach(func() {
+		fake = &fakeGCS{objects: map[string]string{}, generations: map[string]int64{}, ifGenerationMatch: map[string]string{}, metadata: map[string]map[string]string{}}
+		server = httptest.NewServer(fake)
+
+		var err error
+		storageService, err = storage.New(http.DefaultClient) //nolint:staticcheck
+		Expect(err).NotTo(HaveOccurred())
+		storageService.BasePath = server.URL + "/storage/v1/"
+
+		file, err := os.CreateTemp("", "vars-store")
+		Expect(err).NotTo(HaveOccurred())
+		file.Close() //nolint:errcheck
+		filePath = file.Name()
+	})
+
+	AfterEach(func() {
+		server.Close()
+		os.Remove(filePath) //nolint:errcheck
+	})
+
+	Describe("Download", func() {
+		It("downloads the vars store and checks that it can be written", func() {
+			fake.put("path/to/vars-store.yml", "password: secret")
+			storage, err := gcp.NewStorageWithService(storageService, "some-bucket", "path/to/vars-store.yml", 0, "")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(storage.Download(filePath)).To(Succeed())
+
+			contents, err := os.ReadFile(filePath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(contents)).To(Equal("password: secret"))
+			Expect(fake.ifGenerationMatch["path/to/vars-store.yml"]).To(Equal("1"))
+			Expect(fake.generations["path/to/vars-store.yml"]).To(Equal(int64(2)))
+		})
+	})
+
+	Describe("DownloadReadOnly", func() {
+		It("leaves the file untouched when there is no vars store yet", func() {
+			Expect(os.WriteFile(filePath, []byte("untouched"), 0600)).To(Succeed())
+			storage, err := gcp.NewStorageWithService(storageService, "some-bucket", "path/to/vars-store.yml", 0, "")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(storage.DownloadReadOnly(filePath)).To(Succeed())
+
+			contents, err := os.ReadFile(filePath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(contents)).To(Equal("untouched"))
+			Expect(fake.objects).To(BeEmpty())
+		})
+	})
+
+	Describe("Upload", func() {
+		It("uploads the vars store unconditionally when it was not downloaded", func() {
+			fake.put("path/to/vars-store.yml", "password: secret")
+			Expect(os.WriteFile(filePath, []byte("password: new-secret"), 0600)).To(Succeed())
+			storage, err := gcp.NewStorageWithService(storageService, "some-bucket", "path/to/vars-store.yml", 0, "")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(storage.Upload(filePath)).To(Succeed())
+
+			Expect(fake.objects["path/to/vars-store.yml"]).To(Equal("password: new-secret"))
+			Expect(fake.ifGenerationMatch).NotTo(HaveKey("path/to/vars-store.yml"))
+		})
+	})
+
+	Describe("Upload after a download", func() {
+		var storage gcp.Storage
+
+		BeforeEach(func() {
+			var err error
+			storage, err = gcp.NewStorageWithService(storageService, "some-bucket", "path/to/vars-store.yml", 0, "")
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("uploads the vars store when it did not change", func() {
+			fake.put("path/to/vars-store.yml", "password: secret")
+			Expect(storage.Download(filePath)).To(Succeed())
+
+			Expect(os.WriteFile(filePath, []byte("password: secret\nother: new"), 0600)).To(Succeed())
+			Expect(storage.Upload(filePath)).To(Succeed())
+			Expect(fake.objects["path/to/vars-store.yml"]).To(Equal("password: secret\nother: new"))
+			Expect(fake.ifGenerationMatch["path/to/vars-store.yml"]).To(Equal("2"))
+		})
+
+		It("returns a conflict when the vars store changed since the download", func() {
+			fake.put("path/to/vars-store.yml", "password: secret")
+			Expect(storage.DownloadReadOnly(filePath)).To(Succeed())
+			fake.put("path/to/vars-store.yml", "password: changed")
+
+			err := storage.Upload(filePath)
+			Expect(errors.Is(err, provider.ErrConflict)).To(BeTrue())
+			Expect(fake.objects["path/to/vars-store.yml"]).To(Equal("password: changed"))
+		})
+
+		It("only creates the vars store when there was none at the download", func() {
+			Expect(storage.DownloadReadOnly(filePath)).To(Succeed())
+
+			Expect(os.WriteFile(filePath, []byte("password: created"), 0600)).To(Succeed())
+			Expect(storage.Upload(filePath)).To(Succeed())
+			Expect(fake.ifGenerationMatch["path/to/vars-store.yml"]).To(Equal("0"))
+		})
+
+		It("returns a conflict when the vars store was created since the download", func() {
+			Expect(storage.DownloadReadOnly(filePath)).To(Succeed())
+			fake.put("path/to/vars-store.yml", "password: created")
+
+			err := storage.Upload(filePath)
+			Expect(errors.Is(err, provider.ErrConflict)).To(BeTrue())
+			Expect(fake.objects["path/to/vars-store.yml"]).To(Equal("password: created"))
+		})
+	})
+
+	Describe("Revisions", func() {
+		var storage gcp.Storage
+
+		BeforeEach(func() {
+			var err error
+			storage, err = gcp.NewStorageWithService(storageService, "some-bucket", "path/to/vars-store.yml", 2, "main/deploy/deploy-cf/42")
+			Expect(err).NotTo(HaveOccurred())
+
+			fake.put("path/to/vars-store.yml.revision-20260101T000000Z", "password: oldest")
+			fake.put("path/to/vars-store.yml.revision-20260102T000000Z", "password: old")
+		})
+
+		It("keeps the uploads as revisions tagged with the build and deletes the oldest ones", func() {
+			Expect(os.WriteFile(filePath, []byte("password: new"), 0600)).To(Succeed())
+			Expect(storage.Upload(filePath)).To(Succeed())
+
+			revisions, err := storage.Revisions()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(revisions).To(HaveLen(2))
+			Expect(revisions[0]).To(Equal("20260102T000000Z"))
+			Expect(revisions[1]).To(MatchRegexp(`^\d{8}T\d{6}\.\d{9}Z$`))
+
+			newest := "path/to/vars-store.yml.revision-" + revisions[1]
+			Expect(fake.objects[newest]).To(Equal("password: new"))
+			Expect(fake.metadata[newest]).To(Equal(map[string]string{"concourse-build": "main/deploy/deploy-cf/42"}))
+			Expect(fake.objects).NotTo(HaveKey("path/to/vars-store.yml.revision-20260101T000000Z"))
+		})
+
+		It("does not keep a revision when checking that the vars store can be written", func() {
+			fake.put("path/to/vars-store.yml", "password: secret")
+			Expect(storage.Download(filePath)).To(Succeed())
+
+			Expect(storage.Revisions()).To(Equal([]string{"20260101T000000Z", "20260102T000000Z"}))
+		})
+
+		It("downloads a revision", func() {
+			Expect(storage.DownloadRevision("20260101T000000Z", filePath)).To(Succeed())
+
+			contents, err := os.ReadFile(filePath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(contents)).To(Equal("password: oldest"))
+
+			Expect(storage.DownloadRevision("20250101T000000Z", filePath)).To(MatchError(ContainSubstring("Can not read path/to/vars-store.yml.revision-20250101T000000Z in bucket some-bucket")))
+		})
+
+		It("does not keep revisions by default", func() {
+			storage, err := gcp.NewStorageWithService(storageService, "some-bucket", "path/to/vars-store.yml", 0, "")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(os.WriteFile(filePath, []byte("password: new"), 0600)).To(Succeed())
+			Expect(storage.Upload(filePath)).To(Succeed())
+			Expect(fake.objects).To(HaveLen(3))
+		})
+	})
+
+	Describe("NewStorageWithService", func() {
+		It("rejects a negative number of revisions", func() {
+			_, err := gcp.NewStorageWithService(storageService, "some-bucket", "path/to/vars-store.yml", -1, "")
+			Expect(err).To(MatchError("revisions must not be negative got: -1"))
+		})
+	})
+})
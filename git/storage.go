@@ -0,0 +1,486 @@
+package git
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/cloudfoundry/bosh-deployment-resource/storage/provider"
+)
+
+const (
+	// pushAttempts is how often a push that was rejected because the branch
+	// moved on is rebased and tried again.
+	pushAttempts = 5
+
+	defaultAuthorName  = "bosh-deployment-resource"
+	defaultAuthorEmail = "bosh-deployment-resource@localhost"
+
+	// credentialHelper answers the credential requests of git for HTTPS
+	// from the environment, so the password is never part of a command line.
+	credentialHelper = `!f() { echo "username=${BOSH_DEPLOYMENT_RESOURCE_GIT_USERNAME}"; echo "password=${BOSH_DEPLOYMENT_RESOURCE_GIT_PASSWORD}"; }; f`
+)
+
+var commitPattern = regexp.MustCompile(`^[0-9a-f]{4,40}$`)
+
+type Config struct {
+	// URI is the repository to clone, over SSH or HTTPS, and Branch the
+	// branch of File, the vars store in the repository.
+	URI    string
+	Branch string
+	File   string
+
+	// PrivateKey authenticates SSH, with KnownHosts checking the host key.
+	// InsecureIgnoreHostKey skips that check instead. Username and Password
+	// authenticate HTTPS.
+	PrivateKey            string
+	KnownHosts            string
+	InsecureIgnoreHostKey bool
+	Username              string
+	Password              string
+
+	AuthorName  string
+	AuthorEmail string
+
+	// Build is named in the commit messages.
+	Build string
+}
+
+type Storage struct {
+	uri        string
+	branch     string
+	file       string
+	build      string
+	configArgs []string
+	env        []string
+	ssh        *sshConfig
+	repository *repository
+}
+
+// sshConfig is written to files for each git command, which are removed
+// again when it finished.
+type sshConfig struct {
+	privateKey string
+	knownHosts string
+}
+
+// repository is the clone of the branch the vars store is read from and
+// committed to.
+type repository struct {
+	dir string
+	// base is the commit the vars store was downloaded at last, which the
+	// next upload of the vars store is conditional on. It is empty while the
+	// branch does not exist.
+	base string
+}
+
+func NewStorage(config Config) (Storage, error) {
+	if config.URI == "" || config.Branch == "" || config.File == "" {
+		return Storage{}, errors.New("uri, branch and file are required")
+	}
+
+	authorName, authorEmail := config.AuthorName, config.AuthorEmail
+	if authorName == "" {
+		authorName = defaultAuthorName
+	}
+	if authorEmail == "" {
+		authorEmail = defaultAuthorEmail
+	}
+
+	configArgs := []string{
+		"-c", "user.name=" + authorName,
+		"-c", "user.email=" + authorEmail,
+		"-c", "commit.gpgsign=false",
+		// Only the configured credentials are used.
+		"-c", "credential.helper=",
+	}
+	env := []string{"GIT_TERMINAL_PROMPT=0"}
+
+	var ssh *sshConfig
+	if config.PrivateKey != "" {
+		if config.KnownHosts == "" && !config.InsecureIgnoreHostKey {
+			return Storage{}, errors.New("known_hosts is required with private_key, or set insecure_ignore_host_key to not check the host key")
+		}
+		ssh = &sshConfig{privateKey: config.PrivateKey}
+		if !config.InsecureIgnoreHostKey {
+			ssh.knownHosts = config.KnownHosts
+		}
+	}
+
+	if config.Username != "" || config.Password != "" {
+		configArgs = append(configArgs, "-c", "credential.helper="+credentialHelper)
+		env = append(env,
+			"BOSH_DEPLOYMENT_RESOURCE_GIT_USERNAME="+config.Username,
+			"BOSH_DEPLOYMENT_RESOURCE_GIT_PASSWORD="+config.Password,
+		)
+	}
+
+	return Storage{
+		uri:        config.URI,
+		branch:     config.Branch,
+		file:       filepath.Clean(config.File),
+		build:      config.Build,
+		configArgs: configArgs,
+		env:        env,
+		ssh:        ssh,
+		repository: &repository{},
+	}, nil
+}
+
+// command writes the private key, and the known hosts unless the host key is
+// not checked, to a directory and returns the ssh command that uses them. The
+// directory is removed by cleanup.
+func (c sshConfig) command() (string, func(), error) {
+	dir, err := os.MkdirTemp("", "git-ssh")
+	if err != nil {
+		return "", nil, err
+	}
+	cleanup := func() { os.RemoveAll(dir) } //nolint:errcheck
+
+	keyFile := filepath.Join(dir, "key")
+	if err := writeFile(keyFile, c.privateKey); err != nil {
+		cleanup()
+		return "", nil, err
+	}
+
+	hostKeyChecking := "-o StrictHostKeyChecking=no -o UserKnownHostsFile=/dev/null"
+	if c.knownHosts != "" {
+		knownHostsFile := filepath.Join(dir, "known_hosts")
+		if err := writeFile(knownHostsFile, c.knownHosts); err != nil {
+			cleanup()
+			return "", nil, err
+		}
+		hostKeyChecking = fmt.Sprintf("-o StrictHostKeyChecking=yes -o UserKnownHostsFile=%s", knownHostsFile)
+	}
+
+	return fmt.Sprintf("ssh -i %s -o IdentitiesOnly=yes %s", keyFile, hostKeyChecking), cleanup, nil
+}
+
+func (s Storage) Download(filePath string) error {
+	if err := s.DownloadReadOnly(filePath); err != nil {
+		return err
+	}
+
+	if s.repository.base == "" {
+		return nil
+	}
+
+	// Check that we can not only read the file, but can also write it
+	if _, err := s.git("push", "--dry-run", "origin", "HEAD:refs/heads/"+s.branch); err != nil {
+		return fmt.Errorf("Can not write to branch %s of %s: %s", s.branch, s.uri, err) //nolint:staticcheck
+	}
+
+	return nil
+}
+
+// DownloadReadOnly fetches the vars store without verifying that it can be
+// written, and leaves filePath untouched if the vars store does not exist yet.
+func (s Storage) DownloadReadOnly(filePath string) error {
+	if err := s.sync(); err != nil {
+		return err
+	}
+
+	contents, err := os.ReadFile(filepath.Join(s.repository.dir, s.file))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	return os.WriteFile(filePath, contents, 0600)
+}
+
+// Upload commits the vars store and pushes it, unless it was changed since
+// it was last downloaded, in which case it returns provider.ErrConflict.
+// When the branch moved on with other changes, the commit is rebased and
+// pushed again.
+func (s Storage) Upload(filePath string) error {
+	return s.commitAndPush(s.file, filePath, true)
+}
+
+func (s Storage) DownloadObject(suffix, filePath string) error {
+	if err := s.clone(); err != nil {
+		return err
+	}
+
+	contents, err := os.ReadFile(filepath.Join(s.repository.dir, s.file+suffix))
+	if err != nil {
+		return fmt.Errorf("Can not read %s in branch %s of %s: %s", s.file+suffix, s.branch, s.uri, err) //nolint:staticcheck
+	}
+
+	return os.WriteFile(filePath, contents, 0600)
+}
+
+func (s Storage) UploadObject(suffix, filePath string) error {
+	return s.commitAndPush(s.file+suffix, filePath, false)
+}
+
+// Revisions returns the commits that changed the vars store, oldest first.
+func (s Storage) Revisions() ([]string, error) {
+	if err := s.clone(); err != nil {
+		return nil, err
+	}
+
+	if s.repository.base == "" {
+		return []string{}, nil
+	}
+
+	output, err := s.git("log", "--reverse", "--format=%H", "HEAD", "--", s.file)
+	if err != nil {
+		return nil, fmt.Errorf("Can not list the revisions of %s in branch %s of %s: %s", s.file, s.branch, s.uri, err) //nolint:staticcheck
+	}
+
+	return strings.Fields(output), nil
+}
+
+// DownloadRevision reads the vars store as it was committed in the commit.
+func (s Storage) DownloadRevision(id, filePath string) error {
+	if !commitPattern.MatchString(id) {
+		return fmt.Errorf("revision %s is not a commit", id)
+	}
+
+	if err := s.clone(); err != nil {
+		return err
+	}
+
+	contents, err := s.git("show", id+":"+filepath.ToSlash(s.file))
+	if err != nil {
+		return fmt.Errorf("Can not read %s at %s in %s: %s", s.file, id, s.uri, err) //nolint:staticcheck
+	}
+
+	return os.WriteFile(filePath, []byte(contents), 0600)
+}
+
+// Close removes the clone of the branch.
+func (s Storage) Close() error {
+	if s.repository.dir == "" {
+		return nil
+	}
+
+	err := os.RemoveAll(s.repository.dir)
+	s.repository.dir = ""
+	return err
+}
+
+// clone makes sure the branch was cloned, without updating an existing
+// clone.
+func (s Storage) clone() error {
+	if s.repository.dir != "" {
+		return nil
+	}
+
+	return s.sync()
+}
+
+// sync clones the branch, or updates the clone to the latest commit of the
+// branch, and makes that commit the base of the next upload.
+func (s Storage) sync() error {
+	heads, err := s.git("ls-remote", "--heads", s.uri, "refs/heads/"+s.branch)
+	if err != nil {
+		return fmt.Errorf("Can not read branch %s of %s: %s", s.branch, s.uri, err) //nolint:staticcheck
+	}
+
+	if strings.TrimSpace(heads) == "" {
+		// The branch is created by the first upload, on top of no commits.
+		if err := s.init(); err != nil {
+			return err
+		}
+		if _, err := s.git("checkout", "--quiet", "--orphan", s.branch); err != nil {
+			return err
+		}
+		s.repository.base = ""
+		return nil
+	}
+
+	if s.repository.dir == "" {
+		if err := s.init(); err != nil {
+			return err
+		}
+	}
+
+	if err := s.fetch(); err != nil {
+		return err
+	}
+	if _, err := s.git("checkout", "--quiet", "-B", s.branch, "origin/"+s.branch); err != nil {
+		return err
+	}
+	if _, err := s.git("reset", "--quiet", "--hard", "origin/"+s.branch); err != nil {
+		return err
+	}
+
+	base, err := s.git("rev-parse", "HEAD")
+	if err != nil {
+		return err
+	}
+	s.repository.base = strings.TrimSpace(base)
+	return nil
+}
+
+// init creates an empty clone, replacing the previous one.
+func (s Storage) init() error {
+	if s.repository.dir != "" {
+		if err := os.RemoveAll(s.repository.dir); err != nil {
+			return err
+		}
+	}
+
+	dir, err := os.MkdirTemp("", "vars-store-repository")
+	if err != nil {
+		return err
+	}
+	s.repository.dir = dir
+
+	if _, err := s.git("init", "--quiet"); err != nil {
+		return err
+	}
+	_, err = s.git("remote", "add", "origin", s.uri)
+	return err
+}
+
+// commitAndPush commits the file to path in the branch and pushes it. When
+// conditional, it fails with provider.ErrConflict if the branch changed path
+// since it was last downloaded.
+func (s Storage) commitAndPush(path, filePath string, conditional bool) error {
+	if err := s.clone(); err != nil {
+		return err
+	}
+
+	contents, err := os.ReadFile(filePath)
+	if err != nil {
+		return err
+	}
+
+	repositoryPath := filepath.Join(s.repository.dir, path)
+	if err := os.MkdirAll(filepath.Dir(repositoryPath), 0700); err != nil {
+		return err
+	}
+	if err := os.WriteFile(repositoryPath, contents, 0600); err != nil {
+		return err
+	}
+
+	if _, err := s.git("add", "--", path); err != nil {
+		return err
+	}
+
+	if _, err := s.git("diff", "--cached", "--quiet"); err != nil {
+		if _, err := s.git("commit", "--quiet", "-m", s.commitMessage(path)); err != nil {
+			return err
+		}
+	} else if s.repository.base != "" {
+		if ahead, err := s.git("rev-list", "--count", "origin/"+s.branch+"..HEAD"); err == nil && strings.TrimSpace(ahead) == "0" {
+			return nil
+		}
+	}
+
+	for attempt := 1; ; attempt++ {
+		_, err := s.git("push", "--quiet", "origin", "HEAD:refs/heads/"+s.branch)
+		if err == nil {
+			head, err := s.git("rev-parse", "HEAD")
+			if err != nil {
+				return err
+			}
+			s.repository.base = strings.TrimSpace(head)
+			return s.fetch()
+		}
+		if !isRejected(err) || attempt == pushAttempts {
+			return fmt.Errorf("Can not push %s to branch %s of %s: %s", path, s.branch, s.uri, err) //nolint:staticcheck
+		}
+
+		if err := s.fetch(); err != nil {
+			return err
+		}
+
+		if conditional && s.blob(s.repository.base, path) != s.blob("origin/"+s.branch, path) {
+			return fmt.Errorf("Can not push %s to branch %s of %s: %w", path, s.branch, s.uri, provider.ErrConflict) //nolint:staticcheck
+		}
+
+		if _, err := s.git("rebase", "--quiet", "origin/"+s.branch); err != nil {
+			s.git("rebase", "--abort")                                                                  //nolint:errcheck
+			return fmt.Errorf("Can not rebase %s onto branch %s of %s: %s", path, s.branch, s.uri, err) //nolint:staticcheck
+		}
+		if conditional {
+			base, err := s.git("rev-parse", "origin/"+s.branch)
+			if err != nil {
+				return err
+			}
+			s.repository.base = strings.TrimSpace(base)
+		}
+	}
+}
+
+func (s Storage) commitMessage(path string) string {
+	message := fmt.Sprintf("Update %s", filepath.ToSlash(path))
+	if s.build != "" {
+		message += fmt.Sprintf("\n\nWritten by Concourse build %s", s.build)
+	}
+	return message
+}
+
+func (s Storage) fetch() error {
+	if _, err := s.git("fetch", "--quiet", "origin", "+refs/heads/"+s.branch+":refs/remotes/origin/"+s.branch); err != nil {
+		return fmt.Errorf("Can not fetch branch %s of %s: %s", s.branch, s.uri, err) //nolint:staticcheck
+	}
+	return nil
+}
+
+// blob returns the object of path at the commit, or an empty string when path
+// does not exist there.
+func (s Storage) blob(commit, path string) string {
+	if commit == "" {
+		return ""
+	}
+
+	object, err := s.git("rev-parse", "--verify", "--quiet", commit+":"+filepath.ToSlash(path))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(object)
+}
+
+// git runs git in the clone and returns its output.
+func (s Storage) git(args ...string) (string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command("git", append(append([]string{}, s.configArgs...), args...)...)
+	cmd.Dir = s.repository.dir
+	cmd.Env = append(os.Environ(), s.env...)
+
+	if s.ssh != nil {
+		sshCommand, cleanup, err := s.ssh.command()
+		if err != nil {
+			return "", err
+		}
+		defer cleanup()
+		cmd.Env = append(cmd.Env, "GIT_SSH_COMMAND="+sshCommand)
+	}
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		message := strings.TrimSpace(stderr.String())
+		if message == "" {
+			message = err.Error()
+		}
+		return stdout.String(), errors.New(message)
+	}
+
+	return stdout.String(), nil
+}
+
+// isRejected reports whether a push was rejected because the branch has
+// commits that are not in the pushed commit.
+func isRejected(err error) bool {
+	return strings.Contains(err.Error(), "non-fast-forward") || strings.Contains(err.Error(), "fetch first")
+}
+
+func writeFile(path, contents string) error {
+	if !strings.HasSuffix(contents, "\n") {
+		contents += "\n"
+	}
+	return os.WriteFile(path, []byte(contents), 0600)
+}
@@ -0,0 +1,316 @@
+package git_test
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry/bosh-deployment-resource/git"
+	"github.com/cloudfoundry/bosh-deployment-resource/storage/provider"
+)
+
+var _ = Describe("Storage", func() {
+	var (
+		dir      string
+		remote   string
+		other    string
+		config   git.Config
+		filePath string
+	)
+
+	run := func(dir string, args ...string) string {
+		cmd := exec.Command("git", append([]string{"-c", "user.name=other", "-c", "user.email=other@example.com"}, args...)...)
+		cmd.Dir = dir
+		output, err := cmd.CombinedOutput()
+		Expect(err).NotTo(HaveOccurred(), string(output))
+		return strings.TrimSpace(string(output))
+	}
+
+	// commit pushes a change to the file from another clone of the remote.
+	commit := func(file, contents string) {
+		run(other, "pull", "--quiet", "origin", "main")
+		Expect(os.MkdirAll(filepath.Dir(filepath.Join(other, file)), 0700)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(other, file), []byte(contents), 0600)).To(Succeed())
+		run(other, "add", file)
+		run(other, "commit", "--quiet", "-m", "Change "+file)
+		run(other, "push", "--quiet", "origin", "HEAD:refs/heads/main")
+	}
+
+	readRemote := func(file string) string {
+		return run(remote, "show", "main:"+file)
+	}
+
+	BeforeEach(func() {
+		var err error
+		dir, err = os.MkdirTemp("", "git-storage")
+		Expect(err).NotTo(HaveOccurred())
+
+		remote = filepath.Join(dir, "remote.git")
+		other = filepath.Join(dir, "other")
+		run(dir, "init", "--quiet", "--bare", remote)
+		run(dir, "init", "--quiet", other)
+		run(other, "checkout", "--quiet", "-b", "main")
+		run(other, "remote", "add", "origin", remote)
+		Expect(os.WriteFile(filepath.Join(other, "README.md"), []byte("vars stores"), 0600)).To(Succeed())
+		run(other, "add", "README.md")
+		run(other, "commit", "--quiet", "-m", "Initial commit")
+		run(other, "push", "--quiet", "origin", "HEAD:refs/heads/main")
+
+		config = git.Config{
+			URI:    remote,
+			Branch: "main",
+			File:   "path/to/vars-store.yml",
+			Build:  "main/deploy/deploy-cf/42",
+		}
+
+		file, err := os.CreateTemp("", "vars-store")
+		Expect(err).NotTo(HaveOccurred())
+		file.Close() //nolint:errcheck
+		filePath = file.Name()
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)   //nolint:errcheck
+		os.Remove(filePath) //nolint:errcheck
+	})
+
+	Describe("Download", func() {
+		It("downloads the vars store", func() {
+			commit("path/to/vars-store.yml", "password: secret")
+			storage, err := git.NewStorage(config)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(storage.Download(filePath)).To(Succeed())
+
+			contents, err := os.ReadFile(filePath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(contents)).To(Equal("password: secret"))
+		})
+
+		It("leaves the file untouched when there is no vars store yet", func() {
+			Expect(os.WriteFile(filePath, []byte("untouched"), 0600)).To(Succeed())
+			storage, err := git.NewStorage(config)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(storage.Download(filePath)).To(Succeed())
+
+			contents, err := os.ReadFile(filePath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(contents)).To(Equal("untouched"))
+		})
+
+		It("returns an error when the repository can not be read", func() {
+			config.URI = filepath.Join(remote, "missing")
+			storage, err := git.NewStorage(config)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(storage.Download(filePath)).To(MatchError(ContainSubstring("Can not read branch main of " + config.URI)))
+		})
+
+		Context("over SSH", func() {
+			var originalPath string
+
+			BeforeEach(func() {
+				// The fake ssh records how it was called and what the key
+				// file contained, and fails the connection.
+				bin := filepath.Join(dir, "bin")
+				Expect(os.MkdirAll(bin, 0700)).To(Succeed())
+				script := "#!/bin/sh\necho \"$@\" > " + filepath.Join(dir, "ssh-args") + "\ncp \"$2\" " + filepath.Join(dir, "ssh-key") + "\nexit 1\n"
+				Expect(os.WriteFile(filepath.Join(bin, "ssh"), []byte(script), 0700)).To(Succeed())
+
+				originalPath = os.Getenv("PATH")
+				os.Setenv("PATH", bin+string(os.PathListSeparator)+originalPath) //nolint:errcheck
+
+				config.URI = "ssh://git@example.com/vars-stores.git"
+				config.PrivateKey = "some-private-key"
+				config.KnownHosts = "example.com ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIEXAMPLE"
+			})
+
+			AfterEach(func() {
+				os.Setenv("PATH", originalPath) //nolint:errcheck
+			})
+
+			It("checks the host key and removes the key files afterwards", func() {
+				storage, err := git.NewStorage(config)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(storage.Download(filePath)).NotTo(Succeed())
+
+				args, err := os.ReadFile(filepath.Join(dir, "ssh-args"))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(args)).To(ContainSubstring("-o StrictHostKeyChecking=yes"))
+
+				key, err := os.ReadFile(filepath.Join(dir, "ssh-key"))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(key)).To(Equal("some-private-key\n"))
+
+				keyFile := strings.Fields(string(args))[1]
+				Expect(keyFile).NotTo(BeAnExistingFile())
+				Expect(filepath.Dir(keyFile)).NotTo(BeAnExistingFile())
+			})
+
+			It("skips the host key check only when asked to", func() {
+				config.KnownHosts = ""
+				_, err := git.NewStorage(config)
+				Expect(err).To(MatchError("known_hosts is required with private_key, or set insecure_ignore_host_key to not check the host key"))
+
+				config.InsecureIgnoreHostKey = true
+				storage, err := git.NewStorage(config)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(storage.Download(filePath)).NotTo(Succeed())
+
+				args, err := os.ReadFile(filepath.Join(dir, "ssh-args"))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(args)).To(ContainSubstring("-o StrictHostKeyChecking=no -o UserKnownHostsFile=/dev/null"))
+			})
+		})
+	})
+
+	Describe("Upload", func() {
+		var storage git.Storage
+
+		BeforeEach(func() {
+			var err error
+			storage, err = git.NewStorage(config)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("commits the vars store with a generated message and pushes it", func() {
+			Expect(storage.Download(filePath)).To(Succeed())
+
+			Expect(os.WriteFile(filePath, []byte("password: new"), 0600)).To(Succeed())
+			Expect(storage.Upload(filePath)).To(Succeed())
+
+			Expect(readRemote("path/to/vars-store.yml")).To(Equal("password: new"))
+			Expect(run(remote, "log", "-1", "--format=%an%n%B", "main")).To(Equal(
+				"bosh-deployment-resource\nUpdate path/to/vars-store.yml\n\nWritten by Concourse build main/deploy/deploy-cf/42"))
+		})
+
+		It("does not commit when the vars store did not change", func() {
+			commit("path/to/vars-store.yml", "password: secret")
+			Expect(storage.Download(filePath)).To(Succeed())
+			head := run(remote, "rev-parse", "main")
+
+			Expect(storage.Upload(filePath)).To(Succeed())
+			Expect(run(remote, "rev-parse", "main")).To(Equal(head))
+		})
+
+		It("creates the branch when it does not exist yet", func() {
+			config.Branch = "vars-stores"
+			storage, err := git.NewStorage(config)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(storage.Download(filePath)).To(Succeed())
+			Expect(os.WriteFile(filePath, []byte("password: new"), 0600)).To(Succeed())
+			Expect(storage.Upload(filePath)).To(Succeed())
+
+			Expect(run(remote, "show", "vars-stores:path/to/vars-store.yml")).To(Equal("password: new"))
+		})
+
+		It("rebases onto other changes of the branch and pushes again", func() {
+			commit("path/to/vars-store.yml", "password: secret")
+			Expect(storage.Download(filePath)).To(Succeed())
+			commit("other-deployment/vars-store.yml", "password: other")
+
+			Expect(os.WriteFile(filePath, []byte("password: secret\nnew: value"), 0600)).To(Succeed())
+			Expect(storage.Upload(filePath)).To(Succeed())
+
+			Expect(readRemote("path/to/vars-store.yml")).To(Equal("password: secret\nnew: value"))
+			Expect(readRemote("other-deployment/vars-store.yml")).To(Equal("password: other"))
+		})
+
+		It("returns a conflict when the vars store changed since the download", func() {
+			commit("path/to/vars-store.yml", "password: secret")
+			Expect(storage.DownloadReadOnly(filePath)).To(Succeed())
+			commit("path/to/vars-store.yml", "password: changed")
+
+			Expect(os.WriteFile(filePath, []byte("password: ours"), 0600)).To(Succeed())
+			err := storage.Upload(filePath)
+			Expect(errors.Is(err, provider.ErrConflict)).To(BeTrue())
+			Expect(readRemote("path/to/vars-store.yml")).To(Equal("password: changed"))
+
+			Expect(storage.DownloadReadOnly(filePath)).To(Succeed())
+			contents, err := os.ReadFile(filePath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(contents)).To(Equal("password: changed"))
+
+			Expect(os.WriteFile(filePath, []byte("password: merged"), 0600)).To(Succeed())
+			Expect(storage.Upload(filePath)).To(Succeed())
+			Expect(readRemote("path/to/vars-store.yml")).To(Equal("password: merged"))
+		})
+	})
+
+	Describe("UploadObject and DownloadObject", func() {
+		It("stores the object next to the vars store", func() {
+			Expect(os.WriteFile(filePath, []byte("plan"), 0600)).To(Succeed())
+			storage, err := git.NewStorage(config)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(storage.UploadObject(".plan", filePath)).To(Succeed())
+			Expect(readRemote("path/to/vars-store.yml.plan")).To(Equal("plan"))
+
+			Expect(storage.DownloadObject(".plan", filePath)).To(Succeed())
+			Expect(storage.DownloadObject(".missing", filePath)).To(MatchError(ContainSubstring("Can not read path/to/vars-store.yml.missing in branch main of " + remote)))
+		})
+	})
+
+	Describe("Revisions", func() {
+		It("lists the commits that changed the vars store and downloads them", func() {
+			commit("path/to/vars-store.yml", "password: old")
+			first := run(other, "rev-parse", "HEAD")
+			commit("other-deployment/vars-store.yml", "password: other")
+			commit("path/to/vars-store.yml", "password: new")
+			second := run(other, "rev-parse", "HEAD")
+
+			storage, err := git.NewStorage(config)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(storage.Download(filePath)).To(Succeed())
+
+			Expect(storage.Revisions()).To(Equal([]string{first, second}))
+
+			Expect(storage.DownloadRevision(first, filePath)).To(Succeed())
+			contents, err := os.ReadFile(filePath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(contents)).To(Equal("password: old"))
+
+			Expect(storage.DownloadRevision("--output=/tmp/x", filePath)).To(MatchError("revision --output=/tmp/x is not a commit"))
+		})
+	})
+
+	Describe("Close", func() {
+		It("removes the clone of the branch", func() {
+			tempDir := filepath.Join(dir, "tmp")
+			Expect(os.Mkdir(tempDir, 0700)).To(Succeed())
+			previousTempDir, hadTempDir := os.LookupEnv("TMPDIR")
+			os.Setenv("TMPDIR", tempDir) //nolint:errcheck
+			defer func() {
+				if hadTempDir {
+					os.Setenv("TMPDIR", previousTempDir) //nolint:errcheck
+				} else {
+					os.Unsetenv("TMPDIR") //nolint:errcheck
+				}
+			}()
+			commit("path/to/vars-store.yml", "password: secret")
+
+			storage, err := git.NewStorage(config)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(storage.Download(filePath)).To(Succeed())
+			Expect(filepath.Glob(filepath.Join(tempDir, "vars-store-repository*"))).To(HaveLen(1))
+
+			Expect(storage.Close()).To(Succeed())
+			Expect(filepath.Glob(filepath.Join(tempDir, "vars-store-repository*"))).To(BeEmpty())
+		})
+	})
+
+	Describe("NewStorage", func() {
+		It("requires the uri, branch and file", func() {
+			config.Branch = ""
+			_, err := git.NewStorage(config)
+			Expect(err).To(MatchError("uri, branch and file are required"))
+		})
+	})
+})
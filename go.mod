@@ -3,14 +3,19 @@ module github.com/cloudfoundry/bosh-deployment-resource
 go 1.22.0
 
 require (
+	c2sp.org/CCTV/age v0.0.0-20240306222714-3ec4d716e805
+	filippo.io/age v1.2.1
+	github.com/aws/aws-sdk-go v1.55.5
 	github.com/cloudfoundry/bosh-cli/v7 v7.8.6-0.20241217212510-350fe96576a6
 	github.com/cloudfoundry/bosh-utils v0.0.515
+	github.com/cloudfoundry/config-server v0.1.224
 	github.com/cloudfoundry/socks5-proxy v0.2.135
 	github.com/cppforlife/go-patch v0.2.0
 	github.com/cppforlife/go-semi-semantic v0.0.0-20160921010311-576b6af77ae4
 	github.com/jessevdk/go-flags v1.6.1
 	github.com/onsi/ginkgo v1.16.5
 	github.com/onsi/gomega v1.36.1
+	golang.org/x/crypto v0.31.0
 	golang.org/x/oauth2 v0.24.0
 	google.golang.org/api v0.210.0
 	gopkg.in/yaml.v2 v2.4.0
@@ -32,7 +37,6 @@ require (
 	github.com/GoogleCloudPlatform/opentelemetry-operations-go/exporter/metric v0.49.0 // indirect
 	github.com/GoogleCloudPlatform/opentelemetry-operations-go/internal/resourcemapping v0.49.0 // indirect
 	github.com/VividCortex/ewma v1.2.0 // indirect
-	github.com/aws/aws-sdk-go v1.55.5 // indirect
 	github.com/bmatcuk/doublestar v1.3.4 // indirect
 	github.com/census-instrumentation/opencensus-proto v0.4.1 // indirect
 	github.com/cespare/xxhash/v2 v2.3.0 // indirect
@@ -42,7 +46,6 @@ require (
 	github.com/cloudfoundry/bosh-davcli v0.0.385 // indirect
 	github.com/cloudfoundry/bosh-gcscli v0.0.265 // indirect
 	github.com/cloudfoundry/bosh-s3cli v0.0.336 // indirect
-	github.com/cloudfoundry/config-server v0.1.224 // indirect
 	github.com/cloudfoundry/go-socks5 v0.0.0-20240831012420-2590b55236ee // indirect
 	github.com/cncf/xds/go v0.0.0-20240905190251-b4127c9b8d78 // indirect
 	github.com/dustin/go-humanize v1.0.1 // indirect
@@ -82,7 +85,6 @@ require (
 	go.opentelemetry.io/otel/sdk v1.32.0 // indirect
 	go.opentelemetry.io/otel/sdk/metric v1.32.0 // indirect
 	go.opentelemetry.io/otel/trace v1.32.0 // indirect
-	golang.org/x/crypto v0.31.0 // indirect
 	golang.org/x/net v0.32.0 // indirect
 	golang.org/x/sync v0.10.0 // indirect
 	golang.org/x/sys v0.28.0 // indirect
@@ -0,0 +1,202 @@
+package out
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/cloudfoundry/bosh-deployment-resource/concourse"
+)
+
+type expiringCertificate struct {
+	name     string
+	expiry   string
+	daysLeft int
+}
+
+func (e expiringCertificate) String() string {
+	if e.daysLeft < 0 {
+		return fmt.Sprintf("%s expired on %s", e.name, e.expiry)
+	}
+	return fmt.Sprintf("%s expires in %d days on %s", e.name, e.daysLeft, e.expiry)
+}
+
+// checkCertificateExpiry reports the certificates of the vars store and of
+// the director that expire within the configured days. With rotate, the
+// expiring certificates of the vars store and the certificates they signed
+// are removed from it, so the deploy generates them again. With fail, any
+// other expiring certificate fails the put.
+func (c OutCommand) checkCertificateExpiry(params concourse.CertificateExpiryParams, varsStorePath string, dryRun bool) ([]concourse.Metadata, error) {
+	vars := map[string]interface{}{}
+	if varsStorePath != "" {
+		contents, err := os.ReadFile(varsStorePath)
+		if err != nil {
+			return nil, err
+		}
+		vars, err = parseVarsStore(contents)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	varsStoreCertificates, err := expiringVarsStoreCertificates(vars, params.Days)
+	if err != nil {
+		return nil, err
+	}
+
+	directorCertificates, err := c.expiringDirectorCertificates(params.Days)
+	if err != nil {
+		return nil, err
+	}
+
+	metadata := []concourse.Metadata{}
+	for _, certificate := range append(varsStoreCertificates, directorCertificates...) {
+		metadata = append(metadata, concourse.Metadata{Name: "expiring_certificate", Value: certificate.String()})
+	}
+
+	unrotated := directorCertificates
+	if params.Rotate && len(varsStoreCertificates) > 0 {
+		names := []string{}
+		for _, certificate := range varsStoreCertificates {
+			names = append(names, certificate.name)
+		}
+
+		rotated := withSignedCertificates(vars, names)
+		for _, name := range rotated {
+			metadata = append(metadata, concourse.Metadata{Name: "rotated_certificate", Value: name})
+			delete(vars, name)
+		}
+
+		if !dryRun {
+			varsStoreBytes, err := yaml.Marshal(vars)
+			if err != nil {
+				return nil, err
+			}
+			if err := os.WriteFile(varsStorePath, varsStoreBytes, 0600); err != nil {
+				return nil, err
+			}
+		}
+	} else {
+		unrotated = append(varsStoreCertificates, unrotated...)
+	}
+
+	if params.Fail && len(unrotated) > 0 {
+		descriptions := []string{}
+		for _, certificate := range unrotated {
+			descriptions = append(descriptions, certificate.String())
+		}
+		return nil, fmt.Errorf("certificates expire within %d days:\n%s", params.Days, strings.Join(descriptions, "\n"))
+	}
+
+	return metadata, nil
+}
+
+func (c OutCommand) expiringDirectorCertificates(days int) ([]expiringCertificate, error) {
+	certificates, err := c.director.CertificateExpiry()
+	if err != nil {
+		return nil, err
+	}
+
+	expiring := []expiringCertificate{}
+	for _, certificate := range certificates {
+		if certificate.DaysLeft < days {
+			expiring = append(expiring, expiringCertificate{
+				name:     "director " + certificate.Path,
+				expiry:   certificate.Expiry,
+				daysLeft: certificate.DaysLeft,
+			})
+		}
+	}
+
+	return expiring, nil
+}
+
+// expiringVarsStoreCertificates returns the certificate variables of the vars
+// store that expire within the days, sorted by name.
+func expiringVarsStoreCertificates(vars map[string]interface{}, days int) ([]expiringCertificate, error) {
+	names := []string{}
+	for name := range vars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	expiring := []expiringCertificate{}
+	for _, name := range names {
+		certificatePEM := certificateField(vars[name], "certificate")
+		if certificatePEM == "" {
+			continue
+		}
+
+		block, _ := pem.Decode([]byte(certificatePEM))
+		if block == nil {
+			return nil, fmt.Errorf("could not parse certificate %s of the vars store", name)
+		}
+		certificate, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse certificate %s of the vars store: %s", name, err)
+		}
+
+		// Rounded down, so a certificate that expired hours ago is reported as
+		// expired and not as expiring in 0 days.
+		daysLeft := int(math.Floor(time.Until(certificate.NotAfter).Hours() / 24))
+		if daysLeft < days {
+			expiring = append(expiring, expiringCertificate{
+				name:     name,
+				expiry:   certificate.NotAfter.UTC().Format(time.RFC3339),
+				daysLeft: daysLeft,
+			})
+		}
+	}
+
+	return expiring, nil
+}
+
+// withSignedCertificates adds the certificates signed by the named
+// certificates to them, recursively.
+func withSignedCertificates(vars map[string]interface{}, names []string) []string {
+	included := map[string]bool{}
+	for _, name := range names {
+		included[name] = true
+	}
+
+	for added := true; added; {
+		added = false
+		for name, value := range vars {
+			if included[name] {
+				continue
+			}
+			ca := strings.TrimSpace(certificateField(value, "ca"))
+			for signer := range included {
+				if ca != "" && ca == strings.TrimSpace(certificateField(vars[signer], "certificate")) {
+					included[name] = true
+					added = true
+					break
+				}
+			}
+		}
+	}
+
+	all := []string{}
+	for name := range included {
+		all = append(all, name)
+	}
+	sort.Strings(all)
+	return all
+}
+
+func certificateField(value interface{}, field string) string {
+	certificate, ok := value.(map[interface{}]interface{})
+	if !ok {
+		return ""
+	}
+
+	fieldValue, _ := certificate[field].(string)
+	return fieldValue
+}
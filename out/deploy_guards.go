@@ -0,0 +1,130 @@
+package out
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/cloudfoundry/bosh-deployment-resource/bosh"
+	"github.com/cloudfoundry/bosh-deployment-resource/concourse"
+)
+
+func (c OutCommand) checkGuards(diff bosh.DeploymentDiff, guards concourse.GuardParams) error {
+	violations := []string{}
+
+	if !guards.AllowInstanceGroupRemoval {
+		removedInstanceGroups, err := diff.RemovedInstanceGroups()
+		if err != nil {
+			return err
+		}
+		for _, name := range removedInstanceGroups {
+			violations = append(violations, fmt.Sprintf("instance group %s is removed (allow with guards.allow_instance_group_removal)", name))
+		}
+	}
+
+	if !guards.AllowReleaseRemoval {
+		removedReleases, err := diff.RemovedReleases()
+		if err != nil {
+			return err
+		}
+		for _, name := range removedReleases {
+			violations = append(violations, fmt.Sprintf("release %s is removed (allow with guards.allow_release_removal)", name))
+		}
+	}
+
+	changes, err := diff.ChangedInstanceGroups()
+	if err != nil {
+		return err
+	}
+
+	if !guards.AllowInstancesDecrease {
+		for _, change := range changes {
+			violations = append(violations, instancesViolations(change, guards)...)
+		}
+	}
+
+	if !guards.AllowPersistentDiskShrink {
+		diskViolations, err := c.persistentDiskViolations(changes)
+		if err != nil {
+			return err
+		}
+		violations = append(violations, diskViolations...)
+	}
+
+	if len(violations) > 0 {
+		return errors.New("Refusing to deploy:\n- " + strings.Join(violations, "\n- ")) //nolint:staticcheck
+	}
+
+	return nil
+}
+
+func instancesViolations(change bosh.InstanceGroupChange, guards concourse.GuardParams) []string {
+	if change.Before.Instances == nil || change.After.Instances == nil {
+		return nil
+	}
+
+	before, after := *change.Before.Instances, *change.After.Instances
+	if after >= before {
+		return nil
+	}
+
+	violations := []string{}
+	if guards.MaxInstancesDecreasePercent > 0 && (before-after)*100 > guards.MaxInstancesDecreasePercent*before {
+		violations = append(violations, fmt.Sprintf("instance group %s instances drop from %d to %d, more than %d%% (allow with guards.allow_instances_decrease)",
+			change.Name, before, after, guards.MaxInstancesDecreasePercent))
+	}
+	if guards.MinInstances > 0 && after < guards.MinInstances {
+		violations = append(violations, fmt.Sprintf("instance group %s instances drop from %d to %d, below %d (allow with guards.allow_instances_decrease)",
+			change.Name, before, after, guards.MinInstances))
+	}
+	return violations
+}
+
+func (c OutCommand) persistentDiskViolations(changes []bosh.InstanceGroupChange) ([]string, error) {
+	var diskTypeSizes map[string]int
+	diskSize := func(spec bosh.InstanceGroupSpec) (int, error) {
+		if spec.PersistentDisk != nil {
+			return *spec.PersistentDisk, nil
+		}
+		if spec.PersistentDiskType == "" {
+			return 0, nil
+		}
+
+		if diskTypeSizes == nil {
+			var err error
+			diskTypeSizes, err = c.director.DiskTypeSizes()
+			if err != nil {
+				return 0, err
+			}
+		}
+
+		size, ok := diskTypeSizes[spec.PersistentDiskType]
+		if !ok {
+			return 0, fmt.Errorf("could not find disk type %s in the cloud config", spec.PersistentDiskType)
+		}
+		return size, nil
+	}
+
+	violations := []string{}
+	for _, change := range changes {
+		if change.Before.PersistentDisk == nil && change.Before.PersistentDiskType == "" {
+			continue
+		}
+
+		before, err := diskSize(change.Before)
+		if err != nil {
+			return nil, err
+		}
+		after, err := diskSize(change.After)
+		if err != nil {
+			return nil, err
+		}
+
+		if after < before {
+			violations = append(violations, fmt.Sprintf("instance group %s persistent disk shrinks from %d to %d (allow with guards.allow_persistent_disk_shrink)",
+				change.Name, before, after))
+		}
+	}
+
+	return violations, nil
+}
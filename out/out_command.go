@@ -1,10 +1,16 @@
 package out
 
 import (
+	"crypto/sha256"
 	"fmt"
 	"os"
 	"path"
 	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v2"
 
 	"github.com/cloudfoundry/bosh-deployment-resource/bosh"
 	"github.com/cloudfoundry/bosh-deployment-resource/concourse"
@@ -17,6 +23,19 @@ type OutResponse struct {
 	Metadata []concourse.Metadata `json:"metadata"`
 }
 
+// planSuffix names the object the plan is kept in, next to the vars store.
+const planSuffix = ".plan"
+
+type deploymentPlan struct {
+	Manifest   string `yaml:"manifest"`
+	Diff       string `yaml:"diff"`
+	DiffSha256 string `yaml:"diff_sha256"`
+
+	// ManifestSha256 covers the values the redacted diff does not show: the
+	// interpolated manifest and the vars store it is deployed with.
+	ManifestSha256 string `yaml:"manifest_sha256"`
+}
+
 type OutCommand struct {
 	director           bosh.Director
 	boshIOClient       bosh.BoshIO
@@ -35,12 +54,33 @@ func NewOutCommand(director bosh.Director, boshIOClient bosh.BoshIO,
 }
 
 func (c OutCommand) Run(outRequest concourse.OutRequest) (OutResponse, error) {
-	if err := c.director.WaitForDeployLock(); err != nil {
+	if c.storageClient != nil {
+		defer c.storageClient.Close() //nolint:errcheck
+	}
+
+	params := outRequest.Params
+	// With a vars store the deploy task of a previous attempt is not attached
+	// to: the variables it generated were lost with that attempt, so they are
+	// generated and stored again by a new deploy once the task released the
+	// lock.
+	if !params.Delete.Enabled && params.Action == nil && !params.DryRun && !params.Plan && c.storageClient == nil {
+		taskID, err := c.director.AttachToDeployTask()
+		if err != nil {
+			return OutResponse{}, err
+		}
+		if taskID != 0 {
+			return c.attachedDeploy(outRequest, taskID)
+		}
+	}
+
+	if err := c.director.WaitForDeployLock(time.Duration(outRequest.Params.LockTimeout)); err != nil {
 		return OutResponse{}, err
 	}
 
 	if outRequest.Params.Delete.Enabled {
 		return OutResponse{}, c.director.Delete(outRequest.Params.Delete.Force)
+	} else if outRequest.Params.Action != nil {
+		return c.runAction(outRequest)
 	} else {
 		return c.deploy(outRequest)
 	}
@@ -78,36 +118,72 @@ func (c OutCommand) deploy(outRequest concourse.OutRequest) (OutResponse, error)
 		return OutResponse{}, err
 	}
 
-	releaseMetadata, err := c.consumeReleases(manifest, outRequest.Params.Releases)
+	dryRun := outRequest.Params.DryRun || outRequest.Params.Plan
+
+	releases, err := bosh.NewReleases(c.resourcesDirectory, outRequest.Params.Releases)
 	if err != nil {
 		return OutResponse{}, err
 	}
 
-	stemcellMetadata, err := c.consumeStemcells(manifest, outRequest.Params.Stemcells)
+	if len(outRequest.Params.ReleaseDirs) > 0 {
+		releasesDir, err := os.MkdirTemp("", "created-releases")
+		if err != nil {
+			return OutResponse{}, err
+		}
+		defer os.RemoveAll(releasesDir) //nolint:errcheck
+
+		createdReleases, err := c.createReleases(outRequest.Params.ReleaseDirs, releasesDir)
+		if err != nil {
+			return OutResponse{}, err
+		}
+		releases = append(releases, createdReleases...)
+	}
+
+	uploads, localReleases, err := c.consumeReleases(manifest, releases, dryRun)
 	if err != nil {
 		return OutResponse{}, err
 	}
 
+	if outRequest.Params.BoshIOReleases {
+		boshIOReleaseUploads, err := c.uploadBoshIOReleases(manifest, localReleases, dryRun)
+		if err != nil {
+			return OutResponse{}, err
+		}
+		uploads = append(uploads, boshIOReleaseUploads...)
+	}
+
+	stemcellUploads, err := c.consumeStemcells(manifest, outRequest.Params.Stemcells, dryRun)
+	if err != nil {
+		return OutResponse{}, err
+	}
+	uploads = append(uploads, stemcellUploads...)
+
 	if outRequest.Params.BoshIOStemcellType != "" {
-		boshIOStemcellMeta, err := c.uploadBoshIOStemcells(manifest, outRequest.Params.BoshIOStemcellType == "light")
+		boshIOStemcellUploads, err := c.uploadBoshIOStemcells(manifest, outRequest.Params.BoshIOStemcellType == "light", dryRun)
 		if err != nil {
 			return OutResponse{}, err
 		}
-		stemcellMetadata = append(stemcellMetadata, boshIOStemcellMeta...)
+		uploads = append(uploads, boshIOStemcellUploads...)
+	}
+
+	uploadMetadata, err := c.runUploads(uploads, outRequest.Params.UploadConcurrency)
+	if err != nil {
+		return OutResponse{}, err
 	}
 
 	deployParams := bosh.DeployParams{
 		NoRedact:    outRequest.Params.NoRedact,
-		DryRun:      outRequest.Params.DryRun,
 		MaxInFlight: outRequest.Params.MaxInFlight,
 		Recreate:    outRequest.Params.Recreate,
 		SkipDrain:   outRequest.Params.SkipDrain,
 		Cleanup:     outRequest.Params.Cleanup,
 		Fix:         outRequest.Params.Fix,
 		VarFiles:    c.prependResourcesDir(outRequest.Params.VarFiles),
+		Timeout:     time.Duration(outRequest.Params.DeployTimeout),
 	}
 
 	var varsStoreFile *os.File
+	var downloadedVarsStore []byte
 	if c.storageClient != nil {
 		varsStoreFile, err = os.CreateTemp("", "vars-store")
 		if err != nil {
@@ -115,23 +191,153 @@ func (c OutCommand) deploy(outRequest concourse.OutRequest) (OutResponse, error)
 		}
 		defer varsStoreFile.Close() //nolint:errcheck
 
-		if err = c.storageClient.Download(varsStoreFile.Name()); err != nil {
+		download := c.storageClient.Download
+		if dryRun {
+			download = c.storageClient.DownloadReadOnly
+		}
+		if err = download(varsStoreFile.Name()); err != nil {
+			return OutResponse{}, err
+		}
+
+		downloadedVarsStore, err = os.ReadFile(varsStoreFile.Name())
+		if err != nil {
 			return OutResponse{}, err
 		}
 
 		deployParams.VarsStore = varsStoreFile.Name()
 	}
 
-	if err := c.director.Deploy(manifest.Manifest(), deployParams); err != nil {
+	var restoreMetadata []concourse.Metadata
+	if outRequest.Params.RestoreRevision != "" {
+		if err := c.restoreVarsStoreRevision(outRequest.Params.RestoreRevision, deployParams.VarsStore); err != nil {
+			return OutResponse{}, err
+		}
+		restoreMetadata = append(restoreMetadata, concourse.Metadata{Name: "restored_vars_store_revision", Value: outRequest.Params.RestoreRevision})
+	}
+
+	var manifestSha string
+	if outRequest.Params.Plan || outRequest.Params.ApplyPlan {
+		manifestSha, err = manifestSha256(manifest.Manifest(), deployParams.VarsStore)
+		if err != nil {
+			return OutResponse{}, err
+		}
+	}
+
+	var certificateMetadata []concourse.Metadata
+	if outRequest.Params.CertificateExpiry != nil {
+		certificateMetadata, err = c.checkCertificateExpiry(*outRequest.Params.CertificateExpiry, deployParams.VarsStore, dryRun)
+		if err != nil {
+			return OutResponse{}, err
+		}
+	}
+
+	var rotations []caRotation
+	if len(outRequest.Params.RotateVariables) > 0 {
+		variables, err := manifest.Variables()
+		if err != nil {
+			return OutResponse{}, err
+		}
+
+		var rotated []string
+		rotated, rotations, err = rotateVariables(deployParams.VarsStore, outRequest.Params.RotateVariables, outRequest.Params.TransitionalCAs, variables)
+		if err != nil {
+			return OutResponse{}, err
+		}
+		for _, name := range rotated {
+			certificateMetadata = append(certificateMetadata, concourse.Metadata{Name: "rotated_variable", Value: name})
+		}
+	}
+
+	if len(outRequest.Params.RetireOldCAs) > 0 {
+		if err := retireOldCAs(deployParams.VarsStore, outRequest.Params.RetireOldCAs); err != nil {
+			return OutResponse{}, err
+		}
+		for _, name := range outRequest.Params.RetireOldCAs {
+			certificateMetadata = append(certificateMetadata, concourse.Metadata{Name: "retired_old_ca", Value: name})
+		}
+	}
+
+	if dryRun {
+		return c.dryRun(manifest, deployParams, outRequest, manifestSha, append(append(uploadMetadata, restoreMetadata...), certificateMetadata...))
+	}
+
+	var diff bosh.DeploymentDiff
+	if outRequest.Params.ApplyPlan || outRequest.Params.Guards != nil {
+		diff, err = c.director.Diff(manifest.Manifest(), deployParams)
+		if err != nil {
+			return OutResponse{}, err
+		}
+	}
+
+	planMetadata := []concourse.Metadata{}
+	if outRequest.Params.ApplyPlan {
+		planSha, err := c.checkPlan(diff, manifestSha)
+		if err != nil {
+			return OutResponse{}, err
+		}
+		planMetadata = append(planMetadata, concourse.Metadata{Name: "plan", Value: planSha})
+	}
+
+	if outRequest.Params.Guards != nil {
+		if err := c.checkGuards(diff, *outRequest.Params.Guards); err != nil {
+			return OutResponse{}, err
+		}
+	}
+
+	var previousManifest []byte
+	if outRequest.Params.RollbackOnFailure {
+		previousManifest, err = c.previousManifest()
+		if err != nil {
+			return OutResponse{}, err
+		}
+	}
+
+	configMetadata, err := c.updateConfigs(outRequest.Params.Configs)
+	if err != nil {
 		return OutResponse{}, err
 	}
 
+	originalVarsStore := downloadedVarsStore
+	if len(rotations) > 0 {
+		downloadedVarsStore, err = c.deployTrustedCAs(manifest, deployParams, previousManifest, rotations, downloadedVarsStore)
+		if err != nil {
+			return OutResponse{}, err
+		}
+	}
+
+	if err := c.director.Deploy(manifest.Manifest(), deployParams); err != nil {
+		if len(rotations) > 0 {
+			// Roll back with the vars store of the first deploy, which still
+			// has the certificates signed by the old CAs.
+			if writeErr := os.WriteFile(deployParams.VarsStore, downloadedVarsStore, 0600); writeErr != nil {
+				return OutResponse{}, writeErr
+			}
+		}
+		return OutResponse{}, c.rollback(previousManifest, deployParams, err)
+	}
+
+	var variableMetadata []concourse.Metadata
 	if c.storageClient != nil {
-		if err := c.storageClient.Upload(varsStoreFile.Name()); err != nil {
+		deployedVarsStore, err := os.ReadFile(varsStoreFile.Name())
+		if err != nil {
+			return OutResponse{}, err
+		}
+
+		variableMetadata, err = varsStoreChanges(originalVarsStore, deployedVarsStore)
+		if err != nil {
+			return OutResponse{}, err
+		}
+
+		if err := c.uploadVarsStore(varsStoreFile.Name(), downloadedVarsStore); err != nil {
 			return OutResponse{}, err
 		}
 	}
 
+	errandMetadata, err := c.runErrands(outRequest.Params.Errands)
+	if err != nil {
+		return OutResponse{}, c.rollback(previousManifest, deployParams, err)
+	}
+
 	uploadedManifest, err := c.director.DownloadManifest()
 	if err != nil {
 		return OutResponse{}, err
@@ -139,65 +345,294 @@ func (c OutCommand) deploy(outRequest concourse.OutRequest) (OutResponse, error)
 
 	concourseOutput := OutResponse{
 		Version:  concourse.NewVersion(uploadedManifest, outRequest.Source.Target),
-		Metadata: append(releaseMetadata, stemcellMetadata...),
+		Metadata: append(append(append(append(append(append(uploadMetadata, restoreMetadata...), certificateMetadata...), variableMetadata...), configMetadata...), planMetadata...), errandMetadata...),
 	}
 
 	return concourseOutput, nil
 }
 
-func (c OutCommand) consumeReleases(manifest bosh.DeploymentManifest, releaseGlobs []string) ([]concourse.Metadata, error) {
-	releases, err := bosh.NewReleases(c.resourcesDirectory, releaseGlobs)
+// attachedDeploy finishes a put whose deploy task was started by a previous
+// attempt of the build and has now been followed until it finished.
+func (c OutCommand) attachedDeploy(outRequest concourse.OutRequest, taskID int) (OutResponse, error) {
+	errandMetadata, err := c.runErrands(outRequest.Params.Errands)
+	if err != nil {
+		return OutResponse{}, err
+	}
+
+	uploadedManifest, err := c.director.DownloadManifest()
+	if err != nil {
+		return OutResponse{}, err
+	}
+
+	metadata := []concourse.Metadata{{Name: "attached_task", Value: strconv.Itoa(taskID)}}
+	return OutResponse{
+		Version:  concourse.NewVersion(uploadedManifest, outRequest.Source.Target),
+		Metadata: append(metadata, errandMetadata...),
+	}, nil
+}
+
+func (c OutCommand) runAction(outRequest concourse.OutRequest) (OutResponse, error) {
+	action := outRequest.Params.Action
+	actionParams := bosh.InstanceActionParams{
+		Instances:   action.Instances,
+		SkipDrain:   action.SkipDrain,
+		Hard:        action.Hard,
+		Canaries:    action.Canaries,
+		MaxInFlight: action.MaxInFlight,
+	}
+
+	var err error
+	var metadata []concourse.Metadata
+	switch action.Name {
+	case "start":
+		err = c.director.Start(actionParams)
+	case "stop":
+		err = c.director.Stop(actionParams)
+	case "restart":
+		err = c.director.Restart(actionParams)
+	case "recreate":
+		err = c.director.Recreate(actionParams)
+	case "cloud_check":
+		metadata, err = c.cloudCheck(action.Policy, action.ReportOnly)
+	default:
+		err = fmt.Errorf("unknown action: %s", action.Name)
+	}
+	if err != nil {
+		return OutResponse{}, err
+	}
+
+	currentManifest, err := c.director.DownloadManifest()
+	if err != nil {
+		return OutResponse{}, err
+	}
+
+	return OutResponse{
+		Version:  concourse.NewVersion(currentManifest, outRequest.Source.Target),
+		Metadata: metadata,
+	}, nil
+}
+
+func (c OutCommand) cloudCheck(policy map[string]string, reportOnly bool) ([]concourse.Metadata, error) {
+	problems, err := c.director.CloudCheck(policy, reportOnly)
 	if err != nil {
 		return nil, err
 	}
 
 	metadata := []concourse.Metadata{}
+	for _, problem := range problems {
+		if problem.Resolution == "" {
+			metadata = append(metadata, concourse.Metadata{
+				Name:  "problem",
+				Value: fmt.Sprintf("%s: %s", problem.Type, problem.Description),
+			})
+		} else {
+			metadata = append(metadata, concourse.Metadata{
+				Name:  "resolved_problem",
+				Value: fmt.Sprintf("%s: %s with %s", problem.Type, problem.Description, problem.Resolution),
+			})
+		}
+	}
 
-	for _, release := range releases {
-		if err := c.director.UploadRelease(release.FilePath); err != nil {
+	return metadata, nil
+}
+
+func (c OutCommand) dryRun(manifest bosh.DeploymentManifest, deployParams bosh.DeployParams, outRequest concourse.OutRequest, manifestSha string, metadata []concourse.Metadata) (OutResponse, error) {
+	diff, err := c.director.Diff(manifest.Manifest(), deployParams)
+	if err != nil {
+		return OutResponse{}, err
+	}
+
+	diffValue := "no changes"
+	if diff.HasChanges() {
+		diffValue = diff.String()
+	}
+	metadata = append(metadata, concourse.Metadata{Name: "diff", Value: diffValue})
+
+	if outRequest.Params.Plan {
+		if err := c.writePlan(manifest.Manifest(), diff, manifestSha); err != nil {
+			return OutResponse{}, err
+		}
+		metadata = append(metadata, concourse.Metadata{Name: "plan", Value: diff.Sha256()})
+	}
+
+	currentManifest, err := c.director.DownloadManifest()
+	if err != nil {
+		return OutResponse{}, err
+	}
+
+	return OutResponse{
+		Version:  concourse.NewVersion(currentManifest, outRequest.Source.Target),
+		Metadata: metadata,
+	}, nil
+}
+
+func (c OutCommand) writePlan(manifestBytes []byte, diff bosh.DeploymentDiff, manifestSha string) error {
+	planBytes, err := yaml.Marshal(deploymentPlan{
+		Manifest:       string(manifestBytes),
+		Diff:           diff.String(),
+		DiffSha256:     diff.Sha256(),
+		ManifestSha256: manifestSha,
+	})
+	if err != nil {
+		return err
+	}
+
+	planFile, err := os.CreateTemp("", "plan")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(planFile.Name()) //nolint:errcheck
+	defer planFile.Close()           //nolint:errcheck
+
+	if _, err := planFile.Write(planBytes); err != nil {
+		return err
+	}
+
+	return c.storageClient.UploadObject(planSuffix, planFile.Name())
+}
+
+func (c OutCommand) checkPlan(diff bosh.DeploymentDiff, manifestSha string) (string, error) {
+	planFile, err := os.CreateTemp("", "plan")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(planFile.Name()) //nolint:errcheck
+	defer planFile.Close()           //nolint:errcheck
+
+	if err := c.storageClient.DownloadObject(planSuffix, planFile.Name()); err != nil {
+		return "", fmt.Errorf("Could not read the approved plan: %s", err) //nolint:staticcheck
+	}
+
+	planBytes, err := os.ReadFile(planFile.Name())
+	if err != nil {
+		return "", err
+	}
+
+	var plan deploymentPlan
+	if err := yaml.Unmarshal(planBytes, &plan); err != nil {
+		return "", fmt.Errorf("Could not read the approved plan: %s", err) //nolint:staticcheck
+	}
+
+	if diff.Sha256() != plan.DiffSha256 {
+		return "", fmt.Errorf("Refusing to deploy, the diff %s does not match the approved plan %s:\n%s", diff.Sha256(), plan.DiffSha256, diff) //nolint:staticcheck
+	}
+
+	if manifestSha != plan.ManifestSha256 {
+		return "", fmt.Errorf("Refusing to deploy, the manifest or the vars store changed since the approved plan %s", plan.DiffSha256) //nolint:staticcheck
+	}
+
+	return plan.DiffSha256, nil
+}
+
+// manifestSha256 hashes the manifest together with the vars store, so
+// changed secrets are noticed even though the diff redacts them.
+func manifestSha256(manifestBytes []byte, varsStorePath string) (string, error) {
+	varsStoreBytes, err := os.ReadFile(varsStorePath)
+	if err != nil {
+		return "", err
+	}
+
+	manifestSum := sha256.Sum256(manifestBytes)
+	varsStoreSum := sha256.Sum256(varsStoreBytes)
+	return fmt.Sprintf("%x", sha256.Sum256(append(manifestSum[:], varsStoreSum[:]...))), nil
+}
+
+// createReleases creates a release tarball in releasesDir from each of the
+// release directories.
+func (c OutCommand) createReleases(releaseDirs []concourse.ReleaseDirParams, releasesDir string) ([]bosh.Release, error) {
+	tarballs := []string{}
+	for i, releaseDir := range releaseDirs {
+		tarball := fmt.Sprintf("release-%d.tgz", i)
+		err := c.director.CreateRelease(bosh.CreateReleaseParams{
+			Dir:     filepath.Join(c.resourcesDirectory, releaseDir.Dir),
+			Tarball: filepath.Join(releasesDir, tarball),
+			Version: releaseDir.Version,
+			Force:   releaseDir.Force,
+			Final:   releaseDir.Final,
+		})
+		if err != nil {
 			return nil, err
 		}
+		tarballs = append(tarballs, tarball)
+	}
+
+	return bosh.NewReleases(releasesDir, tarballs)
+}
+
+func (c OutCommand) consumeReleases(manifest bosh.DeploymentManifest, releases []bosh.Release, dryRun bool) ([]pendingUpload, map[string]bool, error) {
+	uploads := []pendingUpload{}
+	consumed := map[string]bool{}
+
+	for _, release := range releases {
+		if release.Repository != "" {
+			name, err := manifest.ReleaseName(release.Repository)
+			if err != nil {
+				return nil, nil, err
+			}
+			release.Name = name
+		}
 
 		if err := manifest.UseReleaseVersion(release.Name, release.Version); err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 
-		metadata = append(metadata, concourse.Metadata{
-			Name:  "release",
-			Value: fmt.Sprintf("%s v%s", release.Name, release.Version),
-		})
+		consumed[release.Name] = true
+		uploads = append(uploads, releaseUpload(release, dryRun))
 	}
 
-	return metadata, nil
+	return uploads, consumed, nil
 }
 
-func (c OutCommand) consumeStemcells(manifest bosh.DeploymentManifest, stemcellGlobs []string) ([]concourse.Metadata, error) {
-	stemcells, err := bosh.NewStemcells(c.resourcesDirectory, stemcellGlobs)
+// uploadBoshIOReleases uploads the manifest's releases from bosh.io, except
+// those provided as tarballs and those the director fetches from another url.
+func (c OutCommand) uploadBoshIOReleases(manifest bosh.DeploymentManifest, localReleases map[string]bool, dryRun bool) ([]pendingUpload, error) {
+	releases, err := manifest.Releases()
 	if err != nil {
 		return nil, err
 	}
 
-	metadata := []concourse.Metadata{}
+	uploads := []pendingUpload{}
 
-	for _, stemcell := range stemcells {
-		if err := c.director.UploadStemcell(stemcell.FilePath); err != nil {
+	for _, release := range releases {
+		if localReleases[release.Name] || (release.URL != "" && !bosh.IsBoshIOURL(release.URL)) {
+			continue
+		}
+
+		r, err := bosh.LookupBoshIORelease(c.boshIOClient, release)
+		if err != nil {
 			return nil, err
 		}
 
+		if err := manifest.UseReleaseURL(r.Name, r.URL, r.Sha1); err != nil {
+			return nil, err
+		}
+
+		uploads = append(uploads, releaseUpload(bosh.Release{Name: r.Name, Version: r.Version, URL: r.URL, Sha1: r.Sha1}, dryRun))
+	}
+
+	return uploads, nil
+}
+
+func (c OutCommand) consumeStemcells(manifest bosh.DeploymentManifest, stemcellGlobs []string, dryRun bool) ([]pendingUpload, error) {
+	stemcells, err := bosh.NewStemcells(c.resourcesDirectory, stemcellGlobs)
+	if err != nil {
+		return nil, err
+	}
+
+	uploads := []pendingUpload{}
+
+	for _, stemcell := range stemcells {
 		if err := manifest.UseStemcellVersion(stemcell.Name, stemcell.OperatingSystem, stemcell.Version); err != nil {
 			return nil, err
 		}
 
-		metadata = append(metadata, concourse.Metadata{
-			Name:  "stemcell",
-			Value: fmt.Sprintf("%s v%s", stemcell.Name, stemcell.Version),
-		})
+		uploads = append(uploads, stemcellUpload(stemcell, dryRun))
 	}
 
-	return metadata, nil
+	return uploads, nil
 }
 
-func (c OutCommand) uploadBoshIOStemcells(manifest bosh.DeploymentManifest, light bool) ([]concourse.Metadata, error) {
+func (c OutCommand) uploadBoshIOStemcells(manifest bosh.DeploymentManifest, light bool, dryRun bool) ([]pendingUpload, error) {
 	info, err := c.director.Info()
 	if err != nil {
 		return nil, err
@@ -208,7 +643,7 @@ func (c OutCommand) uploadBoshIOStemcells(manifest bosh.DeploymentManifest, ligh
 		return nil, err
 	}
 
-	metadata := []concourse.Metadata{}
+	uploads := []pendingUpload{}
 
 	for _, stemcell := range stemcells {
 		s, err := bosh.LookupBoshIOStemcell(c.boshIOClient, info.CPI, stemcell.OperatingSystem, stemcell.Version, light)
@@ -217,19 +652,124 @@ func (c OutCommand) uploadBoshIOStemcells(manifest bosh.DeploymentManifest, ligh
 			return nil, err
 		}
 
-		if err := c.director.UploadRemoteStemcell(s.URL, s.Name, s.Version, s.Sha1); err != nil {
+		uploads = append(uploads, stemcellUpload(bosh.Stemcell{Name: s.Name, Version: s.Version, URL: s.URL, Sha1: s.Sha1}, dryRun))
+	}
+
+	return uploads, nil
+}
+
+func (c OutCommand) updateConfigs(configs []concourse.ConfigParams) ([]concourse.Metadata, error) {
+	metadata := []concourse.Metadata{}
+
+	for _, config := range configs {
+		name := config.Name
+		if name == "" {
+			name = "default"
+		}
+
+		configBytes, err := os.ReadFile(path.Join(c.resourcesDirectory, config.File))
+		if err != nil {
+			return nil, fmt.Errorf("Could not read %s config %s: %s", config.Type, name, err) //nolint:staticcheck
+		}
+
+		opsFilePaths, err := tools.UnfurlGlobs(c.resourcesDirectory, config.OpsFiles)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid ops_file name: %s", err) //nolint:staticcheck
+		}
+
+		configBytes, err = c.director.Interpolate(configBytes, bosh.InterpolateParams{
+			Vars:     config.Vars,
+			OpsFiles: opsFilePaths,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		id, changed, err := c.director.UpdateConfig(config.Type, name, configBytes)
+		if err != nil {
 			return nil, err
 		}
 
-		metadata = append(metadata, concourse.Metadata{
-			Name:  "stemcell",
-			Value: fmt.Sprintf("%s v%s", s.Name, s.Version),
+		if changed {
+			metadata = append(metadata, concourse.Metadata{
+				Name:  "config",
+				Value: fmt.Sprintf("%s/%s id %s", config.Type, name, id),
+			})
+		}
+	}
+
+	return metadata, nil
+}
+
+func (c OutCommand) runErrands(errands []concourse.ErrandParams) ([]concourse.Metadata, error) {
+	metadata := []concourse.Metadata{}
+
+	for _, errand := range errands {
+		results, err := c.director.RunErrand(bosh.ErrandParams{
+			Name:        errand.Name,
+			Instances:   errand.Instances,
+			KeepAlive:   errand.KeepAlive,
+			WhenChanged: errand.WhenChanged,
 		})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, result := range results {
+			value := fmt.Sprintf("%s exit code %d", errand.Name, result.ExitCode)
+			if result.Instance != "" {
+				value = fmt.Sprintf("%s on %s exit code %d", errand.Name, result.Instance, result.ExitCode)
+			}
+			metadata = append(metadata, concourse.Metadata{
+				Name:  "errand",
+				Value: value,
+			})
+
+			if result.ExitCode != 0 && !errand.IgnoreFailure {
+				return nil, fmt.Errorf("Errand %s completed with error (exit code %d)", errand.Name, result.ExitCode) //nolint:staticcheck
+			}
+		}
 	}
 
 	return metadata, nil
 }
 
+func (c OutCommand) previousManifest() ([]byte, error) {
+	manifest, err := c.director.DownloadManifest()
+	if err != nil {
+		// Deployment not found error, there is nothing to roll back to on the first deploy
+		if strings.Contains(err.Error(), `"code":70000`) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("Could not save the current manifest for rollback: %s", err) //nolint:staticcheck
+	}
+
+	return manifest, nil
+}
+
+func (c OutCommand) rollback(previousManifest []byte, deployParams bosh.DeployParams, deployErr error) error {
+	if len(previousManifest) == 0 {
+		return deployErr
+	}
+
+	rollbackParams := bosh.DeployParams{
+		NoRedact:    deployParams.NoRedact,
+		MaxInFlight: deployParams.MaxInFlight,
+		SkipDrain:   deployParams.SkipDrain,
+		VarsStore:   deployParams.VarsStore,
+		Timeout:     deployParams.Timeout,
+	}
+
+	// The errors of the CLI end in a newline, which would leave a blank line
+	// before the note about the rollback.
+	deployMessage := strings.TrimRight(deployErr.Error(), "\n")
+	if err := c.director.Deploy(previousManifest, rollbackParams); err != nil {
+		return fmt.Errorf("%s\nRollback to the previous manifest failed: %s", deployMessage, strings.TrimRight(err.Error(), "\n"))
+	}
+
+	return fmt.Errorf("%s\nRolled back to the previous manifest", deployMessage)
+}
+
 func (c OutCommand) prependResourcesDir(varsFiles map[string]string) map[string]string {
 	varsWithAbsPath := map[string]string{}
 	for varName, varFilePath := range varsFiles {
@@ -0,0 +1,317 @@
+package out
+
+import (
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	cfgtypes "github.com/cloudfoundry/config-server/types"
+	"gopkg.in/yaml.v2"
+
+	"github.com/cloudfoundry/bosh-deployment-resource/bosh"
+)
+
+// caRotation is a CA that is rotated in two deploys. The first deploy adds
+// the new CA to the trusted CAs, the second one signs with it.
+type caRotation struct {
+	name           string
+	newCertificate string
+	newPrivateKey  string
+}
+
+// rotateVariables removes the variables from the vars store, so the deploy
+// generates them again, together with the certificates signed by them. With
+// transitional, CAs are not removed but rotated in two deploys: the new CA
+// is generated from its definition in the manifest variables and trusted
+// next to the old one now, and finishCARotations switches to it before the
+// second deploy. It returns the names of the rotated variables.
+func rotateVariables(varsStorePath string, names []string, transitional bool, variables []bosh.Variable) ([]string, []caRotation, error) {
+	vars, err := readVarsStore(varsStorePath)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	removed := []string{}
+	rotations := []caRotation{}
+	for _, name := range names {
+		value, ok := vars[name]
+		if !ok {
+			return nil, nil, fmt.Errorf("could not rotate variable %s, it is not in the vars store", name)
+		}
+
+		if transitional && isCA(value) {
+			rotation, err := startCARotation(vars, name, variables)
+			if err != nil {
+				return nil, nil, err
+			}
+			rotations = append(rotations, rotation)
+			continue
+		}
+
+		removed = append(removed, name)
+	}
+
+	removed = withSignedCertificates(vars, removed)
+	for _, name := range removed {
+		delete(vars, name)
+	}
+
+	if err := writeVarsStore(varsStorePath, vars); err != nil {
+		return nil, nil, err
+	}
+
+	rotated := removed
+	for _, rotation := range rotations {
+		rotated = append(rotated, rotation.name)
+	}
+	sort.Strings(rotated)
+
+	return rotated, rotations, nil
+}
+
+// startCARotation generates a new CA from the definition of the CA with the
+// name in the manifest variables, like BOSH would, and adds it to the trusted
+// CAs of the CA and of the certificates signed by it.
+func startCARotation(vars map[string]interface{}, name string, variables []bosh.Variable) (caRotation, error) {
+	oldCertificatePEM := certificateField(vars[name], "certificate")
+	if strings.TrimSpace(certificateField(vars[name], "ca")) != strings.TrimSpace(oldCertificatePEM) {
+		return caRotation{}, fmt.Errorf("could not rotate CA %s, only self-signed CAs can be rotated in two deploys", name)
+	}
+
+	definition, ok := certificateVariable(variables, name)
+	if !ok || definition.Options["is_ca"] != true || definition.Options["ca"] != nil {
+		return caRotation{}, fmt.Errorf("could not rotate CA %s, it is not defined as a self-signed CA in the variables of the manifest", name)
+	}
+
+	newCA, err := generateCertificate(definition, nil)
+	if err != nil {
+		return caRotation{}, fmt.Errorf("could not generate a new CA for %s: %s", name, err)
+	}
+
+	trusted := strings.TrimSpace(oldCertificatePEM) + "\n" + newCA.Certificate
+	for varName, value := range vars {
+		if varName == name || strings.TrimSpace(certificateField(value, "ca")) == strings.TrimSpace(oldCertificatePEM) {
+			setCertificateField(vars, varName, "ca", trusted)
+		}
+	}
+
+	return caRotation{name: name, newCertificate: newCA.Certificate, newPrivateKey: newCA.PrivateKey}, nil
+}
+
+// deployTrustedCAs is the first deploy of a CA rotation, it trusts the new
+// CAs next to the old ones. Afterwards the vars store is uploaded and the CAs
+// are switched to the new certificates for the second deploy. It returns the
+// uploaded vars store.
+func (c OutCommand) deployTrustedCAs(manifest bosh.DeploymentManifest, deployParams bosh.DeployParams, previousManifest []byte, rotations []caRotation, downloadedVarsStore []byte) ([]byte, error) {
+	if err := c.director.Deploy(manifest.Manifest(), deployParams); err != nil {
+		return nil, c.rollback(previousManifest, deployParams, err)
+	}
+
+	if err := c.uploadVarsStore(deployParams.VarsStore, downloadedVarsStore); err != nil {
+		return nil, err
+	}
+
+	uploadedVarsStore, err := os.ReadFile(deployParams.VarsStore)
+	if err != nil {
+		return nil, err
+	}
+
+	variables, err := manifest.Variables()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := finishCARotations(deployParams.VarsStore, rotations, variables); err != nil {
+		return nil, err
+	}
+
+	return uploadedVarsStore, nil
+}
+
+// finishCARotations switches the rotated CAs to their new certificates. The
+// certificates the manifest variables define as signed by a rotated CA are
+// signed again with the new CA, and keep trusting the old CA next to it, so
+// instances that are not updated yet can still be connected to during the
+// second deploy. Other certificates signed by the rotated CAs, e.g. by
+// intermediate CAs, are removed so the deploy generates them again, only
+// trusting the new CA.
+func finishCARotations(varsStorePath string, rotations []caRotation, variables []bosh.Variable) error {
+	vars, err := readVarsStore(varsStorePath)
+	if err != nil {
+		return err
+	}
+
+	for _, rotation := range rotations {
+		oldCertificatePEM := certificateField(vars[rotation.name], "certificate")
+		trusted := strings.TrimSpace(certificateField(vars[rotation.name], "ca"))
+		bundle := rotation.newCertificate + strings.TrimSpace(oldCertificatePEM) + "\n"
+
+		newCA, err := parseCA(rotation)
+		if err != nil {
+			return err
+		}
+
+		signed := []string{}
+		for name, value := range vars {
+			if name != rotation.name && strings.TrimSpace(certificateField(value, "ca")) == trusted {
+				signed = append(signed, name)
+			}
+		}
+		signed = withSignedCertificates(vars, signed)
+
+		setCertificateField(vars, rotation.name, "certificate", rotation.newCertificate)
+		setCertificateField(vars, rotation.name, "private_key", rotation.newPrivateKey)
+		setCertificateField(vars, rotation.name, "ca", bundle)
+
+		for _, name := range signed {
+			definition, ok := certificateVariable(variables, name)
+			if !ok || definition.Options["ca"] != rotation.name || definition.Options["is_ca"] == true || strings.TrimSpace(certificateField(vars[name], "ca")) != trusted {
+				delete(vars, name)
+				continue
+			}
+
+			certificate, err := generateCertificate(definition, newCA)
+			if err != nil {
+				return fmt.Errorf("could not sign %s with the new CA %s: %s", name, rotation.name, err)
+			}
+			setCertificateField(vars, name, "certificate", certificate.Certificate)
+			setCertificateField(vars, name, "private_key", certificate.PrivateKey)
+			setCertificateField(vars, name, "ca", bundle)
+		}
+	}
+
+	return writeVarsStore(varsStorePath, vars)
+}
+
+// retireOldCAs is the last step of a CA rotation in two deploys: once every
+// instance was updated with the certificates signed by the new CA, the CAs
+// and the certificates they signed stop trusting the old CA.
+func retireOldCAs(varsStorePath string, names []string) error {
+	vars, err := readVarsStore(varsStorePath)
+	if err != nil {
+		return err
+	}
+
+	for _, name := range names {
+		value, ok := vars[name]
+		if !ok {
+			return fmt.Errorf("could not retire the old CA of %s, it is not in the vars store", name)
+		}
+
+		certificatePEM := certificateField(value, "certificate")
+		trusted := strings.TrimSpace(certificateField(value, "ca"))
+		if !isCA(value) || trusted == strings.TrimSpace(certificatePEM) || !strings.HasPrefix(trusted, strings.TrimSpace(certificatePEM)) {
+			return fmt.Errorf("could not retire the old CA of %s, it is not a CA that trusts an old CA next to itself", name)
+		}
+
+		for varName, varValue := range vars {
+			if strings.TrimSpace(certificateField(varValue, "ca")) == trusted {
+				setCertificateField(vars, varName, "ca", certificatePEM)
+			}
+		}
+	}
+
+	return writeVarsStore(varsStorePath, vars)
+}
+
+// certificateVariable returns the definition of the certificate variable with
+// the name in the manifest variables.
+func certificateVariable(variables []bosh.Variable, name string) (bosh.Variable, bool) {
+	for _, variable := range variables {
+		if variable.Name == name && variable.Type == "certificate" {
+			return variable, true
+		}
+	}
+
+	return bosh.Variable{}, false
+}
+
+// generateCertificate generates the certificate of the definition with the
+// generator of the BOSH CLI, signed by the CA when the definition has one.
+func generateCertificate(definition bosh.Variable, ca *signingCA) (cfgtypes.CertResponse, error) {
+	var loader cfgtypes.CertsLoader
+	if ca != nil {
+		loader = ca
+	}
+
+	certificate, err := cfgtypes.NewCertificateGenerator(loader).Generate(definition.Options)
+	if err != nil {
+		return cfgtypes.CertResponse{}, err
+	}
+
+	return certificate.(cfgtypes.CertResponse), nil
+}
+
+// signingCA loads the new certificate of a rotated CA for the certificate
+// generator of the BOSH CLI.
+type signingCA struct {
+	name        string
+	certificate *x509.Certificate
+	privateKey  *rsa.PrivateKey
+}
+
+func (s *signingCA) LoadCerts(name string) (*x509.Certificate, *rsa.PrivateKey, error) {
+	if name != s.name {
+		return nil, nil, fmt.Errorf("could not load CA %s, only %s is rotated", name, s.name)
+	}
+
+	return s.certificate, s.privateKey, nil
+}
+
+func parseCA(rotation caRotation) (*signingCA, error) {
+	certificateBlock, _ := pem.Decode([]byte(rotation.newCertificate))
+	privateKeyBlock, _ := pem.Decode([]byte(rotation.newPrivateKey))
+	if certificateBlock == nil || privateKeyBlock == nil {
+		return nil, fmt.Errorf("could not parse the new CA %s", rotation.name)
+	}
+
+	certificate, err := x509.ParseCertificate(certificateBlock.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse the new CA %s: %s", rotation.name, err)
+	}
+
+	privateKey, err := x509.ParsePKCS1PrivateKey(privateKeyBlock.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse the private key of the new CA %s: %s", rotation.name, err)
+	}
+
+	return &signingCA{name: rotation.name, certificate: certificate, privateKey: privateKey}, nil
+}
+
+func isCA(value interface{}) bool {
+	block, _ := pem.Decode([]byte(certificateField(value, "certificate")))
+	if block == nil {
+		return false
+	}
+
+	certificate, err := x509.ParseCertificate(block.Bytes)
+	return err == nil && certificate.IsCA
+}
+
+func setCertificateField(vars map[string]interface{}, name, field, fieldValue string) {
+	if certificate, ok := vars[name].(map[interface{}]interface{}); ok {
+		certificate[field] = fieldValue
+	}
+}
+
+func readVarsStore(varsStorePath string) (map[string]interface{}, error) {
+	contents, err := os.ReadFile(varsStorePath)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseVarsStore(contents)
+}
+
+func writeVarsStore(varsStorePath string, vars map[string]interface{}) error {
+	varsStoreBytes, err := yaml.Marshal(vars)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(varsStorePath, varsStoreBytes, 0600)
+}
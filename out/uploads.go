@@ -0,0 +1,125 @@
+package out
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/cloudfoundry/bosh-deployment-resource/bosh"
+	"github.com/cloudfoundry/bosh-deployment-resource/concourse"
+)
+
+// pendingUpload is a release or stemcell to upload. upload returns the name to
+// report it under in the metadata.
+type pendingUpload struct {
+	value  string
+	upload func(director bosh.Director) (string, error)
+}
+
+// runUploads uploads up to concurrency releases and stemcells at a time, and
+// reports them in the metadata in the order they were given.
+func (c OutCommand) runUploads(uploads []pendingUpload, concurrency int) ([]concourse.Metadata, error) {
+	metadata := make([]concourse.Metadata, len(uploads))
+
+	if concurrency <= 1 {
+		for i, u := range uploads {
+			name, err := u.upload(c.director)
+			if err != nil {
+				return nil, err
+			}
+			metadata[i] = concourse.Metadata{Name: name, Value: u.value}
+		}
+		return metadata, nil
+	}
+
+	errs := make([]error, len(uploads))
+	semaphore := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+
+	for i, u := range uploads {
+		wg.Add(1)
+		go func(i int, u pendingUpload) {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+
+			name, err := u.upload(c.director.WithOutputPrefix(fmt.Sprintf("[%s] ", u.value)))
+			metadata[i] = concourse.Metadata{Name: name, Value: u.value}
+			errs[i] = err
+		}(i, u)
+	}
+	wg.Wait()
+
+	failures := []string{}
+	for _, err := range errs {
+		if err != nil {
+			failures = append(failures, err.Error())
+		}
+	}
+	if len(failures) > 0 {
+		return nil, errors.New(strings.Join(failures, "\n"))
+	}
+
+	return metadata, nil
+}
+
+// releaseUpload uploads a release tarball, or has the director fetch the
+// release itself when only its url is known. Releases the director already
+// has are skipped.
+func releaseUpload(release bosh.Release, dryRun bool) pendingUpload {
+	return pendingUpload{
+		value: fmt.Sprintf("%s v%s", release.Name, release.Version),
+		upload: func(director bosh.Director) (string, error) {
+			needsUpload, err := director.ReleaseNeedsUpload(release)
+			if err != nil {
+				return "", err
+			}
+			if !needsUpload || dryRun {
+				return uploadMetadataName("release", dryRun, needsUpload), nil
+			}
+
+			if release.URL != "" {
+				err = director.UploadRemoteRelease(release.URL, release.Name, release.Version, release.Sha1)
+			} else {
+				err = director.UploadRelease(release.FilePath)
+			}
+			return uploadMetadataName("release", dryRun, needsUpload), err
+		},
+	}
+}
+
+func stemcellUpload(stemcell bosh.Stemcell, dryRun bool) pendingUpload {
+	return pendingUpload{
+		value: fmt.Sprintf("%s v%s", stemcell.Name, stemcell.Version),
+		upload: func(director bosh.Director) (string, error) {
+			needsUpload, err := director.StemcellNeedsUpload(stemcell)
+			if err != nil {
+				return "", err
+			}
+			if !needsUpload || dryRun {
+				return uploadMetadataName("stemcell", dryRun, needsUpload), nil
+			}
+
+			if stemcell.URL != "" {
+				err = director.UploadRemoteStemcell(stemcell.URL, stemcell.Name, stemcell.Version, stemcell.Sha1)
+			} else {
+				err = director.UploadStemcell(stemcell.FilePath)
+			}
+			return uploadMetadataName("stemcell", dryRun, needsUpload), err
+		},
+	}
+}
+
+// uploadMetadataName reports artifacts the director already had, and those a
+// dry run skipped uploading, under different names so they are not mistaken
+// for ones that were uploaded.
+func uploadMetadataName(kind string, dryRun, needsUpload bool) string {
+	if !needsUpload {
+		return kind + "_already_uploaded"
+	}
+	if dryRun {
+		return kind + "_to_upload"
+	}
+	return kind
+}
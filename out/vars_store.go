@@ -0,0 +1,215 @@
+package out
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/cloudfoundry/bosh-deployment-resource/concourse"
+	"github.com/cloudfoundry/bosh-deployment-resource/storage"
+)
+
+// varsStoreUploadAttempts is how often the vars store is merged with the
+// changes of other deploys and uploaded again before giving up.
+const varsStoreUploadAttempts = 5
+
+// uploadVarsStore uploads the vars store written by the deploy. When another
+// deploy changed the vars store since it was downloaded, it re-reads the vars
+// store, merges in the variables this deploy changed and tries again.
+func (c OutCommand) uploadVarsStore(varsStorePath string, downloaded []byte) error {
+	base := downloaded
+	for attempt := 1; ; attempt++ {
+		err := c.storageClient.Upload(varsStorePath)
+		if err == nil || !storage.IsConflict(err) {
+			return err
+		}
+		if attempt == varsStoreUploadAttempts {
+			return fmt.Errorf("could not upload the vars store after %d attempts: %s", attempt, err)
+		}
+
+		ours, err := os.ReadFile(varsStorePath)
+		if err != nil {
+			return err
+		}
+
+		theirs, err := c.downloadVarsStore()
+		if err != nil {
+			return err
+		}
+
+		merged, err := mergeVarsStore(base, ours, theirs)
+		if err != nil {
+			return err
+		}
+
+		if err := os.WriteFile(varsStorePath, merged, 0600); err != nil {
+			return err
+		}
+		base = theirs
+	}
+}
+
+// downloadVarsStore reads the current vars store, which also makes it the
+// revision the next upload is conditional on.
+func (c OutCommand) downloadVarsStore() ([]byte, error) {
+	varsStoreFile, err := os.CreateTemp("", "vars-store")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(varsStoreFile.Name()) //nolint:errcheck
+	defer varsStoreFile.Close()           //nolint:errcheck
+
+	if err := c.storageClient.DownloadReadOnly(varsStoreFile.Name()); err != nil {
+		return nil, err
+	}
+
+	return os.ReadFile(varsStoreFile.Name())
+}
+
+// varsStoreChanges reports the variables that were added, changed or removed
+// between before and after with their types, never with their values.
+func varsStoreChanges(before, after []byte) ([]concourse.Metadata, error) {
+	beforeVars, err := parseVarsStore(before)
+	if err != nil {
+		return nil, err
+	}
+	afterVars, err := parseVarsStore(after)
+	if err != nil {
+		return nil, err
+	}
+
+	metadata := []concourse.Metadata{}
+	for _, name := range changedVariables(beforeVars, afterVars) {
+		beforeValue, inBefore := beforeVars[name]
+		afterValue, inAfter := afterVars[name]
+
+		switch {
+		case !inBefore:
+			metadata = append(metadata, concourse.Metadata{Name: "added_variable", Value: fmt.Sprintf("%s (%s)", name, variableType(afterValue))})
+		case !inAfter:
+			metadata = append(metadata, concourse.Metadata{Name: "removed_variable", Value: fmt.Sprintf("%s (%s)", name, variableType(beforeValue))})
+		default:
+			metadata = append(metadata, concourse.Metadata{Name: "changed_variable", Value: fmt.Sprintf("%s (%s)", name, variableType(afterValue))})
+		}
+	}
+
+	return metadata, nil
+}
+
+// variableType guesses the type the variable was generated with from the
+// fields of its value.
+func variableType(value interface{}) string {
+	fields, ok := value.(map[interface{}]interface{})
+	if !ok {
+		if _, ok := value.(string); ok {
+			return "password"
+		}
+		return "value"
+	}
+
+	switch {
+	case fields["certificate"] != nil:
+		return "certificate"
+	case fields["public_key_fingerprint"] != nil:
+		return "ssh"
+	case fields["private_key"] != nil && fields["public_key"] != nil:
+		return "rsa"
+	default:
+		return "value"
+	}
+}
+
+// restoreVarsStoreRevision replaces the downloaded vars store with one of its
+// kept revisions.
+func (c OutCommand) restoreVarsStoreRevision(id, varsStorePath string) error {
+	revisions, err := c.storageClient.Revisions()
+	if err != nil {
+		return err
+	}
+
+	for _, revision := range revisions {
+		if revision == id {
+			return c.storageClient.DownloadRevision(id, varsStorePath)
+		}
+	}
+
+	if len(revisions) == 0 {
+		return fmt.Errorf("could not restore vars store revision %s, no revisions are kept", id)
+	}
+	return fmt.Errorf("could not restore vars store revision %s, the kept revisions are: %s", id, strings.Join(revisions, ", "))
+}
+
+// mergeVarsStore applies the variables that were added, changed or removed
+// between base and ours to theirs. It fails when theirs changed one of those
+// variables to a different value as well.
+func mergeVarsStore(base, ours, theirs []byte) ([]byte, error) {
+	baseVars, err := parseVarsStore(base)
+	if err != nil {
+		return nil, err
+	}
+	ourVars, err := parseVarsStore(ours)
+	if err != nil {
+		return nil, err
+	}
+	theirVars, err := parseVarsStore(theirs)
+	if err != nil {
+		return nil, err
+	}
+
+	conflicts := []string{}
+	for _, name := range changedVariables(baseVars, ourVars) {
+		ourValue, ours := ourVars[name]
+		theirValue, theirs := theirVars[name]
+		baseValue, inBase := baseVars[name]
+
+		theirsChanged := theirs != inBase || !reflect.DeepEqual(theirValue, baseValue)
+		if theirsChanged && (theirs != ours || !reflect.DeepEqual(theirValue, ourValue)) {
+			conflicts = append(conflicts, name)
+			continue
+		}
+
+		if ours {
+			theirVars[name] = ourValue
+		} else {
+			delete(theirVars, name)
+		}
+	}
+
+	if len(conflicts) > 0 {
+		return nil, fmt.Errorf("could not merge the vars store, another deploy changed the same variables to different values: %s", strings.Join(conflicts, ", "))
+	}
+
+	return yaml.Marshal(theirVars)
+}
+
+// changedVariables returns the sorted names of the variables that were added,
+// changed or removed between from and to.
+func changedVariables(from, to map[string]interface{}) []string {
+	names := []string{}
+	for name, value := range to {
+		if fromValue, ok := from[name]; !ok || !reflect.DeepEqual(fromValue, value) {
+			names = append(names, name)
+		}
+	}
+	for name := range from {
+		if _, ok := to[name]; !ok {
+			names = append(names, name)
+		}
+	}
+
+	sort.Strings(names)
+	return names
+}
+
+func parseVarsStore(contents []byte) (map[string]interface{}, error) {
+	vars := map[string]interface{}{}
+	if err := yaml.Unmarshal(contents, &vars); err != nil {
+		return nil, fmt.Errorf("could not parse the vars store: %s", err)
+	}
+
+	return vars, nil
+}
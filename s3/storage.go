@@ -0,0 +1,383 @@
+package s3
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/kms"
+	awss3 "github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+
+	"github.com/cloudfoundry/bosh-deployment-resource/encryption"
+	"github.com/cloudfoundry/bosh-deployment-resource/storage/provider"
+)
+
+const defaultRegion = "us-east-1"
+
+type Config struct {
+	Bucket    string
+	Key       string
+	Region    string
+	Endpoint  string
+	PathStyle bool
+
+	// CredentialsSource is "static" to use the access key, or
+	// "env_or_profile" to use the environment or the instance profile.
+	CredentialsSource string
+	AccessKeyID       string
+	SecretAccessKey   string
+	SessionToken      string
+
+	// ServerSideEncryption is "AES256" or "aws:kms", with SSEKMSKeyID
+	// selecting a KMS key other than the default one.
+	ServerSideEncryption string
+	SSEKMSKeyID          string
+
+	// Versioning requires versioning to be enabled on the bucket, so every
+	// upload keeps the previous vars store as an older version.
+	Versioning bool
+
+	// Revisions is how many uploads of the vars store are kept as copies
+	// next to it, tagged with Build.
+	Revisions int
+	Build     string
+}
+
+type Storage struct {
+	bucket               string
+	objectPath           string
+	serverSideEncryption string
+	sseKMSKeyID          string
+	versioning           bool
+	revisions            int
+	build                string
+	client               s3iface.S3API
+	etag                 *etag
+}
+
+// etag is the ETag of the vars store that was downloaded last, which the
+// next upload of the vars store is conditional on.
+type etag struct {
+	known bool
+	value string
+}
+
+func NewStorage(config Config) (Storage, error) {
+	if config.Bucket == "" || config.Key == "" {
+		return Storage{}, errors.New("bucket and key are required")
+	}
+
+	if config.Revisions < 0 {
+		return Storage{}, fmt.Errorf("revisions must not be negative got: %d", config.Revisions)
+	}
+
+	switch config.ServerSideEncryption {
+	case "", awss3.ServerSideEncryptionAes256, awss3.ServerSideEncryptionAwsKms:
+	default:
+		return Storage{}, fmt.Errorf("server_side_encryption only supports 'AES256' or 'aws:kms' got: %s", config.ServerSideEncryption)
+	}
+
+	awsSession, err := newSession(config)
+	if err != nil {
+		return Storage{}, err
+	}
+
+	s3Config := aws.NewConfig().WithS3ForcePathStyle(config.PathStyle)
+	if config.Endpoint != "" {
+		s3Config = s3Config.WithEndpoint(config.Endpoint)
+	}
+
+	return Storage{
+		bucket:               config.Bucket,
+		objectPath:           config.Key,
+		serverSideEncryption: config.ServerSideEncryption,
+		sseKMSKeyID:          config.SSEKMSKeyID,
+		versioning:           config.Versioning,
+		revisions:            config.Revisions,
+		build:                config.Build,
+		client:               awss3.New(awsSession, s3Config),
+		etag:                 &etag{},
+	}, nil
+}
+
+// NewKMSCipher returns a cipher for the vars store that wraps its keys with
+// the KMS key, using the region and credentials of the config.
+func NewKMSCipher(config Config, keyID string) (encryption.Cipher, error) {
+	awsSession, err := newSession(config)
+	if err != nil {
+		return nil, err
+	}
+
+	return encryption.NewKMSCipher(kms.New(awsSession), keyID), nil
+}
+
+func newSession(config Config) (*session.Session, error) {
+	region := config.Region
+	if region == "" {
+		region = defaultRegion
+	}
+
+	awsConfig := aws.NewConfig().WithRegion(region)
+
+	switch config.CredentialsSource {
+	case "", "static":
+		if config.AccessKeyID == "" || config.SecretAccessKey == "" {
+			return nil, errors.New("access_key_id and secret_access_key are required for static credentials")
+		}
+		awsConfig = awsConfig.WithCredentials(credentials.NewStaticCredentials(
+			config.AccessKeyID, config.SecretAccessKey, config.SessionToken))
+	case "env_or_profile":
+	default:
+		return nil, fmt.Errorf("credentials_source only supports 'static' or 'env_or_profile' got: %s", config.CredentialsSource)
+	}
+
+	return session.NewSession(awsConfig)
+}
+
+func (s Storage) Download(filePath string) error {
+	if err := s.DownloadReadOnly(filePath); err != nil {
+		return err
+	}
+
+	if s.versioning {
+		if err := s.checkVersioning(); err != nil {
+			return err
+		}
+	}
+
+	// Check that we can not only read the file, but can also write it
+	return s.write(filePath)
+}
+
+// DownloadReadOnly fetches the vars store without verifying that it can be
+// written, and leaves filePath untouched if the vars store does not exist yet.
+func (s Storage) DownloadReadOnly(filePath string) error {
+	objectETag, err := s.download(s.objectPath, filePath)
+	if err != nil {
+		if isNotFound(err) {
+			*s.etag = etag{known: true}
+			return nil
+		}
+
+		return fmt.Errorf("Can not read %s in bucket %s: %s", s.objectPath, s.bucket, err) //nolint:staticcheck
+	}
+
+	*s.etag = etag{known: true, value: objectETag}
+	return nil
+}
+
+// Upload writes the vars store, unless it was changed since it was last
+// downloaded, in which case it returns provider.ErrConflict. The upload is
+// kept as a revision when revisions are configured.
+func (s Storage) Upload(filePath string) error {
+	if err := s.write(filePath); err != nil {
+		return err
+	}
+
+	return s.keepRevision(filePath)
+}
+
+func (s Storage) write(filePath string) error {
+	input, err := s.putObjectInput(s.objectPath, filePath)
+	if err != nil {
+		return err
+	}
+
+	request, output := s.client.PutObjectRequest(input)
+	if s.etag.known {
+		if s.etag.value == "" {
+			request.HTTPRequest.Header.Set("If-None-Match", "*")
+		} else {
+			request.HTTPRequest.Header.Set("If-Match", s.etag.value)
+		}
+	}
+
+	if err := request.Send(); err != nil {
+		if isConflict(err) {
+			return fmt.Errorf("Can not write to %s in bucket %s: %w", s.objectPath, s.bucket, provider.ErrConflict) //nolint:staticcheck
+		}
+		return fmt.Errorf("Can not write to %s in bucket %s: %s", s.objectPath, s.bucket, err) //nolint:staticcheck
+	}
+
+	*s.etag = etag{known: true, value: aws.StringValue(output.ETag)}
+	return nil
+}
+
+func (s Storage) DownloadObject(suffix, filePath string) error {
+	if _, err := s.download(s.objectPath+suffix, filePath); err != nil {
+		return fmt.Errorf("Can not read %s in bucket %s: %s", s.objectPath+suffix, s.bucket, err) //nolint:staticcheck
+	}
+
+	return nil
+}
+
+func (s Storage) UploadObject(suffix, filePath string) error {
+	return s.upload(s.objectPath+suffix, filePath)
+}
+
+// Revisions returns the IDs of the kept revisions of the vars store, oldest
+// first.
+func (s Storage) Revisions() ([]string, error) {
+	prefix := s.objectPath + provider.RevisionSuffix
+	revisions := []string{}
+	err := s.client.ListObjectsV2Pages(&awss3.ListObjectsV2Input{
+		Bucket: aws.String(s.bucket),
+		Prefix: aws.String(prefix),
+	}, func(page *awss3.ListObjectsV2Output, _ bool) bool {
+		for _, object := range page.Contents {
+			revisions = append(revisions, strings.TrimPrefix(aws.StringValue(object.Key), prefix))
+		}
+		return true
+	})
+	if err != nil {
+		return nil, fmt.Errorf("Can not list the revisions of %s in bucket %s: %s", s.objectPath, s.bucket, err) //nolint:staticcheck
+	}
+
+	provider.SortRevisions(revisions)
+	return revisions, nil
+}
+
+func (s Storage) DownloadRevision(id, filePath string) error {
+	return s.DownloadObject(provider.RevisionSuffix+id, filePath)
+}
+
+// Close does nothing, the S3 storage keeps no local state.
+func (s Storage) Close() error {
+	return nil
+}
+
+// keepRevision copies the uploaded vars store to a new revision, and deletes
+// the oldest revisions beyond the ones to keep.
+func (s Storage) keepRevision(filePath string) error {
+	if s.revisions == 0 {
+		return nil
+	}
+
+	input, err := s.putObjectInput(s.objectPath+provider.RevisionSuffix+provider.NewRevisionID(time.Now()), filePath)
+	if err != nil {
+		return err
+	}
+	if s.build != "" {
+		input.Metadata = map[string]*string{provider.BuildMetadataKey: aws.String(s.build)}
+	}
+	if _, err := s.client.PutObject(input); err != nil {
+		return fmt.Errorf("Can not write to %s in bucket %s: %s", aws.StringValue(input.Key), s.bucket, err) //nolint:staticcheck
+	}
+
+	revisions, err := s.Revisions()
+	if err != nil {
+		return err
+	}
+
+	for len(revisions) > s.revisions {
+		objectPath := s.objectPath + provider.RevisionSuffix + revisions[0]
+		_, err := s.client.DeleteObject(&awss3.DeleteObjectInput{
+			Bucket: aws.String(s.bucket),
+			Key:    aws.String(objectPath),
+		})
+		if err != nil {
+			return fmt.Errorf("Can not delete %s in bucket %s: %s", objectPath, s.bucket, err) //nolint:staticcheck
+		}
+		revisions = revisions[1:]
+	}
+
+	return nil
+}
+
+// download writes the object to filePath and returns its ETag.
+func (s Storage) download(objectPath, filePath string) (string, error) {
+	output, err := s.client.GetObject(&awss3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(objectPath),
+	})
+	if err != nil {
+		return "", err
+	}
+	defer output.Body.Close() //nolint:errcheck
+
+	responseBytes, err := io.ReadAll(output.Body)
+	if err != nil {
+		return "", err
+	}
+
+	return aws.StringValue(output.ETag), os.WriteFile(filePath, responseBytes, 0600)
+}
+
+func (s Storage) upload(objectPath, filePath string) error {
+	input, err := s.putObjectInput(objectPath, filePath)
+	if err != nil {
+		return err
+	}
+
+	if _, err = s.client.PutObject(input); err != nil {
+		return fmt.Errorf("Can not write to %s in bucket %s: %s", objectPath, s.bucket, err) //nolint:staticcheck
+	}
+
+	return nil
+}
+
+func (s Storage) putObjectInput(objectPath, filePath string) (*awss3.PutObjectInput, error) {
+	contents, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	input := &awss3.PutObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(objectPath),
+		Body:   bytes.NewReader(contents),
+	}
+	if s.serverSideEncryption != "" {
+		input.ServerSideEncryption = aws.String(s.serverSideEncryption)
+	}
+	if s.sseKMSKeyID != "" {
+		input.SSEKMSKeyId = aws.String(s.sseKMSKeyID)
+	}
+
+	return input, nil
+}
+
+// checkVersioning fails when versioning is not enabled on the bucket. The
+// versioning of a bucket is left to its owner rather than changed by a put.
+func (s Storage) checkVersioning() error {
+	versioning, err := s.client.GetBucketVersioning(&awss3.GetBucketVersioningInput{
+		Bucket: aws.String(s.bucket),
+	})
+	if err != nil {
+		return fmt.Errorf("Can not read the versioning of bucket %s: %s", s.bucket, err) //nolint:staticcheck
+	}
+
+	if aws.StringValue(versioning.Status) != awss3.BucketVersioningStatusEnabled {
+		return fmt.Errorf("versioning is not enabled on bucket %s, enable it or remove versioning from the config", s.bucket)
+	}
+
+	return nil
+}
+
+func isNotFound(err error) bool {
+	return hasStatusCode(err, http.StatusNotFound)
+}
+
+// isConflict reports whether a conditional write failed. S3 returns 409 when
+// a concurrent conditional write to the same object is still in progress.
+func isConflict(err error) bool {
+	return hasStatusCode(err, http.StatusPreconditionFailed) || hasStatusCode(err, http.StatusConflict)
+}
+
+func hasStatusCode(err error, statusCode int) bool {
+	var requestFailure awserr.RequestFailure
+	if errors.As(err, &requestFailure) {
+		return requestFailure.StatusCode() == statusCode
+	}
+	return false
+}
@@ -0,0 +1,337 @@
+package s3_test
+
+import (
+	"crypto/md5"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry/bosh-deployment-resource/s3"
+	"github.com/cloudfoundry/bosh-deployment-resource/storage/provider"
+)
+
+// fakeS3 serves path style requests for the objects of a single bucket.
+type fakeS3 struct {
+	lock       sync.Mutex
+	objects    map[string]string
+	etags      map[string]string
+	headers    map[string]http.Header
+	versioning string
+}
+
+func (f *fakeS3) put(key, object string) {
+	f.objects[key] = object
+	f.etags[key] = fmt.Sprintf(`"%x"`, md5.Sum([]byte(object)))
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	key := strings.TrimPrefix(r.URL.Path, "/some-bucket/")
+
+	switch {
+	case r.URL.Query().Has("versioning") && r.Method == http.MethodGet:
+		w.Write([]byte(`<VersioningConfiguration><Status>` + f.versioning + `</Status></VersioningConfiguration>`)) //nolint:errcheck
+	case r.URL.Query().Has("list-type"):
+		keys := []string{}
+		for objectKey := range f.objects {
+			if strings.HasPrefix(objectKey, r.URL.Query().Get("prefix")) {
+				keys = append(keys, objectKey)
+			}
+		}
+		sort.Strings(keys)
+
+		w.Write([]byte(`<ListBucketResult><IsTruncated>false</IsTruncated>`)) //nolint:errcheck
+		for _, objectKey := range keys {
+			w.Write([]byte(`<Contents><Key>` + objectKey + `</Key></Contents>`)) //nolint:errcheck
+		}
+		w.Write([]byte(`</ListBucketResult>`)) //nolint:errcheck
+	case r.Method == http.MethodDelete:
+		delete(f.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == http.MethodGet:
+		object, ok := f.objects[key]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`<Error><Code>NoSuchKey</Code></Error>`)) //nolint:errcheck
+			return
+		}
+		w.Header().Set("ETag", f.etags[key])
+		w.Write([]byte(object)) //nolint:errcheck
+	case r.Method == http.MethodPut:
+		_, exists := f.objects[key]
+		ifMatch := r.Header.Get("If-Match")
+		if (ifMatch != "" && ifMatch != f.etags[key]) || (r.Header.Get("If-None-Match") == "*" && exists) {
+			w.WriteHeader(http.StatusPreconditionFailed)
+			w.Write([]byte(`<Error><Code>PreconditionFailed</Code></Error>`)) //nolint:errcheck
+			return
+		}
+
+		body, _ := io.ReadAll(r.Body) //nolint:errcheck
+		f.put(key, string(body))
+		f.headers[key] = r.Header
+		w.Header().Set("ETag", f.etags[key])
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+var _ = Describe("Storage", func() {
+	var (
+		fake     *fakeS3
+		server   *httptest.Server
+		config   s3.Config
+		filePath string
+	)
+
+	BeforeEach(func() {
+		fake = &fakeS3{objects: map[string]string{}, etags: map[string]string{}, headers: map[string]http.Header{}}
+		server = httptest.NewServer(fake)
+
+		config = s3.Config{
+			Bucket:          "some-bucket",
+			Key:             "path/to/vars-store.yml",
+			Endpoint:        server.URL,
+			PathStyle:       true,
+			AccessKeyID:     "some-key-id",
+			SecretAccessKey: "some-secret",
+		}
+
+		file, err := os.CreateTemp("", "vars-store")
+		Expect(err).NotTo(HaveOccurred())
+		file.Close() //nolint:errcheck
+		filePath = file.Name()
+	})
+
+	AfterEach(func() {
+		server.Close()
+		os.Remove(filePath) //nolint:errcheck
+	})
+
+	Describe("Download", func() {
+		It("downloads the vars store and checks that it can be written", func() {
+			fake.put("path/to/vars-store.yml", "password: secret")
+			storage, err := s3.NewStorage(config)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(storage.Download(filePath)).To(Succeed())
+
+			contents, err := os.ReadFile(filePath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(contents)).To(Equal("password: secret"))
+			Expect(fake.headers).To(HaveKey("path/to/vars-store.yml"))
+		})
+
+		Context("when versioning is required", func() {
+			BeforeEach(func() {
+				config.Versioning = true
+			})
+
+			It("downloads the vars store when versioning is enabled on the bucket", func() {
+				fake.versioning = "Enabled"
+				storage, err := s3.NewStorage(config)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(storage.Download(filePath)).To(Succeed())
+			})
+
+			It("fails without changing the bucket when versioning is not enabled", func() {
+				fake.versioning = "Suspended"
+				storage, err := s3.NewStorage(config)
+				Expect(err).NotTo(HaveOccurred())
+
+				err = storage.Download(filePath)
+				Expect(err).To(MatchError("versioning is not enabled on bucket some-bucket, enable it or remove versioning from the config"))
+				Expect(fake.versioning).To(Equal("Suspended"))
+				Expect(fake.objects).To(BeEmpty())
+			})
+		})
+	})
+
+	Describe("DownloadReadOnly", func() {
+		It("leaves the file untouched when there is no vars store yet", func() {
+			Expect(os.WriteFile(filePath, []byte("untouched"), 0600)).To(Succeed())
+			storage, err := s3.NewStorage(config)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(storage.DownloadReadOnly(filePath)).To(Succeed())
+
+			contents, err := os.ReadFile(filePath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(contents)).To(Equal("untouched"))
+			Expect(fake.objects).To(BeEmpty())
+		})
+	})
+
+	Describe("Upload", func() {
+		It("uploads the vars store with server side encryption", func() {
+			config.ServerSideEncryption = "aws:kms"
+			config.SSEKMSKeyID = "some-kms-key"
+			Expect(os.WriteFile(filePath, []byte("password: new-secret"), 0600)).To(Succeed())
+			storage, err := s3.NewStorage(config)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(storage.Upload(filePath)).To(Succeed())
+
+			Expect(fake.objects["path/to/vars-store.yml"]).To(Equal("password: new-secret"))
+			headers := fake.headers["path/to/vars-store.yml"]
+			Expect(headers.Get("X-Amz-Server-Side-Encryption")).To(Equal("aws:kms"))
+			Expect(headers.Get("X-Amz-Server-Side-Encryption-Aws-Kms-Key-Id")).To(Equal("some-kms-key"))
+		})
+	})
+
+	Describe("Upload after a download", func() {
+		var storage s3.Storage
+
+		BeforeEach(func() {
+			var err error
+			storage, err = s3.NewStorage(config)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("uploads the vars store when it did not change", func() {
+			fake.put("path/to/vars-store.yml", "password: secret")
+			Expect(storage.Download(filePath)).To(Succeed())
+			Expect(fake.headers["path/to/vars-store.yml"].Get("If-Match")).To(Equal(fmt.Sprintf(`"%x"`, md5.Sum([]byte("password: secret")))))
+
+			Expect(os.WriteFile(filePath, []byte("password: secret\nother: new"), 0600)).To(Succeed())
+			Expect(storage.Upload(filePath)).To(Succeed())
+			Expect(fake.objects["path/to/vars-store.yml"]).To(Equal("password: secret\nother: new"))
+		})
+
+		It("returns a conflict when the vars store changed since the download", func() {
+			fake.put("path/to/vars-store.yml", "password: secret")
+			Expect(storage.DownloadReadOnly(filePath)).To(Succeed())
+			fake.put("path/to/vars-store.yml", "password: changed")
+
+			err := storage.Upload(filePath)
+			Expect(errors.Is(err, provider.ErrConflict)).To(BeTrue())
+			Expect(fake.objects["path/to/vars-store.yml"]).To(Equal("password: changed"))
+		})
+
+		It("returns a conflict when the vars store was created since the download", func() {
+			Expect(storage.DownloadReadOnly(filePath)).To(Succeed())
+			fake.put("path/to/vars-store.yml", "password: created")
+
+			err := storage.Upload(filePath)
+			Expect(errors.Is(err, provider.ErrConflict)).To(BeTrue())
+		})
+	})
+
+	Describe("Revisions", func() {
+		var storage s3.Storage
+
+		BeforeEach(func() {
+			config.Revisions = 2
+			config.Build = "main/deploy/deploy-cf/42"
+
+			var err error
+			storage, err = s3.NewStorage(config)
+			Expect(err).NotTo(HaveOccurred())
+
+			fake.put("path/to/vars-store.yml.revision-20260101T000000Z", "password: oldest")
+			fake.put("path/to/vars-store.yml.revision-20260102T000000Z", "password: old")
+		})
+
+		It("keeps the uploads as revisions tagged with the build and deletes the oldest ones", func() {
+			Expect(os.WriteFile(filePath, []byte("password: new"), 0600)).To(Succeed())
+			Expect(storage.Upload(filePath)).To(Succeed())
+
+			revisions, err := storage.Revisions()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(revisions).To(HaveLen(2))
+			Expect(revisions[0]).To(Equal("20260102T000000Z"))
+			Expect(revisions[1]).To(MatchRegexp(`^\d{8}T\d{6}\.\d{9}Z$`))
+
+			newest := "path/to/vars-store.yml.revision-" + revisions[1]
+			Expect(fake.objects[newest]).To(Equal("password: new"))
+			Expect(fake.headers[newest].Get("X-Amz-Meta-Concourse-Build")).To(Equal("main/deploy/deploy-cf/42"))
+			Expect(fake.objects).NotTo(HaveKey("path/to/vars-store.yml.revision-20260101T000000Z"))
+		})
+
+		It("does not keep a revision when checking that the vars store can be written", func() {
+			fake.put("path/to/vars-store.yml", "password: secret")
+			Expect(storage.Download(filePath)).To(Succeed())
+
+			Expect(storage.Revisions()).To(Equal([]string{"20260101T000000Z", "20260102T000000Z"}))
+		})
+
+		It("downloads a revision", func() {
+			Expect(storage.DownloadRevision("20260101T000000Z", filePath)).To(Succeed())
+
+			contents, err := os.ReadFile(filePath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(contents)).To(Equal("password: oldest"))
+
+			Expect(storage.DownloadRevision("20250101T000000Z", filePath)).To(MatchError(ContainSubstring("Can not read path/to/vars-store.yml.revision-20250101T000000Z in bucket some-bucket")))
+		})
+
+		It("does not keep revisions by default", func() {
+			config.Revisions = 0
+			storage, err := s3.NewStorage(config)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(os.WriteFile(filePath, []byte("password: new"), 0600)).To(Succeed())
+			Expect(storage.Upload(filePath)).To(Succeed())
+			Expect(fake.objects).To(HaveLen(3))
+		})
+	})
+
+	Describe("UploadObject and DownloadObject", func() {
+		It("stores the object next to the vars store", func() {
+			Expect(os.WriteFile(filePath, []byte("plan"), 0600)).To(Succeed())
+			storage, err := s3.NewStorage(config)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(storage.UploadObject(".plan", filePath)).To(Succeed())
+			Expect(fake.objects).To(HaveKey("path/to/vars-store.yml.plan"))
+
+			Expect(storage.DownloadObject(".plan", filePath)).To(Succeed())
+			Expect(storage.DownloadObject(".missing", filePath)).To(MatchError(ContainSubstring("Can not read path/to/vars-store.yml.missing in bucket some-bucket")))
+		})
+	})
+
+	Describe("NewStorage", func() {
+		It("requires the bucket and key", func() {
+			config.Key = ""
+			_, err := s3.NewStorage(config)
+			Expect(err).To(MatchError("bucket and key are required"))
+		})
+
+		It("rejects unknown credential sources", func() {
+			config.CredentialsSource = "vault"
+			_, err := s3.NewStorage(config)
+			Expect(err).To(MatchError("credentials_source only supports 'static' or 'env_or_profile' got: vault"))
+		})
+
+		It("does not require keys for the instance profile", func() {
+			config.CredentialsSource = "env_or_profile"
+			config.AccessKeyID = ""
+			config.SecretAccessKey = ""
+			_, err := s3.NewStorage(config)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("rejects a negative number of revisions", func() {
+			config.Revisions = -1
+			_, err := s3.NewStorage(config)
+			Expect(err).To(MatchError("revisions must not be negative got: -1"))
+		})
+
+		It("rejects unknown server side encryption", func() {
+			config.ServerSideEncryption = "rot13"
+			_, err := s3.NewStorage(config)
+			Expect(err).To(MatchError("server_side_encryption only supports 'AES256' or 'aws:kms' got: rot13"))
+		})
+	})
+})
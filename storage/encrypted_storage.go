@@ -0,0 +1,219 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/cloudfoundry/bosh-deployment-resource/encryption"
+	"github.com/cloudfoundry/bosh-deployment-resource/s3"
+)
+
+type EncryptionConfig struct {
+	Identity   string   `json:"identity"`
+	Recipients []string `json:"recipients"`
+	Passphrase string   `json:"passphrase"`
+	KMSKeyID   string   `json:"kms_key_id"`
+
+	// The region and credentials the KMS key is used with. The s3 provider
+	// uses its own when none are set.
+	KMSRegion            string `json:"kms_region"`
+	KMSCredentialsSource string `json:"kms_credentials_source"`
+	KMSAccessKeyID       string `json:"kms_access_key_id"`
+	KMSSecretAccessKey   string `json:"kms_secret_access_key"`
+	KMSSessionToken      string `json:"kms_session_token"`
+
+	// MigratePlaintext allows reading a vars store that is not encrypted
+	// yet, once, so it is stored encrypted by the next deploy.
+	MigratePlaintext bool `json:"migrate_plaintext"`
+}
+
+func (c *EncryptionConfig) migratesPlaintext() bool {
+	return c != nil && c.MigratePlaintext
+}
+
+// encryptedStorage decrypts the files it downloads and encrypts the files it
+// uploads, so the vars store is only ever stored encrypted. A vars store
+// that is not encrypted yet is refused, unless migratePlaintext allows
+// reading it as is to encrypt it on the next upload.
+type encryptedStorage struct {
+	client           StorageClient
+	cipher           encryption.Cipher
+	migratePlaintext bool
+}
+
+// newCipher returns the cipher for the encryption config, or nil when the
+// vars store is not encrypted. providerKMSConfig holds the AWS region and
+// credentials of the provider a KMS key is used with when the encryption
+// config has none, and is nil for providers outside of AWS.
+func newCipher(config *EncryptionConfig, providerKMSConfig *s3.Config) (encryption.Cipher, error) {
+	if config == nil {
+		return nil, nil
+	}
+
+	keys := 0
+	for _, key := range []string{config.Identity, config.Passphrase, config.KMSKeyID} {
+		if key != "" {
+			keys++
+		}
+	}
+	if keys != 1 {
+		return nil, errors.New("encryption needs exactly one of identity, passphrase or kms_key_id")
+	}
+	if len(config.Recipients) > 0 && config.Identity == "" {
+		return nil, errors.New("encryption.recipients can only be used with encryption.identity")
+	}
+
+	switch {
+	case config.Identity != "":
+		return encryption.NewX25519Cipher(config.Identity, config.Recipients)
+	case config.Passphrase != "":
+		return encryption.NewPassphraseCipher(config.Passphrase)
+	default:
+		kmsConfig, err := config.kmsConfig(providerKMSConfig)
+		if err != nil {
+			return nil, err
+		}
+		return s3.NewKMSCipher(kmsConfig, config.KMSKeyID)
+	}
+}
+
+// kmsConfig returns the region and credentials of the encryption config, or
+// those of the provider when the encryption config sets none.
+func (c *EncryptionConfig) kmsConfig(providerKMSConfig *s3.Config) (s3.Config, error) {
+	kmsConfig := s3.Config{
+		Region:            c.KMSRegion,
+		CredentialsSource: c.KMSCredentialsSource,
+		AccessKeyID:       c.KMSAccessKeyID,
+		SecretAccessKey:   c.KMSSecretAccessKey,
+		SessionToken:      c.KMSSessionToken,
+	}
+	if providerKMSConfig != nil && kmsConfig == (s3.Config{}) {
+		return *providerKMSConfig, nil
+	}
+
+	switch kmsConfig.CredentialsSource {
+	case "", "static":
+		if kmsConfig.AccessKeyID == "" || kmsConfig.SecretAccessKey == "" {
+			return s3.Config{}, errors.New("encryption.kms_access_key_id and encryption.kms_secret_access_key are required for static credentials")
+		}
+	case "env_or_profile":
+	default:
+		return s3.Config{}, fmt.Errorf("encryption.kms_credentials_source only supports 'static' or 'env_or_profile' got: %s", kmsConfig.CredentialsSource)
+	}
+
+	return kmsConfig, nil
+}
+
+// WithEncryption returns a client that encrypts everything the client stores
+// with the cipher, or the client itself when the cipher is nil. A file that
+// is not encrypted is only read when migratePlaintext is set.
+func WithEncryption(client StorageClient, cipher encryption.Cipher, migratePlaintext bool) StorageClient {
+	if cipher == nil {
+		return client
+	}
+
+	return encryptedStorage{client: client, cipher: cipher, migratePlaintext: migratePlaintext}
+}
+
+func (s encryptedStorage) Download(filePath string) error {
+	if err := s.client.Download(filePath); err != nil {
+		return err
+	}
+
+	return s.decrypt(filePath)
+}
+
+func (s encryptedStorage) DownloadReadOnly(filePath string) error {
+	if err := s.client.DownloadReadOnly(filePath); err != nil {
+		return err
+	}
+
+	return s.decrypt(filePath)
+}
+
+func (s encryptedStorage) Upload(filePath string) error {
+	return s.encrypted(filePath, s.client.Upload)
+}
+
+func (s encryptedStorage) DownloadObject(suffix, filePath string) error {
+	if err := s.client.DownloadObject(suffix, filePath); err != nil {
+		return err
+	}
+
+	return s.decrypt(filePath)
+}
+
+func (s encryptedStorage) UploadObject(suffix, filePath string) error {
+	return s.encrypted(filePath, func(encryptedPath string) error {
+		return s.client.UploadObject(suffix, encryptedPath)
+	})
+}
+
+func (s encryptedStorage) Revisions() ([]string, error) {
+	return s.client.Revisions()
+}
+
+func (s encryptedStorage) DownloadRevision(id, filePath string) error {
+	if err := s.client.DownloadRevision(id, filePath); err != nil {
+		return err
+	}
+
+	return s.decrypt(filePath)
+}
+
+func (s encryptedStorage) Close() error {
+	return s.client.Close()
+}
+
+func (s encryptedStorage) decrypt(filePath string) error {
+	contents, err := os.ReadFile(filePath)
+	if err != nil {
+		return err
+	}
+
+	// An empty file is a vars store that does not exist yet.
+	if len(contents) == 0 {
+		return nil
+	}
+
+	if !encryption.IsEncrypted(contents) {
+		if s.migratePlaintext {
+			return nil
+		}
+		return errors.New("the vars store is not encrypted, set encryption.migrate_plaintext once to encrypt it with the next deploy")
+	}
+
+	plaintext, err := s.cipher.Decrypt(contents)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(filePath, plaintext, 0600)
+}
+
+// encrypted calls upload with an encrypted copy of the file.
+func (s encryptedStorage) encrypted(filePath string, upload func(string) error) error {
+	contents, err := os.ReadFile(filePath)
+	if err != nil {
+		return err
+	}
+
+	ciphertext, err := s.cipher.Encrypt(contents)
+	if err != nil {
+		return err
+	}
+
+	encryptedFile, err := os.CreateTemp("", "encrypted")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(encryptedFile.Name()) //nolint:errcheck
+	defer encryptedFile.Close()           //nolint:errcheck
+
+	if _, err := encryptedFile.Write(ciphertext); err != nil {
+		return err
+	}
+
+	return upload(encryptedFile.Name())
+}
@@ -0,0 +1,164 @@
+package storage_test
+
+import (
+	"os"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry/bosh-deployment-resource/concourse"
+	"github.com/cloudfoundry/bosh-deployment-resource/encryption"
+	"github.com/cloudfoundry/bosh-deployment-resource/storage"
+	"github.com/cloudfoundry/bosh-deployment-resource/storage/storagefakes"
+)
+
+var _ = Describe("WithEncryption", func() {
+	var (
+		fakeClient *storagefakes.FakeStorageClient
+		cipher     encryption.Cipher
+		client     storage.StorageClient
+		stored     []byte
+		filePath   string
+	)
+
+	BeforeEach(func() {
+		identity, err := encryption.GenerateX25519Identity()
+		Expect(err).NotTo(HaveOccurred())
+		cipher, err = encryption.NewX25519Cipher(identity, nil)
+		Expect(err).NotTo(HaveOccurred())
+
+		stored = nil
+		fakeClient = new(storagefakes.FakeStorageClient)
+		fakeClient.DownloadStub = func(filePath string) error {
+			return os.WriteFile(filePath, stored, 0600)
+		}
+		fakeClient.UploadStub = func(filePath string) error {
+			stored, err = os.ReadFile(filePath)
+			return err
+		}
+		client = storage.WithEncryption(fakeClient, cipher, false)
+
+		file, err := os.CreateTemp("", "vars-store")
+		Expect(err).NotTo(HaveOccurred())
+		file.Close() //nolint:errcheck
+		filePath = file.Name()
+	})
+
+	AfterEach(func() {
+		os.Remove(filePath) //nolint:errcheck
+	})
+
+	It("uploads the vars store encrypted and downloads it decrypted", func() {
+		Expect(os.WriteFile(filePath, []byte("password: secret\n"), 0600)).To(Succeed())
+		Expect(client.Upload(filePath)).To(Succeed())
+
+		Expect(encryption.IsEncrypted(stored)).To(BeTrue())
+		Expect(string(stored)).NotTo(ContainSubstring("secret"))
+
+		Expect(os.WriteFile(filePath, nil, 0600)).To(Succeed())
+		Expect(client.Download(filePath)).To(Succeed())
+
+		contents, err := os.ReadFile(filePath)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(contents)).To(Equal("password: secret\n"))
+	})
+
+	It("refuses a vars store that is not encrypted", func() {
+		stored = []byte("password: secret\n")
+
+		err := client.Download(filePath)
+		Expect(err).To(MatchError("the vars store is not encrypted, set encryption.migrate_plaintext once to encrypt it with the next deploy"))
+	})
+
+	It("reads an empty vars store", func() {
+		Expect(client.Download(filePath)).To(Succeed())
+
+		contents, err := os.ReadFile(filePath)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(contents).To(BeEmpty())
+	})
+
+	Context("when migrating a vars store that is not encrypted", func() {
+		BeforeEach(func() {
+			client = storage.WithEncryption(fakeClient, cipher, true)
+		})
+
+		It("reads it as is and uploads it encrypted", func() {
+			stored = []byte("password: secret\n")
+
+			Expect(client.Download(filePath)).To(Succeed())
+
+			contents, err := os.ReadFile(filePath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(contents)).To(Equal("password: secret\n"))
+
+			Expect(client.Upload(filePath)).To(Succeed())
+			Expect(encryption.IsEncrypted(stored)).To(BeTrue())
+		})
+	})
+
+	It("decrypts the revisions of the vars store", func() {
+		Expect(os.WriteFile(filePath, []byte("password: secret\n"), 0600)).To(Succeed())
+		Expect(client.Upload(filePath)).To(Succeed())
+		fakeClient.RevisionsReturns([]string{"20261018T120000Z"}, nil)
+		fakeClient.DownloadRevisionStub = func(_, filePath string) error {
+			return os.WriteFile(filePath, stored, 0600)
+		}
+
+		Expect(client.Revisions()).To(Equal([]string{"20261018T120000Z"}))
+		Expect(client.DownloadRevision("20261018T120000Z", filePath)).To(Succeed())
+
+		contents, err := os.ReadFile(filePath)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(contents)).To(Equal("password: secret\n"))
+	})
+
+	It("returns the client itself without a cipher", func() {
+		Expect(storage.WithEncryption(fakeClient, nil, false)).To(BeIdenticalTo(fakeClient))
+	})
+
+	Describe("NewStorageClient", func() {
+		newClient := func(encryptionConfig map[string]interface{}) error {
+			_, err := storage.NewStorageClient(concourse.Source{
+				VarsStore: concourse.VarsStore{
+					Provider: "gcs",
+					Config: map[string]interface{}{
+						"json_key":   "{\"type\": \"service_account\"}",
+						"file_name":  "bar",
+						"bucket":     "baz",
+						"encryption": encryptionConfig,
+					},
+				},
+			}, "")
+			return err
+		}
+
+		It("accepts a passphrase", func() {
+			Expect(newClient(map[string]interface{}{"passphrase": "some-passphrase"})).To(Succeed())
+		})
+
+		It("requires exactly one key", func() {
+			err := newClient(map[string]interface{}{"passphrase": "some-passphrase", "kms_key_id": "some-key"})
+			Expect(err).To(MatchError("encryption needs exactly one of identity, passphrase or kms_key_id"))
+		})
+
+		It("accepts a KMS key with its own region and credentials", func() {
+			Expect(newClient(map[string]interface{}{
+				"kms_key_id":            "some-key",
+				"kms_region":            "eu-west-1",
+				"kms_access_key_id":     "some-access-key-id",
+				"kms_secret_access_key": "some-secret-access-key",
+			})).To(Succeed())
+		})
+
+		It("requires credentials for a KMS key outside of S3", func() {
+			err := newClient(map[string]interface{}{"kms_key_id": "some-key"})
+			Expect(err).To(MatchError("encryption.kms_access_key_id and encryption.kms_secret_access_key are required for static credentials"))
+		})
+
+		It("returns an error for an unknown KMS credentials source", func() {
+			err := newClient(map[string]interface{}{"kms_key_id": "some-key", "kms_credentials_source": "magic"})
+			Expect(err).To(MatchError("encryption.kms_credentials_source only supports 'static' or 'env_or_profile' got: magic"))
+		})
+	})
+})
@@ -0,0 +1,49 @@
+// Package provider holds what the vars store providers share. It is kept
+// apart from the storage package, which imports the providers.
+package provider
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// RevisionSuffix names the kept revisions of the vars store after it,
+	// followed by the ID of the revision, e.g.
+	// vars-store.yml.revision-20261018T120000.123456789Z.
+	RevisionSuffix = ".revision-"
+
+	// BuildMetadataKey tags a revision with the Concourse build that wrote it.
+	BuildMetadataKey = "concourse-build"
+
+	// revisionFormat has nanoseconds, so two uploads within the same second
+	// do not overwrite each other's revision. Revisions kept before had no
+	// fraction of a second, e.g. 20261018T120000Z.
+	revisionFormat = "20060102T150405.000000000Z"
+)
+
+// ErrConflict is returned by Upload when the vars store was changed by
+// someone else since it was downloaded.
+var ErrConflict = errors.New("the vars store was changed since it was downloaded")
+
+// NewRevisionID returns the ID of a revision kept at the time.
+func NewRevisionID(t time.Time) string {
+	return t.UTC().Format(revisionFormat)
+}
+
+// SortRevisions sorts revision IDs oldest first, including the IDs of
+// revisions kept without a fraction of a second.
+func SortRevisions(ids []string) {
+	sort.SliceStable(ids, func(i, j int) bool {
+		return revisionSortKey(ids[i]) < revisionSortKey(ids[j])
+	})
+}
+
+func revisionSortKey(id string) string {
+	if strings.HasSuffix(id, "Z") && !strings.Contains(id, ".") {
+		return strings.TrimSuffix(id, "Z") + ".000000000Z"
+	}
+	return id
+}
@@ -0,0 +1,43 @@
+package provider_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry/bosh-deployment-resource/storage/provider"
+)
+
+var _ = Describe("Revisions", func() {
+	Describe("NewRevisionID", func() {
+		It("names the revision after the time in UTC with nanoseconds", func() {
+			t := time.Date(2026, 10, 18, 14, 0, 0, 120, time.FixedZone("CEST", 2*60*60))
+			Expect(provider.NewRevisionID(t)).To(Equal("20261018T120000.000000120Z"))
+		})
+
+		It("names two revisions within the same second differently", func() {
+			t := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+			Expect(provider.NewRevisionID(t)).NotTo(Equal(provider.NewRevisionID(t.Add(time.Millisecond))))
+		})
+	})
+
+	Describe("SortRevisions", func() {
+		It("sorts the revisions oldest first, including the ones without a fraction of a second", func() {
+			ids := []string{
+				"20261018T120001Z",
+				"20261018T120000.500000000Z",
+				"20261018T120000Z",
+				"20261018T115959.999999999Z",
+			}
+
+			provider.SortRevisions(ids)
+			Expect(ids).To(Equal([]string{
+				"20261018T115959.999999999Z",
+				"20261018T120000Z",
+				"20261018T120000.500000000Z",
+				"20261018T120001Z",
+			}))
+		})
+	})
+})
@@ -2,40 +2,204 @@ package storage
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 
 	"github.com/cloudfoundry/bosh-deployment-resource/concourse"
 	"github.com/cloudfoundry/bosh-deployment-resource/gcp"
+	"github.com/cloudfoundry/bosh-deployment-resource/git"
+	"github.com/cloudfoundry/bosh-deployment-resource/s3"
+	"github.com/cloudfoundry/bosh-deployment-resource/storage/provider"
 )
 
 type GCSConfig struct {
 	FileName string `json:"file_name"`
 	Bucket   string `json:"bucket"`
 	JSONKey  string `json:"json_key"`
+
+	Revisions  int               `json:"revisions"`
+	Encryption *EncryptionConfig `json:"encryption"`
+}
+
+type S3Config struct {
+	Bucket               string `json:"bucket"`
+	Key                  string `json:"key"`
+	Region               string `json:"region"`
+	Endpoint             string `json:"endpoint"`
+	PathStyle            bool   `json:"path_style"`
+	CredentialsSource    string `json:"credentials_source"`
+	AccessKeyID          string `json:"access_key_id"`
+	SecretAccessKey      string `json:"secret_access_key"`
+	SessionToken         string `json:"session_token"`
+	ServerSideEncryption string `json:"server_side_encryption"`
+	SSEKMSKeyID          string `json:"sse_kms_key_id"`
+	Versioning           bool   `json:"versioning"`
+	Revisions            int    `json:"revisions"`
+
+	Encryption *EncryptionConfig `json:"encryption"`
 }
 
+type GitConfig struct {
+	URI                   string `json:"uri"`
+	Branch                string `json:"branch"`
+	File                  string `json:"file"`
+	PrivateKey            string `json:"private_key"`
+	KnownHosts            string `json:"known_hosts"`
+	InsecureIgnoreHostKey bool   `json:"insecure_ignore_host_key"`
+	Username              string `json:"username"`
+	Password              string `json:"password"`
+	AuthorName            string `json:"author_name"`
+	AuthorEmail           string `json:"author_email"`
+
+	Encryption *EncryptionConfig `json:"encryption"`
+}
+
+//go:generate counterfeiter . StorageClient
 type StorageClient interface {
 	Download(filePath string) error
+	DownloadReadOnly(filePath string) error
 	Upload(filePath string) error
+
+	// DownloadObject and UploadObject read and write files stored next to
+	// the vars store, under its name followed by suffix.
+	DownloadObject(suffix, filePath string) error
+	UploadObject(suffix, filePath string) error
+
+	// Revisions returns the IDs of the kept revisions of the vars store,
+	// oldest first, and DownloadRevision reads one of them.
+	Revisions() ([]string, error)
+	DownloadRevision(id, filePath string) error
+
+	// Close removes what the client keeps locally, e.g. the clone of a git
+	// repository.
+	Close() error
 }
 
-func NewStorageClient(source concourse.Source) (StorageClient, error) {
-	if source.VarsStore.Provider == "gcs" {
-		gcsConfigJson, err := json.Marshal(source.VarsStore.Config)
-		if err != nil {
-			return nil, err
-		}
+// NewStorageClient returns the client for the vars store provider of the
+// source, or nil when the source has no vars store. The revisions of the vars
+// store it keeps are tagged with the build.
+func NewStorageClient(source concourse.Source, build string) (StorageClient, error) {
+	switch source.VarsStore.Provider {
+	case "":
+		return nil, nil
+	case "gcs":
+		return newGCSClient(source.VarsStore.Config, build)
+	case "s3":
+		return newS3Client(source.VarsStore.Config, build)
+	case "git":
+		return newGitClient(source.VarsStore.Config, build)
+	}
+
+	return nil, fmt.Errorf("vars_store.provider only supports 'gcs', 's3' or 'git' got: %s", source.VarsStore.Provider)
+}
+
+func newGCSClient(config map[string]interface{}, build string) (StorageClient, error) {
+	gcsConfig := GCSConfig{}
+	if err := decodeConfig(config, &gcsConfig); err != nil {
+		return nil, err
+	}
+
+	cipher, err := newCipher(gcsConfig.Encryption, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := gcp.NewStorage(
+		gcsConfig.JSONKey,
+		gcsConfig.Bucket,
+		gcsConfig.FileName,
+		gcsConfig.Revisions,
+		build,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return WithEncryption(client, cipher, gcsConfig.Encryption.migratesPlaintext()), nil
+}
 
-		gcsConfig := GCSConfig{}
-		if err := json.Unmarshal(gcsConfigJson, &gcsConfig); err != nil {
-			return nil, err
-		}
+func newS3Client(config map[string]interface{}, build string) (StorageClient, error) {
+	s3Config := S3Config{}
+	if err := decodeConfig(config, &s3Config); err != nil {
+		return nil, err
+	}
+
+	providerConfig := s3.Config{
+		Bucket:               s3Config.Bucket,
+		Key:                  s3Config.Key,
+		Region:               s3Config.Region,
+		Endpoint:             s3Config.Endpoint,
+		PathStyle:            s3Config.PathStyle,
+		CredentialsSource:    s3Config.CredentialsSource,
+		AccessKeyID:          s3Config.AccessKeyID,
+		SecretAccessKey:      s3Config.SecretAccessKey,
+		SessionToken:         s3Config.SessionToken,
+		ServerSideEncryption: s3Config.ServerSideEncryption,
+		SSEKMSKeyID:          s3Config.SSEKMSKeyID,
+		Versioning:           s3Config.Versioning,
+		Revisions:            s3Config.Revisions,
+		Build:                build,
+	}
+
+	client, err := s3.NewStorage(providerConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	cipher, err := newCipher(s3Config.Encryption, &providerConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	return WithEncryption(client, cipher, s3Config.Encryption.migratesPlaintext()), nil
+}
+
+func newGitClient(config map[string]interface{}, build string) (StorageClient, error) {
+	gitConfig := GitConfig{}
+	if err := decodeConfig(config, &gitConfig); err != nil {
+		return nil, err
+	}
+
+	cipher, err := newCipher(gitConfig.Encryption, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := git.NewStorage(git.Config{
+		URI:                   gitConfig.URI,
+		Branch:                gitConfig.Branch,
+		File:                  gitConfig.File,
+		PrivateKey:            gitConfig.PrivateKey,
+		KnownHosts:            gitConfig.KnownHosts,
+		InsecureIgnoreHostKey: gitConfig.InsecureIgnoreHostKey,
+		Username:              gitConfig.Username,
+		Password:              gitConfig.Password,
+		AuthorName:            gitConfig.AuthorName,
+		AuthorEmail:           gitConfig.AuthorEmail,
+		Build:                 build,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return WithEncryption(client, cipher, gitConfig.Encryption.migratesPlaintext()), nil
+}
+
+// ErrConflict is returned by Upload when the vars store was changed by
+// someone else since it was downloaded.
+var ErrConflict = provider.ErrConflict
+
+// IsConflict reports whether an Upload failed because the vars store was
+// changed since it was downloaded.
+func IsConflict(err error) bool {
+	return errors.Is(err, ErrConflict)
+}
 
-		return gcp.NewStorage(
-			gcsConfig.JSONKey,
-			gcsConfig.Bucket,
-			gcsConfig.FileName,
-		)
+func decodeConfig(config map[string]interface{}, providerConfig interface{}) error {
+	configJson, err := json.Marshal(config)
+	if err != nil {
+		return err
 	}
 
-	return nil, nil
+	return json.Unmarshal(configJson, providerConfig)
 }
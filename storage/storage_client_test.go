@@ -1,11 +1,16 @@
 package storage_test
 
 import (
+	"errors"
+	"fmt"
+
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 
 	"github.com/cloudfoundry/bosh-deployment-resource/concourse"
 	"github.com/cloudfoundry/bosh-deployment-resource/gcp"
+	"github.com/cloudfoundry/bosh-deployment-resource/git"
+	"github.com/cloudfoundry/bosh-deployment-resource/s3"
 	"github.com/cloudfoundry/bosh-deployment-resource/storage"
 )
 
@@ -23,25 +28,104 @@ var _ = Describe("StorageClient", func() {
 					},
 				}
 
-				storageClient, err := storage.NewStorageClient(source)
+				storageClient, err := storage.NewStorageClient(source, "")
 				Expect(err).NotTo(HaveOccurred())
 				Expect(storageClient).To(BeAssignableToTypeOf(gcp.Storage{}))
 			})
 		})
 
-		Context("otherwise", func() {
-			It("returns nil", func() {
+		Context("when asking for an S3 client", func() {
+			It("returns an S3 client", func() {
 				source := concourse.Source{
 					VarsStore: concourse.VarsStore{
-						Provider: "unknown",
-						Config:   map[string]interface{}{},
+						Provider: "s3",
+						Config: map[string]interface{}{
+							"bucket":            "baz",
+							"key":               "bar",
+							"endpoint":          "https://minio.example.com",
+							"path_style":        true,
+							"access_key_id":     "some-key-id",
+							"secret_access_key": "some-secret",
+						},
+					},
+				}
+
+				storageClient, err := storage.NewStorageClient(source, "")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(storageClient).To(BeAssignableToTypeOf(s3.Storage{}))
+			})
+
+			It("returns an error when the config is invalid", func() {
+				source := concourse.Source{
+					VarsStore: concourse.VarsStore{
+						Provider: "s3",
+						Config:   map[string]interface{}{"bucket": "baz", "key": "bar"},
+					},
+				}
+
+				_, err := storage.NewStorageClient(source, "")
+				Expect(err).To(MatchError("access_key_id and secret_access_key are required for static credentials"))
+			})
+		})
+
+		Context("when asking for a git client", func() {
+			It("returns a git client", func() {
+				source := concourse.Source{
+					VarsStore: concourse.VarsStore{
+						Provider: "git",
+						Config: map[string]interface{}{
+							"uri":    "https://git.example.com/vars-stores.git",
+							"branch": "main",
+							"file":   "cf/vars-store.yml",
+						},
 					},
 				}
 
-				storageClient, err := storage.NewStorageClient(source)
+				storageClient, err := storage.NewStorageClient(source, "")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(storageClient).To(BeAssignableToTypeOf(git.Storage{}))
+			})
+
+			It("returns an error when the config is invalid", func() {
+				source := concourse.Source{
+					VarsStore: concourse.VarsStore{
+						Provider: "git",
+						Config:   map[string]interface{}{"uri": "https://git.example.com/vars-stores.git"},
+					},
+				}
+
+				_, err := storage.NewStorageClient(source, "")
+				Expect(err).To(MatchError("uri, branch and file are required"))
+			})
+		})
+
+		Context("when there is no vars store", func() {
+			It("returns nil", func() {
+				storageClient, err := storage.NewStorageClient(concourse.Source{}, "")
 				Expect(err).NotTo(HaveOccurred())
 				Expect(storageClient).To(BeNil())
 			})
 		})
+
+		Context("when the provider is unknown", func() {
+			It("returns an error", func() {
+				source := concourse.Source{
+					VarsStore: concourse.VarsStore{
+						Provider: "gsc",
+						Config:   map[string]interface{}{},
+					},
+				}
+
+				_, err := storage.NewStorageClient(source, "")
+				Expect(err).To(MatchError("vars_store.provider only supports 'gcs', 's3' or 'git' got: gsc"))
+			})
+		})
+	})
+
+	Describe("IsConflict", func() {
+		It("recognizes the conflicts of every provider", func() {
+			Expect(storage.IsConflict(fmt.Errorf("upload failed: %w", storage.ErrConflict))).To(BeTrue())
+			Expect(storage.IsConflict(errors.New("upload failed"))).To(BeFalse())
+		})
 	})
 })
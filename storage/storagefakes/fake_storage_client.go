@@ -1,4 +1,4 @@
-// This file was generated by counterfeiter
+// Code generated by counterfeiter. DO NOT EDIT.
 package storagefakes
 
 import (
@@ -8,37 +8,171 @@ import (
 )
 
 type FakeStorageClient struct {
-	DownloadStub        func(filePath string) error
+	CloseStub        func() error
+	closeMutex       sync.RWMutex
+	closeArgsForCall []struct {
+	}
+	closeReturns struct {
+		result1 error
+	}
+	closeReturnsOnCall map[int]struct {
+		result1 error
+	}
+	DownloadStub        func(string) error
 	downloadMutex       sync.RWMutex
 	downloadArgsForCall []struct {
-		filePath string
+		arg1 string
 	}
 	downloadReturns struct {
 		result1 error
 	}
-	UploadStub        func(filePath string) error
+	downloadReturnsOnCall map[int]struct {
+		result1 error
+	}
+	DownloadObjectStub        func(string, string) error
+	downloadObjectMutex       sync.RWMutex
+	downloadObjectArgsForCall []struct {
+		arg1 string
+		arg2 string
+	}
+	downloadObjectReturns struct {
+		result1 error
+	}
+	downloadObjectReturnsOnCall map[int]struct {
+		result1 error
+	}
+	DownloadReadOnlyStub        func(string) error
+	downloadReadOnlyMutex       sync.RWMutex
+	downloadReadOnlyArgsForCall []struct {
+		arg1 string
+	}
+	downloadReadOnlyReturns struct {
+		result1 error
+	}
+	downloadReadOnlyReturnsOnCall map[int]struct {
+		result1 error
+	}
+	DownloadRevisionStub        func(string, string) error
+	downloadRevisionMutex       sync.RWMutex
+	downloadRevisionArgsForCall []struct {
+		arg1 string
+		arg2 string
+	}
+	downloadRevisionReturns struct {
+		result1 error
+	}
+	downloadRevisionReturnsOnCall map[int]struct {
+		result1 error
+	}
+	RevisionsStub        func() ([]string, error)
+	revisionsMutex       sync.RWMutex
+	revisionsArgsForCall []struct {
+	}
+	revisionsReturns struct {
+		result1 []string
+		result2 error
+	}
+	revisionsReturnsOnCall map[int]struct {
+		result1 []string
+		result2 error
+	}
+	UploadStub        func(string) error
 	uploadMutex       sync.RWMutex
 	uploadArgsForCall []struct {
-		filePath string
+		arg1 string
 	}
 	uploadReturns struct {
 		result1 error
 	}
+	uploadReturnsOnCall map[int]struct {
+		result1 error
+	}
+	UploadObjectStub        func(string, string) error
+	uploadObjectMutex       sync.RWMutex
+	uploadObjectArgsForCall []struct {
+		arg1 string
+		arg2 string
+	}
+	uploadObjectReturns struct {
+		result1 error
+	}
+	uploadObjectReturnsOnCall map[int]struct {
+		result1 error
+	}
 	invocations      map[string][][]interface{}
 	invocationsMutex sync.RWMutex
 }
 
-func (fake *FakeStorageClient) Download(filePath string) error {
+func (fake *FakeStorageClient) Close() error {
+	fake.closeMutex.Lock()
+	ret, specificReturn := fake.closeReturnsOnCall[len(fake.closeArgsForCall)]
+	fake.closeArgsForCall = append(fake.closeArgsForCall, struct {
+	}{})
+	stub := fake.CloseStub
+	fakeReturns := fake.closeReturns
+	fake.recordInvocation("Close", []interface{}{})
+	fake.closeMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeStorageClient) CloseCallCount() int {
+	fake.closeMutex.RLock()
+	defer fake.closeMutex.RUnlock()
+	return len(fake.closeArgsForCall)
+}
+
+func (fake *FakeStorageClient) CloseCalls(stub func() error) {
+	fake.closeMutex.Lock()
+	defer fake.closeMutex.Unlock()
+	fake.CloseStub = stub
+}
+
+func (fake *FakeStorageClient) CloseReturns(result1 error) {
+	fake.closeMutex.Lock()
+	defer fake.closeMutex.Unlock()
+	fake.CloseStub = nil
+	fake.closeReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeStorageClient) CloseReturnsOnCall(i int, result1 error) {
+	fake.closeMutex.Lock()
+	defer fake.closeMutex.Unlock()
+	fake.CloseStub = nil
+	if fake.closeReturnsOnCall == nil {
+		fake.closeReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.closeReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeStorageClient) Download(arg1 string) error {
 	fake.downloadMutex.Lock()
+	ret, specificReturn := fake.downloadReturnsOnCall[len(fake.downloadArgsForCall)]
 	fake.downloadArgsForCall = append(fake.downloadArgsForCall, struct {
-		filePath string
-	}{filePath})
-	fake.recordInvocation("Download", []interface{}{filePath})
+		arg1 string
+	}{arg1})
+	stub := fake.DownloadStub
+	fakeReturns := fake.downloadReturns
+	fake.recordInvocation("Download", []interface{}{arg1})
 	fake.downloadMutex.Unlock()
-	if fake.DownloadStub != nil {
-		return fake.DownloadStub(filePath)
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
 	}
-	return fake.downloadReturns.result1
+	return fakeReturns.result1
 }
 
 func (fake *FakeStorageClient) DownloadCallCount() int {
@@ -47,30 +181,300 @@ func (fake *FakeStorageClient) DownloadCallCount() int {
 	return len(fake.downloadArgsForCall)
 }
 
+func (fake *FakeStorageClient) DownloadCalls(stub func(string) error) {
+	fake.downloadMutex.Lock()
+	defer fake.downloadMutex.Unlock()
+	fake.DownloadStub = stub
+}
+
 func (fake *FakeStorageClient) DownloadArgsForCall(i int) string {
 	fake.downloadMutex.RLock()
 	defer fake.downloadMutex.RUnlock()
-	return fake.downloadArgsForCall[i].filePath
+	argsForCall := fake.downloadArgsForCall[i]
+	return argsForCall.arg1
 }
 
 func (fake *FakeStorageClient) DownloadReturns(result1 error) {
+	fake.downloadMutex.Lock()
+	defer fake.downloadMutex.Unlock()
 	fake.DownloadStub = nil
 	fake.downloadReturns = struct {
 		result1 error
 	}{result1}
 }
 
-func (fake *FakeStorageClient) Upload(filePath string) error {
+func (fake *FakeStorageClient) DownloadReturnsOnCall(i int, result1 error) {
+	fake.downloadMutex.Lock()
+	defer fake.downloadMutex.Unlock()
+	fake.DownloadStub = nil
+	if fake.downloadReturnsOnCall == nil {
+		fake.downloadReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.downloadReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeStorageClient) DownloadObject(arg1 string, arg2 string) error {
+	fake.downloadObjectMutex.Lock()
+	ret, specificReturn := fake.downloadObjectReturnsOnCall[len(fake.downloadObjectArgsForCall)]
+	fake.downloadObjectArgsForCall = append(fake.downloadObjectArgsForCall, struct {
+		arg1 string
+		arg2 string
+	}{arg1, arg2})
+	stub := fake.DownloadObjectStub
+	fakeReturns := fake.downloadObjectReturns
+	fake.recordInvocation("DownloadObject", []interface{}{arg1, arg2})
+	fake.downloadObjectMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeStorageClient) DownloadObjectCallCount() int {
+	fake.downloadObjectMutex.RLock()
+	defer fake.downloadObjectMutex.RUnlock()
+	return len(fake.downloadObjectArgsForCall)
+}
+
+func (fake *FakeStorageClient) DownloadObjectCalls(stub func(string, string) error) {
+	fake.downloadObjectMutex.Lock()
+	defer fake.downloadObjectMutex.Unlock()
+	fake.DownloadObjectStub = stub
+}
+
+func (fake *FakeStorageClient) DownloadObjectArgsForCall(i int) (string, string) {
+	fake.downloadObjectMutex.RLock()
+	defer fake.downloadObjectMutex.RUnlock()
+	argsForCall := fake.downloadObjectArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeStorageClient) DownloadObjectReturns(result1 error) {
+	fake.downloadObjectMutex.Lock()
+	defer fake.downloadObjectMutex.Unlock()
+	fake.DownloadObjectStub = nil
+	fake.downloadObjectReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeStorageClient) DownloadObjectReturnsOnCall(i int, result1 error) {
+	fake.downloadObjectMutex.Lock()
+	defer fake.downloadObjectMutex.Unlock()
+	fake.DownloadObjectStub = nil
+	if fake.downloadObjectReturnsOnCall == nil {
+		fake.downloadObjectReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.downloadObjectReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeStorageClient) DownloadReadOnly(arg1 string) error {
+	fake.downloadReadOnlyMutex.Lock()
+	ret, specificReturn := fake.downloadReadOnlyReturnsOnCall[len(fake.downloadReadOnlyArgsForCall)]
+	fake.downloadReadOnlyArgsForCall = append(fake.downloadReadOnlyArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.DownloadReadOnlyStub
+	fakeReturns := fake.downloadReadOnlyReturns
+	fake.recordInvocation("DownloadReadOnly", []interface{}{arg1})
+	fake.downloadReadOnlyMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeStorageClient) DownloadReadOnlyCallCount() int {
+	fake.downloadReadOnlyMutex.RLock()
+	defer fake.downloadReadOnlyMutex.RUnlock()
+	return len(fake.downloadReadOnlyArgsForCall)
+}
+
+func (fake *FakeStorageClient) DownloadReadOnlyCalls(stub func(string) error) {
+	fake.downloadReadOnlyMutex.Lock()
+	defer fake.downloadReadOnlyMutex.Unlock()
+	fake.DownloadReadOnlyStub = stub
+}
+
+func (fake *FakeStorageClient) DownloadReadOnlyArgsForCall(i int) string {
+	fake.downloadReadOnlyMutex.RLock()
+	defer fake.downloadReadOnlyMutex.RUnlock()
+	argsForCall := fake.downloadReadOnlyArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeStorageClient) DownloadReadOnlyReturns(result1 error) {
+	fake.downloadReadOnlyMutex.Lock()
+	defer fake.downloadReadOnlyMutex.Unlock()
+	fake.DownloadReadOnlyStub = nil
+	fake.downloadReadOnlyReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeStorageClient) DownloadReadOnlyReturnsOnCall(i int, result1 error) {
+	fake.downloadReadOnlyMutex.Lock()
+	defer fake.downloadReadOnlyMutex.Unlock()
+	fake.DownloadReadOnlyStub = nil
+	if fake.downloadReadOnlyReturnsOnCall == nil {
+		fake.downloadReadOnlyReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.downloadReadOnlyReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeStorageClient) DownloadRevision(arg1 string, arg2 string) error {
+	fake.downloadRevisionMutex.Lock()
+	ret, specificReturn := fake.downloadRevisionReturnsOnCall[len(fake.downloadRevisionArgsForCall)]
+	fake.downloadRevisionArgsForCall = append(fake.downloadRevisionArgsForCall, struct {
+		arg1 string
+		arg2 string
+	}{arg1, arg2})
+	stub := fake.DownloadRevisionStub
+	fakeReturns := fake.downloadRevisionReturns
+	fake.recordInvocation("DownloadRevision", []interface{}{arg1, arg2})
+	fake.downloadRevisionMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeStorageClient) DownloadRevisionCallCount() int {
+	fake.downloadRevisionMutex.RLock()
+	defer fake.downloadRevisionMutex.RUnlock()
+	return len(fake.downloadRevisionArgsForCall)
+}
+
+func (fake *FakeStorageClient) DownloadRevisionCalls(stub func(string, string) error) {
+	fake.downloadRevisionMutex.Lock()
+	defer fake.downloadRevisionMutex.Unlock()
+	fake.DownloadRevisionStub = stub
+}
+
+func (fake *FakeStorageClient) DownloadRevisionArgsForCall(i int) (string, string) {
+	fake.downloadRevisionMutex.RLock()
+	defer fake.downloadRevisionMutex.RUnlock()
+	argsForCall := fake.downloadRevisionArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeStorageClient) DownloadRevisionReturns(result1 error) {
+	fake.downloadRevisionMutex.Lock()
+	defer fake.downloadRevisionMutex.Unlock()
+	fake.DownloadRevisionStub = nil
+	fake.downloadRevisionReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeStorageClient) DownloadRevisionReturnsOnCall(i int, result1 error) {
+	fake.downloadRevisionMutex.Lock()
+	defer fake.downloadRevisionMutex.Unlock()
+	fake.DownloadRevisionStub = nil
+	if fake.downloadRevisionReturnsOnCall == nil {
+		fake.downloadRevisionReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.downloadRevisionReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeStorageClient) Revisions() ([]string, error) {
+	fake.revisionsMutex.Lock()
+	ret, specificReturn := fake.revisionsReturnsOnCall[len(fake.revisionsArgsForCall)]
+	fake.revisionsArgsForCall = append(fake.revisionsArgsForCall, struct {
+	}{})
+	stub := fake.RevisionsStub
+	fakeReturns := fake.revisionsReturns
+	fake.recordInvocation("Revisions", []interface{}{})
+	fake.revisionsMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeStorageClient) RevisionsCallCount() int {
+	fake.revisionsMutex.RLock()
+	defer fake.revisionsMutex.RUnlock()
+	return len(fake.revisionsArgsForCall)
+}
+
+func (fake *FakeStorageClient) RevisionsCalls(stub func() ([]string, error)) {
+	fake.revisionsMutex.Lock()
+	defer fake.revisionsMutex.Unlock()
+	fake.RevisionsStub = stub
+}
+
+func (fake *FakeStorageClient) RevisionsReturns(result1 []string, result2 error) {
+	fake.revisionsMutex.Lock()
+	defer fake.revisionsMutex.Unlock()
+	fake.RevisionsStub = nil
+	fake.revisionsReturns = struct {
+		result1 []string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeStorageClient) RevisionsReturnsOnCall(i int, result1 []string, result2 error) {
+	fake.revisionsMutex.Lock()
+	defer fake.revisionsMutex.Unlock()
+	fake.RevisionsStub = nil
+	if fake.revisionsReturnsOnCall == nil {
+		fake.revisionsReturnsOnCall = make(map[int]struct {
+			result1 []string
+			result2 error
+		})
+	}
+	fake.revisionsReturnsOnCall[i] = struct {
+		result1 []string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeStorageClient) Upload(arg1 string) error {
 	fake.uploadMutex.Lock()
+	ret, specificReturn := fake.uploadReturnsOnCall[len(fake.uploadArgsForCall)]
 	fake.uploadArgsForCall = append(fake.uploadArgsForCall, struct {
-		filePath string
-	}{filePath})
-	fake.recordInvocation("Upload", []interface{}{filePath})
+		arg1 string
+	}{arg1})
+	stub := fake.UploadStub
+	fakeReturns := fake.uploadReturns
+	fake.recordInvocation("Upload", []interface{}{arg1})
 	fake.uploadMutex.Unlock()
-	if fake.UploadStub != nil {
-		return fake.UploadStub(filePath)
+	if stub != nil {
+		return stub(arg1)
 	}
-	return fake.uploadReturns.result1
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
 }
 
 func (fake *FakeStorageClient) UploadCallCount() int {
@@ -79,27 +483,128 @@ func (fake *FakeStorageClient) UploadCallCount() int {
 	return len(fake.uploadArgsForCall)
 }
 
+func (fake *FakeStorageClient) UploadCalls(stub func(string) error) {
+	fake.uploadMutex.Lock()
+	defer fake.uploadMutex.Unlock()
+	fake.UploadStub = stub
+}
+
 func (fake *FakeStorageClient) UploadArgsForCall(i int) string {
 	fake.uploadMutex.RLock()
 	defer fake.uploadMutex.RUnlock()
-	return fake.uploadArgsForCall[i].filePath
+	argsForCall := fake.uploadArgsForCall[i]
+	return argsForCall.arg1
 }
 
 func (fake *FakeStorageClient) UploadReturns(result1 error) {
+	fake.uploadMutex.Lock()
+	defer fake.uploadMutex.Unlock()
 	fake.UploadStub = nil
 	fake.uploadReturns = struct {
 		result1 error
 	}{result1}
 }
 
+func (fake *FakeStorageClient) UploadReturnsOnCall(i int, result1 error) {
+	fake.uploadMutex.Lock()
+	defer fake.uploadMutex.Unlock()
+	fake.UploadStub = nil
+	if fake.uploadReturnsOnCall == nil {
+		fake.uploadReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.uploadReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeStorageClient) UploadObject(arg1 string, arg2 string) error {
+	fake.uploadObjectMutex.Lock()
+	ret, specificReturn := fake.uploadObjectReturnsOnCall[len(fake.uploadObjectArgsForCall)]
+	fake.uploadObjectArgsForCall = append(fake.uploadObjectArgsForCall, struct {
+		arg1 string
+		arg2 string
+	}{arg1, arg2})
+	stub := fake.UploadObjectStub
+	fakeReturns := fake.uploadObjectReturns
+	fake.recordInvocation("UploadObject", []interface{}{arg1, arg2})
+	fake.uploadObjectMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeStorageClient) UploadObjectCallCount() int {
+	fake.uploadObjectMutex.RLock()
+	defer fake.uploadObjectMutex.RUnlock()
+	return len(fake.uploadObjectArgsForCall)
+}
+
+func (fake *FakeStorageClient) UploadObjectCalls(stub func(string, string) error) {
+	fake.uploadObjectMutex.Lock()
+	defer fake.uploadObjectMutex.Unlock()
+	fake.UploadObjectStub = stub
+}
+
+func (fake *FakeStorageClient) UploadObjectArgsForCall(i int) (string, string) {
+	fake.uploadObjectMutex.RLock()
+	defer fake.uploadObjectMutex.RUnlock()
+	argsForCall := fake.uploadObjectArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeStorageClient) UploadObjectReturns(result1 error) {
+	fake.uploadObjectMutex.Lock()
+	defer fake.uploadObjectMutex.Unlock()
+	fake.UploadObjectStub = nil
+	fake.uploadObjectReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeStorageClient) UploadObjectReturnsOnCall(i int, result1 error) {
+	fake.uploadObjectMutex.Lock()
+	defer fake.uploadObjectMutex.Unlock()
+	fake.UploadObjectStub = nil
+	if fake.uploadObjectReturnsOnCall == nil {
+		fake.uploadObjectReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.uploadObjectReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *FakeStorageClient) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
+	fake.closeMutex.RLock()
+	defer fake.closeMutex.RUnlock()
 	fake.downloadMutex.RLock()
 	defer fake.downloadMutex.RUnlock()
+	fake.downloadObjectMutex.RLock()
+	defer fake.downloadObjectMutex.RUnlock()
+	fake.downloadReadOnlyMutex.RLock()
+	defer fake.downloadReadOnlyMutex.RUnlock()
+	fake.downloadRevisionMutex.RLock()
+	defer fake.downloadRevisionMutex.RUnlock()
+	fake.revisionsMutex.RLock()
+	defer fake.revisionsMutex.RUnlock()
 	fake.uploadMutex.RLock()
 	defer fake.uploadMutex.RUnlock()
-	return fake.invocations
+	fake.uploadObjectMutex.RLock()
+	defer fake.uploadObjectMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
 }
 
 func (fake *FakeStorageClient) recordInvocation(key string, args []interface{}) {